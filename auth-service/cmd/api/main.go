@@ -1,28 +1,43 @@
 package main
 
 import (
-	_ "errors"
+	"context"
+	"errors"
 	_ "fmt"
 	"github.com/Frozz164/forum-app_v2/auth-service/config"
 	"github.com/Frozz164/forum-app_v2/auth-service/handlers"
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
 	"github.com/Frozz164/forum-app_v2/auth-service/internal/migrations"
 	"github.com/Frozz164/forum-app_v2/auth-service/internal/repository"
 	"github.com/Frozz164/forum-app_v2/auth-service/internal/service"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/blobstore"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/health"
 	"github.com/Frozz164/forum-app_v2/auth-service/pkg/helper"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/metrics"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/middleware"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/notifier"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/ratelimit"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/requestid"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/tracing"
 	"github.com/gin-contrib/cors"
 	"github.com/gin-gonic/gin"
 	_ "github.com/lib/pq"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"net/http"
 	"os"
+	"os/signal"
 	"strconv"
+	"syscall"
 	"time"
 )
 
 func init() {
 	zerolog.TimeFieldFormat = time.RFC3339
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
-	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr})
+	log.Logger = log.Output(zerolog.ConsoleWriter{Out: os.Stderr}).Hook(tracing.LogHook{}, requestid.LogHook{})
 }
 
 func maskToken(token string) string {
@@ -71,38 +86,155 @@ func main() {
 	}
 	log.Info().Msg("Migrations completed successfully")
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "auth-service",
+		Exporter:    cfg.Tracing.Exporter,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up tracing")
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Failed to flush traces")
+		}
+	}()
+
+	registry := metrics.NewRegistry()
+	metrics.RegisterDB(registry, db, cfg.Database.Name)
+
 	authRepo := repository.NewAuthRepositoryImpl(db)
-	authService := service.NewAuthServiceImpl(authRepo, cfg)
+	sanctionRepo := repository.NewSanctionRepositoryImpl(db)
+	auditStore := audit.NewStore(db)
+	avatarStore, err := blobstore.New(cfg.Avatars.Storage)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize avatar storage")
+	}
+	var limitStore ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "postgres":
+		limitStore = ratelimit.NewPostgresStore(db)
+	case "memory":
+		limitStore = ratelimit.NewMemoryStore()
+	default:
+		log.Fatal().Str("store", cfg.RateLimit.Store).Msg("Unknown RATE_LIMIT_STORE, expected memory or postgres")
+	}
+
+	authService := service.NewAuthServiceImpl(authRepo, sanctionRepo, auditStore, cfg, metrics.NewLoginCounter(registry))
+	sanctionService := service.NewSanctionServiceImpl(sanctionRepo, authRepo, auditStore)
 	authHandler := handlers.NewAuthServiceHandler(cfg, authService)
+	avatarHandler := handlers.NewAvatarHandler(service.NewAvatarServiceImpl(authRepo, avatarStore, cfg.Avatars.MaxBytes), cfg.Avatars.MaxBytes)
+	sanctionHandler := handlers.NewSanctionHandler(sanctionService, notifier.NewForumNotifier(cfg.ForumServiceURL))
+
+	checker := health.NewChecker()
+	checker.Add("database", health.PingDB(db))
+	checker.Add("migrations", func(ctx context.Context) error {
+		return migrations.CheckVersion(ctx, db)
+	})
 
 	router := gin.New()
+	// Без списка доверенных прокси gin верит X-Forwarded-For от любого
+	// клиента, и c.ClientIP() (ключ лимитов и аудита) можно подделать.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
+	router.Use(requestid.Middleware())
+	router.Use(tracing.Middleware("auth-service"))
 	router.Use(ginLoggerMiddleware())
-	router.Use(gin.Recovery())
+	router.Use(metrics.HTTP(registry))
+	router.Use(gin.CustomRecovery(problem.Recover))
+	router.Use(audit.Middleware())
 
 	// Настройка CORS
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", requestid.Header},
+		ExposeHeaders:    []string{"Content-Length", requestid.Header, "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 		MaxAge:           12 * time.Hour,
 	}))
+	router.NoRoute(problem.NoRoute)
+	router.GET("/healthz", checker.Live)
+	router.GET("/readyz", checker.Ready)
+	router.GET("/metrics", metrics.Handler(registry))
+
+	// Бан и смена роли действуют сразу, а не после истечения уже выданного JWT.
+	rejectBanned := sanctionHandler.RejectBanned()
+	currentRole := authHandler.CurrentRole()
+
 	api := router.Group("/api/v1")
 	{
-		api.POST("/register", authHandler.Register)
-		api.POST("/login", authHandler.Login)
+		api.POST("/register", ratelimit.Middleware(limitStore, cfg.RateLimit.Register), authHandler.Register)
+		api.POST("/login", ratelimit.Middleware(limitStore, cfg.RateLimit.Login), authHandler.Login)
 		api.GET("/validate", authHandler.Validate)
+		api.GET("/me", middleware.RequireAuth(cfg.JWT.SecretKey), rejectBanned, authHandler.Profile)
+		// Через /me/sanctions forum-service узнаёт о бане, поэтому забаненным он доступен.
+		api.GET("/me/sanctions", middleware.RequireAuth(cfg.JWT.SecretKey), sanctionHandler.Active)
+		api.PUT("/me/avatar", middleware.RequireAuth(cfg.JWT.SecretKey), rejectBanned, avatarHandler.Upload)
+		api.DELETE("/me/avatar", middleware.RequireAuth(cfg.JWT.SecretKey), rejectBanned, avatarHandler.Remove)
+		api.GET("/avatars/:id", avatarHandler.Get)
+		// Только для вошедших и с лимитом: иначе по именам можно перебрать всех пользователей.
+		api.GET("/users", middleware.RequireAuth(cfg.JWT.SecretKey), ratelimit.Middleware(limitStore, cfg.RateLimit.Lookup), authHandler.LookupUsers)
+	}
+
+	moderation := router.Group("/api/v1/moderation")
+	moderation.Use(middleware.RequireAuth(cfg.JWT.SecretKey), rejectBanned, currentRole, middleware.RequireRole(domain.RoleModerator, domain.RoleAdmin))
+	{
+		moderation.POST("/users/:id/sanctions", sanctionHandler.Issue)
+		moderation.GET("/users/:id/sanctions", sanctionHandler.List)
+		moderation.DELETE("/sanctions/:id", sanctionHandler.Revoke)
+	}
+
+	admin := router.Group("/api/v1/admin")
+	admin.Use(middleware.RequireAuth(cfg.JWT.SecretKey), rejectBanned, currentRole, middleware.RequireRole(domain.RoleAdmin))
+	{
+		admin.PUT("/users/:id/role", authHandler.ChangeRole)
+		admin.GET("/audit", audit.QueryHandler(auditStore))
 	}
 
 	router.Static("/static", "../web")
 	router.StaticFile("/", "../web/index.html")
 
 	addr := ":" + cfg.Port
-	log.Info().Str("address", addr).Msg("Starting auth service")
-	if err := router.Run(addr); err != nil {
+	srv := &http.Server{
+		Addr:    addr,
+		Handler: router,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go ratelimit.RunEviction(ctx, limitStore, time.Minute)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info().Str("address", addr).Msg("Starting auth service")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
 		log.Fatal().Err(err).Msg("Server startup failed")
+	case <-ctx.Done():
+	}
+	stop()
+
+	checker.ShutDown()
+	log.Info().Dur("delay", cfg.ShutdownDelay).Msg("Readiness set to failing, waiting for load balancer")
+	time.Sleep(cfg.ShutdownDelay)
+
+	// База закрывается отложенным вызовом выше — уже после того, как
+	// текущие запросы завершатся.
+	log.Info().Dur("timeout", cfg.ShutdownTimeout).Msg("Shutting down auth service")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to drain in-flight requests")
 	}
+	log.Info().Msg("Auth service stopped")
 }
 
 func ginLoggerMiddleware() gin.HandlerFunc {
@@ -139,6 +271,6 @@ func ginLoggerMiddleware() gin.HandlerFunc {
 			logEvent = log.Warn()
 		}
 
-		logEvent.Msg("HTTP request")
+		logEvent.Ctx(c.Request.Context()).Msg("HTTP request")
 	}
 }
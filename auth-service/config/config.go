@@ -6,15 +6,38 @@ import (
 	"log"
 	"os"
 	"strconv"
+	"strings"
 	"time"
 
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/blobstore"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/ratelimit"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port     string
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Port            string
+	Database        DatabaseConfig
+	JWT             JWTConfig
+	ForumServiceURL string
+	// PublicURL — адрес auth-service, по которому браузер загружает аватары.
+	PublicURL string
+	Avatars   AvatarConfig
+	Tracing   TracingConfig
+	RateLimit RateLimitConfig
+	// ShutdownTimeout — сколько при остановке ждать завершения текущих запросов.
+	ShutdownTimeout time.Duration
+	// ShutdownDelay — пауза между сигналом остановки и остановкой сервера:
+	// /readyz уже отвечает 503, и балансировщик успевает убрать экземпляр.
+	ShutdownDelay time.Duration
+	// TrustedProxies — адреса или подсети прокси, которым можно верить
+	// в X-Forwarded-For. Пустой список: клиентом считается адрес соединения,
+	// и подделанный заголовок не обходит лимиты по IP.
+	TrustedProxies []string
+}
+
+type AvatarConfig struct {
+	MaxBytes int64
+	Storage  blobstore.Config
 }
 
 type DatabaseConfig struct {
@@ -27,6 +50,24 @@ type DatabaseConfig struct {
 	MaxConns int
 }
 
+type TracingConfig struct {
+	// Exporter — none, stdout (для локального запуска) или otlp; адрес
+	// коллектора задаётся стандартной OTEL_EXPORTER_OTLP_ENDPOINT.
+	Exporter    string
+	SampleRatio float64
+}
+
+type RateLimitConfig struct {
+	// Store — memory (у каждого экземпляра свои счётчики) или postgres
+	// (общие лимиты для всех реплик).
+	Store    string
+	Login    ratelimit.Policy
+	Register ratelimit.Policy
+	// Lookup — поиск пользователей по именам (GET /users), считается
+	// на пользователя из токена.
+	Lookup ratelimit.Policy
+}
+
 type JWTConfig struct {
 	SecretKey string
 	ExpiresIn int
@@ -45,6 +86,22 @@ func Load() *Config {
 	}
 
 	expiresIn, _ := strconv.Atoi(getEnv("JWT_EXPIRES_IN", "36000"))
+	avatarMaxBytes, _ := strconv.ParseInt(getEnv("AVATAR_MAX_BYTES", "2097152"), 10, 64)
+	sampleRatio, err := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1"), 64)
+	if err != nil {
+		log.Printf("Warning: invalid TRACING_SAMPLE_RATIO, using 1: %v", err)
+		sampleRatio = 1
+	}
+	shutdownTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "15s"))
+	if err != nil {
+		log.Printf("Warning: invalid SHUTDOWN_TIMEOUT, using 15s: %v", err)
+		shutdownTimeout = 15 * time.Second
+	}
+	shutdownDelay, err := time.ParseDuration(getEnv("SHUTDOWN_DELAY", "5s"))
+	if err != nil {
+		log.Printf("Warning: invalid SHUTDOWN_DELAY, using 5s: %v", err)
+		shutdownDelay = 5 * time.Second
+	}
 
 	return &Config{
 		Port: getEnv("PORT", "8080"),
@@ -61,6 +118,33 @@ func Load() *Config {
 			SecretKey: getEnv("JWT_SECRET", ""),
 			ExpiresIn: expiresIn,
 		},
+		ForumServiceURL: getEnv("FORUM_SERVICE_URL", "http://localhost:8081"),
+		PublicURL:       getEnv("PUBLIC_URL", "http://localhost:8080"),
+		Avatars: AvatarConfig{
+			MaxBytes: avatarMaxBytes,
+			Storage: blobstore.Config{
+				Backend:     getEnv("BLOB_BACKEND", "local"),
+				LocalDir:    getEnv("AVATAR_DIR", "./avatars"),
+				S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+				S3Region:    getEnv("S3_REGION", "us-east-1"),
+				S3Bucket:    getEnv("S3_BUCKET", ""),
+				S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+				S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			},
+		},
+		Tracing: TracingConfig{
+			Exporter:    getEnv("TRACING_EXPORTER", "none"),
+			SampleRatio: sampleRatio,
+		},
+		RateLimit: RateLimitConfig{
+			Store:    getEnv("RATE_LIMIT_STORE", "memory"),
+			Login:    loadPolicy("login", "RATE_LIMIT_LOGIN", "10/1m"),
+			Register: loadPolicy("register", "RATE_LIMIT_REGISTER", "5/1h"),
+			Lookup:   loadPolicy("lookup", "RATE_LIMIT_LOOKUP", "60/1m"),
+		},
+		ShutdownTimeout: shutdownTimeout,
+		ShutdownDelay:   shutdownDelay,
+		TrustedProxies:  getList("TRUSTED_PROXIES"),
 	}
 }
 
@@ -91,3 +175,24 @@ func getEnv(key, defaultValue string) string {
 	}
 	return defaultValue
 }
+
+// getList читает список через запятую; пустые элементы отбрасываются.
+func getList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// loadPolicy читает лимит маршрута вида "10/1m" или "off".
+func loadPolicy(name, key, defaultValue string) ratelimit.Policy {
+	policy, err := ratelimit.ParsePolicy(name, getEnv(key, defaultValue))
+	if err != nil {
+		log.Printf("Warning: invalid %s, using %s: %v", key, defaultValue, err)
+		policy, _ = ratelimit.ParsePolicy(name, defaultValue)
+	}
+	return policy
+}
@@ -1,15 +1,19 @@
 package handlers
 
 import (
+	"errors"
+	"fmt"
 	"net/http"
 	"regexp"
 	"strconv"
 	"strings"
 
 	"github.com/Frozz164/forum-app_v2/auth-service/config"
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
 	"github.com/Frozz164/forum-app_v2/auth-service/internal/service"
 	"github.com/Frozz164/forum-app_v2/auth-service/model"
 	"github.com/Frozz164/forum-app_v2/auth-service/pkg/helper"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
 	"github.com/gin-gonic/gin"
 	"github.com/go-playground/validator/v10"
 	"github.com/rs/zerolog"
@@ -43,12 +47,12 @@ func validateUsername(fl validator.FieldLevel) bool {
 }
 
 func (h *AuthServiceHandler) Register(c *gin.Context) {
-	logger := h.logger.With().Str("method", "Register").Logger()
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Register").Logger()
 
 	var req model.RegisterRequest
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Warn().Err(err).Msg("Invalid request format")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		problem.Abort(c, problem.ErrInvalidRequest)
 		return
 	}
 
@@ -60,31 +64,31 @@ func (h *AuthServiceHandler) Register(c *gin.Context) {
 	// Дополнительная валидация
 	if err := h.validator.Struct(req); err != nil {
 		logger.Warn().Err(err).Msg("Validation failed")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		problem.Abort(c, problem.ErrValidation.WithDetail(err.Error()))
 		return
 	}
 
 	// Проверка email
 	if !strings.Contains(req.Email, "@") {
 		logger.Warn().Msg("Invalid email format")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid email format"})
+		problem.Abort(c, errInvalidEmail)
 		return
 	}
 
 	userID, err := h.authService.CreateUser(c.Request.Context(), req.Username, req.Password, req.Email)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to create user")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to create user"})
+		fail(c, err)
 		return
 	}
 
-	token, err := h.generateToken(userID)
+	token, err := h.generateToken(userID, req.Username)
 	if err != nil {
 		logger.Error().
 			Err(err).
 			Int64("user_id", userID).
 			Msg("Failed to generate token")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": "Failed to generate token"})
+		fail(c, err)
 		return
 	}
 
@@ -100,7 +104,7 @@ func (h *AuthServiceHandler) Register(c *gin.Context) {
 }
 
 func (h *AuthServiceHandler) Login(c *gin.Context) {
-	logger := h.logger.With().Str("method", "Login").Logger()
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Login").Logger()
 
 	var req struct {
 		Username string `json:"username"`
@@ -110,7 +114,7 @@ func (h *AuthServiceHandler) Login(c *gin.Context) {
 
 	if err := c.ShouldBindJSON(&req); err != nil {
 		logger.Warn().Err(err).Msg("Invalid request format")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Invalid request format"})
+		problem.Abort(c, problem.ErrInvalidRequest)
 		return
 	}
 
@@ -128,13 +132,21 @@ func (h *AuthServiceHandler) Login(c *gin.Context) {
 		token, err = h.authService.LoginByEmail(c.Request.Context(), req.Email, req.Password)
 	} else {
 		logger.Warn().Msg("Username or email required")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "Username or email required"})
+		problem.Abort(c, errLoginRequired)
 		return
 	}
 
+	var banned *domain.BannedError
+	if errors.As(err, &banned) {
+		logger.Warn().Int64("sanction_id", banned.Sanction.ID).Msg("Login refused for banned user")
+		problem.Abort(c, errAccountBanned.
+			With("reason", banned.Sanction.Reason).
+			With("expires_at", banned.Sanction.ExpiresAt))
+		return
+	}
 	if err != nil {
 		logger.Warn().Err(err).Msg("Invalid credentials")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid credentials"})
+		problem.Abort(c, errInvalidCredentials)
 		return
 	}
 
@@ -147,27 +159,27 @@ func (h *AuthServiceHandler) Login(c *gin.Context) {
 	})
 }
 
-func (h *AuthServiceHandler) generateToken(userID int64) (string, error) {
+func (h *AuthServiceHandler) generateToken(userID int64, username string) (string, error) {
 	h.logger.Debug().
 		Int64("user_id", userID).
 		Msg("Generating JWT token")
-	return helper.GenerateJWT(userID, h.cfg.JWT.SecretKey, strconv.Itoa(h.cfg.JWT.ExpiresIn))
+	return helper.GenerateJWTWithClaims(userID, username, domain.RoleUser, h.cfg.JWT.SecretKey, h.cfg.JWT.ExpiresIn)
 }
 
 func (h *AuthServiceHandler) Validate(c *gin.Context) {
-	logger := h.logger.With().Str("method", "Validate").Logger()
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Validate").Logger()
 
 	authHeader := c.GetHeader("Authorization")
 	if authHeader == "" {
 		logger.Warn().Msg("Authorization header is missing")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Authorization header is required"})
+		problem.Abort(c, problem.ErrUnauthorized)
 		return
 	}
 
 	tokenParts := strings.Split(authHeader, " ")
 	if len(tokenParts) != 2 || tokenParts[0] != "Bearer" {
 		logger.Warn().Str("header", authHeader).Msg("Invalid authorization format")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid authorization format"})
+		problem.Abort(c, errInvalidAuthHeader)
 		return
 	}
 
@@ -177,7 +189,7 @@ func (h *AuthServiceHandler) Validate(c *gin.Context) {
 	userID, err := h.authService.ValidateToken(token)
 	if err != nil {
 		logger.Error().Err(err).Msg("Token validation failed")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "Invalid token"})
+		problem.Abort(c, problem.ErrInvalidToken)
 		return
 	}
 
@@ -190,3 +202,108 @@ func (h *AuthServiceHandler) Validate(c *gin.Context) {
 		"is_valid": true,
 	})
 }
+
+func (h *AuthServiceHandler) ChangeRole(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "ChangeRole").Logger()
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Invalid user ID format")
+		problem.Abort(c, errInvalidUserID)
+		return
+	}
+
+	var req struct {
+		Role string `json:"role" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		problem.Abort(c, problem.ErrInvalidRequest)
+		return
+	}
+
+	actorID := c.GetInt64("userID")
+	logger = logger.With().Int64("user_id", userID).Int64("actor_id", actorID).Str("role", req.Role).Logger()
+
+	err = h.authService.ChangeRole(c.Request.Context(), userID, req.Role, actorID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		fail(c, err)
+		return
+	}
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to change role")
+		fail(c, err)
+		return
+	}
+
+	logger.Info().Msg("User role changed")
+	c.JSON(http.StatusOK, gin.H{"user_id": userID, "role": req.Role})
+}
+
+func (h *AuthServiceHandler) Profile(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Profile").Logger()
+
+	userID := c.GetInt64("userID")
+	profile, err := h.authService.GetProfile(c.Request.Context(), userID)
+	if errors.Is(err, domain.ErrUserNotFound) {
+		fail(c, err)
+		return
+	}
+	if err != nil {
+		logger.Error().Err(err).Int64("user_id", userID).Msg("Failed to get profile")
+		fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, profile)
+}
+
+// maxLookupUsernames ограничивает число имён в одном запросе LookupUsers.
+const maxLookupUsernames = 50
+
+// LookupUsers отвечает на GET /users?username=a&username=b публичными данными
+// найденных пользователей. Неизвестные имена в ответ не попадают.
+func (h *AuthServiceHandler) LookupUsers(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "LookupUsers").Logger()
+
+	usernames := c.QueryArray("username")
+	if len(usernames) == 0 {
+		problem.Abort(c, errUsernameRequired)
+		return
+	}
+	if len(usernames) > maxLookupUsernames {
+		problem.Abort(c, problem.ErrValidation.WithDetail(fmt.Sprintf("at most %d usernames per request", maxLookupUsernames)))
+		return
+	}
+
+	users, err := h.authService.LookupUsers(c.Request.Context(), usernames)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to look up users")
+		fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+// CurrentRole заменяет роль из JWT текущей ролью из базы, чтобы смена роли
+// действовала сразу, а не после истечения уже выданного токена. Должен стоять
+// после RequireAuth и перед RequireRole.
+func (h *AuthServiceHandler) CurrentRole() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		userID := c.GetInt64("userID")
+		profile, err := h.authService.GetProfile(c.Request.Context(), userID)
+		if err != nil {
+			h.logger.Error().
+				Ctx(c.Request.Context()).
+				Err(err).
+				Int64("user_id", userID).
+				Msg("Failed to load current role")
+			fail(c, err)
+			return
+		}
+
+		c.Set("role", profile.Role)
+		c.Next()
+	}
+}
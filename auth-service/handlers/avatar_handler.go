@@ -0,0 +1,130 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/service"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type AvatarHandler struct {
+	avatarService service.AvatarService
+	maxBytes      int64
+	logger        zerolog.Logger
+}
+
+func NewAvatarHandler(avatarService service.AvatarService, maxBytes int64) *AvatarHandler {
+	return &AvatarHandler{
+		avatarService: avatarService,
+		maxBytes:      maxBytes,
+		logger:        log.With().Str("component", "avatar_handler").Logger(),
+	}
+}
+
+// Upload принимает multipart/form-data с изображением в поле "avatar".
+func (h *AvatarHandler) Upload(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Upload").Logger()
+
+	userID := c.GetInt64("userID")
+	logger = logger.With().Int64("user_id", userID).Logger()
+
+	// Запас на заголовки multipart поверх размера файла.
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes+64<<10)
+	header, err := c.FormFile("avatar")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			problem.Abort(c, errAvatarTooLarge)
+			return
+		}
+		logger.Warn().Err(err).Msg("Missing avatar file")
+		problem.Abort(c, errAvatarRequired)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to open avatar file")
+		fail(c, err)
+		return
+	}
+	defer file.Close()
+
+	err = h.avatarService.UploadAvatar(c.Request.Context(), userID, file)
+	switch {
+	case errors.Is(err, domain.ErrAvatarTooLarge), errors.Is(err, domain.ErrUnsupportedImage), errors.Is(err, domain.ErrUserNotFound):
+		fail(c, err)
+		return
+	case err != nil:
+		logger.Error().Err(err).Msg("Failed to upload avatar")
+		fail(c, err)
+		return
+	}
+
+	logger.Info().Msg("Avatar uploaded")
+	c.Status(http.StatusNoContent)
+}
+
+func (h *AvatarHandler) Remove(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Remove").Logger()
+
+	userID := c.GetInt64("userID")
+	if err := h.avatarService.RemoveAvatar(c.Request.Context(), userID); err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			fail(c, err)
+			return
+		}
+		logger.Error().Err(err).Int64("user_id", userID).Msg("Failed to remove avatar")
+		fail(c, err)
+		return
+	}
+
+	logger.Info().Int64("user_id", userID).Msg("Avatar removed")
+	c.Status(http.StatusNoContent)
+}
+
+// Get отдаёт аватар пользователя; размер выбирается параметром ?size=.
+func (h *AvatarHandler) Get(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Get").Logger()
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Abort(c, errInvalidUserID)
+		return
+	}
+	size, _ := strconv.Atoi(c.Query("size"))
+
+	body, etag, err := h.avatarService.OpenAvatar(c.Request.Context(), userID, size)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			fail(c, err)
+			return
+		}
+		logger.Error().Err(err).Int64("user_id", userID).Msg("Failed to open avatar")
+		fail(c, err)
+		return
+	}
+	defer body.Close()
+
+	etag = `"` + etag + `"`
+	c.Header("ETag", etag)
+	c.Header("Cache-Control", "public, max-age=300")
+	if c.GetHeader("If-None-Match") == etag {
+		c.Status(http.StatusNotModified)
+		return
+	}
+
+	c.Header("Content-Type", "image/png")
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Status(http.StatusOK)
+	if _, err := io.Copy(c.Writer, body); err != nil {
+		logger.Warn().Err(err).Int64("user_id", userID).Msg("Failed to write avatar")
+	}
+}
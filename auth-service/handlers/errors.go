@@ -0,0 +1,58 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/gin-gonic/gin"
+)
+
+// Ошибки API auth-service. Коды стабильны — клиенты различают ошибки по ним,
+// а не по тексту detail.
+var (
+	errInvalidUserID      = problem.ErrInvalidParam.WithDetail("invalid user ID")
+	errInvalidSanctionID  = problem.ErrInvalidParam.WithDetail("invalid sanction ID")
+	errInvalidEmail       = problem.ErrValidation.WithDetail("invalid email format")
+	errLoginRequired      = problem.ErrValidation.WithDetail("username or email required")
+	errUsernameRequired   = problem.ErrValidation.WithDetail("at least one username is required")
+	errAvatarRequired     = problem.ErrValidation.WithDetail("avatar file is required")
+	errInvalidCredentials = problem.New(http.StatusUnauthorized, "invalid_credentials", "invalid credentials")
+	errAccountBanned      = problem.New(http.StatusForbidden, "account_banned", "account is banned")
+	errUserNotFound       = problem.New(http.StatusNotFound, "user_not_found", "user not found")
+	errSanctionNotFound   = problem.New(http.StatusNotFound, "sanction_not_found", "sanction not found or already revoked")
+	errAvatarTooLarge     = problem.New(http.StatusRequestEntityTooLarge, "avatar_too_large", "avatar file too large")
+	errUnsupportedImage   = problem.New(http.StatusUnsupportedMediaType, "unsupported_image", "avatar must be a PNG, JPEG or GIF image")
+	errInvalidAuthHeader  = problem.ErrUnauthorized.WithDetail("invalid authorization format")
+	errOutranked          = problem.ErrForbidden.WithDetail("target user has an equal or higher role")
+	errLastAdmin          = problem.New(http.StatusConflict, "last_admin", "cannot demote the last admin")
+)
+
+// fail отвечает ошибкой в формате problem+json. Ошибки domain получают свой
+// код и статус, ошибки проверки ввода — validation_failed с текстом проверки;
+// всё остальное уходит клиенту как internal_error без подробностей.
+func fail(c *gin.Context, err error) {
+	problem.Abort(c, fromDomain(err))
+}
+
+func fromDomain(err error) error {
+	var validation *domain.ValidationError
+	switch {
+	case errors.As(err, &validation):
+		return problem.ErrValidation.WithDetail(validation.Message)
+	case errors.Is(err, domain.ErrUserNotFound):
+		return errUserNotFound
+	case errors.Is(err, domain.ErrSanctionNotFound):
+		return errSanctionNotFound
+	case errors.Is(err, domain.ErrAvatarTooLarge):
+		return errAvatarTooLarge
+	case errors.Is(err, domain.ErrUnsupportedImage):
+		return errUnsupportedImage
+	case errors.Is(err, domain.ErrOutranked):
+		return errOutranked
+	case errors.Is(err, domain.ErrLastAdmin):
+		return errLastAdmin
+	}
+	return err
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func TestFailMapsDomainErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"validation", domain.Invalid("password is too short"), http.StatusBadRequest, "validation_failed", "password is too short"},
+		{"user not found", domain.ErrUserNotFound, http.StatusNotFound, "user_not_found", "user not found"},
+		{"wrapped user not found", fmt.Errorf("failed to get user: %w", domain.ErrUserNotFound), http.StatusNotFound, "user_not_found", "user not found"},
+		{"sanction not found", domain.ErrSanctionNotFound, http.StatusNotFound, "sanction_not_found", "sanction not found or already revoked"},
+		{"avatar too large", domain.ErrAvatarTooLarge, http.StatusRequestEntityTooLarge, "avatar_too_large", "avatar file too large"},
+		{"unsupported image", domain.ErrUnsupportedImage, http.StatusUnsupportedMediaType, "unsupported_image", "avatar must be a PNG, JPEG or GIF image"},
+		{"outranked", domain.ErrOutranked, http.StatusForbidden, "forbidden", "target user has an equal or higher role"},
+		{"last admin", domain.ErrLastAdmin, http.StatusConflict, "last_admin", "cannot demote the last admin"},
+		{"api error passes through", errAccountBanned, http.StatusForbidden, "account_banned", "account is banned"},
+		{"internal error hides details", errors.New("pq: connection refused"), http.StatusInternalServerError, "internal_error", "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			c, _ := gin.CreateTestContext(rec)
+			c.Request = httptest.NewRequest(http.MethodGet, "/api/v1/test", nil)
+			fail(c, tt.err)
+
+			var body struct {
+				Status int    `json:"status"`
+				Code   string `json:"code"`
+				Detail string `json:"detail"`
+			}
+			if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+				t.Fatalf("failed to decode body %q: %v", rec.Body.String(), err)
+			}
+			if rec.Code != tt.wantStatus || body.Status != tt.wantStatus {
+				t.Errorf("status = %d (body %d), want %d", rec.Code, body.Status, tt.wantStatus)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+			if body.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", body.Detail, tt.wantDetail)
+			}
+		})
+	}
+}
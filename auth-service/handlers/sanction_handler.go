@@ -0,0 +1,182 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/service"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/notifier"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type SanctionHandler struct {
+	sanctionService service.SanctionService
+	notifier        *notifier.ForumNotifier
+	logger          zerolog.Logger
+}
+
+func NewSanctionHandler(sanctionService service.SanctionService, forumNotifier *notifier.ForumNotifier) *SanctionHandler {
+	return &SanctionHandler{
+		sanctionService: sanctionService,
+		notifier:        forumNotifier,
+		logger:          log.With().Str("component", "sanction_handler").Logger(),
+	}
+}
+
+func (h *SanctionHandler) Issue(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Issue").Logger()
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Invalid user ID format")
+		problem.Abort(c, errInvalidUserID)
+		return
+	}
+
+	var req struct {
+		Type            string `json:"type" binding:"required"`
+		Reason          string `json:"reason" binding:"required"`
+		DurationSeconds int64  `json:"duration_seconds"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		problem.Abort(c, problem.ErrInvalidRequest)
+		return
+	}
+
+	actorID := c.GetInt64("userID")
+	logger = logger.With().
+		Int64("user_id", userID).
+		Int64("actor_id", actorID).
+		Str("type", req.Type).
+		Logger()
+
+	sanction, err := h.sanctionService.IssueSanction(c.Request.Context(), userID, req.Type, req.Reason,
+		time.Duration(req.DurationSeconds)*time.Second, actorID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			fail(c, err)
+			return
+		}
+		logger.Warn().Err(err).Msg("Failed to issue sanction")
+		fail(c, err)
+		return
+	}
+
+	h.notify(c, sanction)
+
+	logger.Info().Int64("sanction_id", sanction.ID).Msg("Sanction issued")
+	c.JSON(http.StatusCreated, sanction)
+}
+
+func (h *SanctionHandler) Revoke(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Revoke").Logger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Invalid sanction ID format")
+		problem.Abort(c, errInvalidSanctionID)
+		return
+	}
+
+	sanction, err := h.sanctionService.RevokeSanction(c.Request.Context(), id, c.GetInt64("userID"))
+	if err != nil {
+		if errors.Is(err, domain.ErrSanctionNotFound) {
+			fail(c, err)
+			return
+		}
+		logger.Error().Err(err).Int64("sanction_id", id).Msg("Failed to revoke sanction")
+		fail(c, err)
+		return
+	}
+
+	h.notify(c, sanction)
+
+	logger.Info().Int64("sanction_id", id).Msg("Sanction revoked")
+	c.JSON(http.StatusOK, sanction)
+}
+
+func (h *SanctionHandler) List(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "List").Logger()
+
+	userID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Invalid user ID format")
+		problem.Abort(c, errInvalidUserID)
+		return
+	}
+
+	sanctions, err := h.sanctionService.ListSanctions(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error().Err(err).Int64("user_id", userID).Msg("Failed to list sanctions")
+		fail(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, sanctions)
+}
+
+// Active возвращает действующие санкции владельца токена. Используется
+// forum-service для проверки мьютов и запретов на публикацию.
+func (h *SanctionHandler) Active(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Active").Logger()
+
+	userID := c.GetInt64("userID")
+	sanctions, err := h.sanctionService.ActiveSanctions(c.Request.Context(), userID)
+	if err != nil {
+		logger.Error().Err(err).Int64("user_id", userID).Msg("Failed to get active sanctions")
+		fail(c, err)
+		return
+	}
+	if sanctions == nil {
+		sanctions = []*domain.Sanction{}
+	}
+
+	c.JSON(http.StatusOK, sanctions)
+}
+
+func (h *SanctionHandler) notify(c *gin.Context, sanction *domain.Sanction) {
+	authHeader := c.GetHeader("Authorization")
+	// Уведомление переживает запрос, но сохраняет его request ID и трассу.
+	parent := context.WithoutCancel(c.Request.Context())
+	go func() {
+		ctx, cancel := context.WithTimeout(parent, 5*time.Second)
+		defer cancel()
+		h.notifier.SanctionChanged(ctx, authHeader, sanction)
+	}()
+}
+
+// RejectBanned отклоняет запросы забаненных пользователей, даже если их JWT
+// выдан до бана. Ставится после RequireAuth.
+func (h *SanctionHandler) RejectBanned() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := h.logger.With().
+			Ctx(c.Request.Context()).
+			Str("middleware", "RejectBanned").
+			Str("path", c.Request.URL.Path).
+			Int64("user_id", c.GetInt64("userID")).
+			Logger()
+
+		ban, err := h.sanctionService.ActiveBan(c.Request.Context(), c.GetInt64("userID"))
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to check ban")
+			fail(c, err)
+			return
+		}
+		if ban != nil {
+			logger.Warn().Int64("sanction_id", ban.ID).Msg("Request refused for banned user")
+			problem.Abort(c, errAccountBanned.
+				With("reason", ban.Reason).
+				With("expires_at", ban.ExpiresAt))
+			return
+		}
+		c.Next()
+	}
+}
@@ -0,0 +1,46 @@
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+const (
+	SanctionBan            = "ban"
+	SanctionMute           = "mute"
+	SanctionPostSuspension = "post_suspension"
+)
+
+var ErrSanctionNotFound = errors.New("sanction not found")
+
+type Sanction struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	ActorID   int64  `json:"actor_id"`
+	ExpiresAt string `json:"expires_at,omitempty"` // пусто — бессрочно
+	CreatedAt string `json:"created_at"`
+	RevokedAt string `json:"revoked_at,omitempty"`
+	RevokedBy int64  `json:"revoked_by,omitempty"`
+}
+
+func IsValidSanctionType(t string) bool {
+	switch t {
+	case SanctionBan, SanctionMute, SanctionPostSuspension:
+		return true
+	}
+	return false
+}
+
+// BannedError возвращается при попытке входа в заблокированный аккаунт.
+type BannedError struct {
+	Sanction *Sanction
+}
+
+func (e *BannedError) Error() string {
+	if e.Sanction.ExpiresAt == "" {
+		return fmt.Sprintf("account is permanently banned: %s", e.Sanction.Reason)
+	}
+	return fmt.Sprintf("account is banned until %s: %s", e.Sanction.ExpiresAt, e.Sanction.Reason)
+}
@@ -1,8 +1,66 @@
 package domain
 
+import "errors"
+
+var (
+	ErrUserNotFound     = errors.New("user not found")
+	ErrAvatarTooLarge   = errors.New("avatar file too large")
+	ErrUnsupportedImage = errors.New("avatar must be a PNG, JPEG or GIF image")
+	// ErrOutranked — действие над пользователем с равной или более высокой ролью.
+	ErrOutranked = errors.New("target user has an equal or higher role")
+	// ErrLastAdmin — смена роли оставила бы систему без администраторов.
+	ErrLastAdmin = errors.New("cannot demote the last admin")
+)
+
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
 type User struct {
 	ID       int64  `json:"id"`
 	Username string `json:"username"`
 	Password string `json:"password"`
 	Email    string `json:"email"`
+	Role     string `json:"role"`
+	// AvatarVersion — версия загруженного аватара; пустая строка означает identicon.
+	AvatarVersion string `json:"-"`
+}
+
+// Profile — публичные данные пользователя, которые отдаются клиенту.
+type Profile struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	Email     string `json:"email"`
+	Role      string `json:"role"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// UserRef — минимальные публичные данные пользователя для ссылок на него
+// из других сервисов (например, при разборе @упоминаний).
+type UserRef struct {
+	ID        int64  `json:"id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+func IsValidRole(role string) bool {
+	return role == RoleUser || role == RoleModerator || role == RoleAdmin
+}
+
+// Outranks сообщает, стоит ли роль role строго выше роли other:
+// user < moderator < admin.
+func Outranks(role, other string) bool {
+	return roleRank(role) > roleRank(other)
+}
+
+func roleRank(role string) int {
+	switch role {
+	case RoleAdmin:
+		return 2
+	case RoleModerator:
+		return 1
+	}
+	return 0
 }
@@ -3,6 +3,9 @@ package migrations
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/ratelimit"
 )
 
 func MigrateDB(db *sql.DB) error {
@@ -13,6 +16,22 @@ func MigrateDB(db *sql.DB) error {
 			password TEXT NOT NULL,
 			email TEXT NOT NULL UNIQUE
 		);
+
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS role TEXT NOT NULL DEFAULT 'user';
+		ALTER TABLE users ADD COLUMN IF NOT EXISTS avatar_version TEXT NOT NULL DEFAULT '';
+
+		CREATE TABLE IF NOT EXISTS user_sanctions (
+			id SERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+			type TEXT NOT NULL,
+			reason TEXT NOT NULL,
+			actor_id BIGINT NOT NULL,
+			expires_at TIMESTAMP,
+			created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+			revoked_at TIMESTAMP,
+			revoked_by BIGINT
+		);
+		CREATE INDEX IF NOT EXISTS idx_user_sanctions_user ON user_sanctions (user_id, type);
 	`
 
 	_, err := db.Exec(query)
@@ -20,5 +39,17 @@ func MigrateDB(db *sql.DB) error {
 		return fmt.Errorf("failed to create table: %w", err)
 	}
 
+	if err := audit.Migrate(db); err != nil {
+		return err
+	}
+
+	if err := ratelimit.Migrate(db); err != nil {
+		return err
+	}
+
+	if err := recordVersion(db); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -10,6 +10,9 @@ type AuthRepository interface {
 	Create(ctx context.Context, user *domain.User) (int64, error)
 	GetByUsername(ctx context.Context, username string) (*domain.User, error)
 	GetByID(ctx context.Context, id int64) (*domain.User, error)
+	GetByUsernames(ctx context.Context, usernames []string) ([]*domain.User, error)
+	UpdateRole(ctx context.Context, id int64, role string) error
+	UpdateAvatar(ctx context.Context, id int64, version string) error
 	LoginByEmail(ctx context.Context, email, password string) (string, error)
 	GetByEmail(ctx context.Context, email string) (interface{}, interface{})
 }
@@ -5,6 +5,7 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+	"github.com/lib/pq"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
@@ -34,7 +35,11 @@ func NewAuthRepositoryImpl(db *sql.DB) *AuthRepositoryImpl {
 }
 
 func (r *AuthRepositoryImpl) Create(ctx context.Context, user *domain.User) (int64, error) {
-	r.logger.Info().
+	ctx, span := startSpan(ctx, "AuthRepository.Create")
+	defer span.End()
+	logger := r.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().
 		Str("username", user.Username).
 		Str("email", user.Email).
 		Msg("Create user called")
@@ -48,58 +53,178 @@ func (r *AuthRepositoryImpl) Create(ctx context.Context, user *domain.User) (int
 	var id int64
 	err := r.db.QueryRowContext(ctx, query, user.Username, user.Password, user.Email).Scan(&id)
 	if err != nil {
-		r.logger.Error().Err(err).Msg("Error creating user in database")
+		logger.Error().Err(err).Msg("Error creating user in database")
 		return 0, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	r.logger.Info().Int64("user_id", id).Msg("User created in database")
+	logger.Info().Int64("user_id", id).Msg("User created in database")
 	return id, nil
 }
 
 func (r *AuthRepositoryImpl) GetByUsername(ctx context.Context, username string) (*domain.User, error) {
-	r.logger.Info().Str("username", username).Msg("GetByUsername called")
+	ctx, span := startSpan(ctx, "AuthRepository.GetByUsername")
+	defer span.End()
+	logger := r.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Str("username", username).Msg("GetByUsername called")
 
 	query := `
-		SELECT id, username, password, email
+		SELECT id, username, password, email, role, avatar_version
 		FROM users
 		WHERE username = $1
 	`
 
 	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password, &user.Email)
+	err := r.db.QueryRowContext(ctx, query, username).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.Role, &user.AvatarVersion)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			r.logger.Info().Msg("User not found by username")
+			logger.Info().Msg("User not found by username")
 			return nil, nil
 		}
-		r.logger.Error().Err(err).Msg("Error getting user by username")
+		logger.Error().Err(err).Msg("Error getting user by username")
 		return nil, fmt.Errorf("failed to get user by username: %w", err)
 	}
 
-	r.logger.Info().Int64("user_id", user.ID).Msg("User found by username")
+	logger.Info().Int64("user_id", user.ID).Msg("User found by username")
 	return user, nil
 }
 
 func (r *AuthRepositoryImpl) GetByID(ctx context.Context, id int64) (*domain.User, error) {
-	r.logger.Info().Int64("user_id", id).Msg("GetByID called")
+	ctx, span := startSpan(ctx, "AuthRepository.GetByID")
+	defer span.End()
+	logger := r.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Int64("user_id", id).Msg("GetByID called")
 
 	query := `
-		SELECT id, username, password, email
+		SELECT id, username, password, email, role, avatar_version
 		FROM users
 		WHERE id = $1
 	`
 
 	user := &domain.User{}
-	err := r.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Password, &user.Email)
+	err := r.db.QueryRowContext(ctx, query, id).Scan(&user.ID, &user.Username, &user.Password, &user.Email, &user.Role, &user.AvatarVersion)
 	if err != nil {
 		if err == sql.ErrNoRows {
-			r.logger.Info().Msg("User not found by ID")
+			logger.Info().Msg("User not found by ID")
 			return nil, nil
 		}
-		r.logger.Error().Err(err).Msg("Error getting user by ID")
+		logger.Error().Err(err).Msg("Error getting user by ID")
 		return nil, fmt.Errorf("failed to get user by ID: %w", err)
 	}
 
-	r.logger.Info().Int64("user_id", user.ID).Msg("User found by ID")
+	logger.Info().Int64("user_id", user.ID).Msg("User found by ID")
 	return user, nil
 }
+
+// GetByUsernames возвращает пользователей с указанными именами; несуществующие
+// имена просто отсутствуют в результате. Пароль и email не выбираются.
+func (r *AuthRepositoryImpl) GetByUsernames(ctx context.Context, usernames []string) ([]*domain.User, error) {
+	ctx, span := startSpan(ctx, "AuthRepository.GetByUsernames")
+	defer span.End()
+	logger := r.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Int("username_count", len(usernames)).Msg("GetByUsernames called")
+
+	query := `
+		SELECT id, username, role
+		FROM users
+		WHERE username = ANY($1)
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, pq.Array(usernames))
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting users by usernames")
+		return nil, fmt.Errorf("failed to get users by usernames: %w", err)
+	}
+	defer rows.Close()
+
+	var users []*domain.User
+	for rows.Next() {
+		user := &domain.User{}
+		if err := rows.Scan(&user.ID, &user.Username, &user.Role); err != nil {
+			return nil, fmt.Errorf("failed to scan user: %w", err)
+		}
+		users = append(users, user)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	logger.Info().Int("user_count", len(users)).Msg("Users found by usernames")
+	return users, nil
+}
+
+func (r *AuthRepositoryImpl) UpdateRole(ctx context.Context, id int64, role string) error {
+	ctx, span := startSpan(ctx, "AuthRepository.UpdateRole")
+	defer span.End()
+	logger := r.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().
+		Int64("user_id", id).
+		Str("role", role).
+		Msg("UpdateRole called")
+
+	// Строки администраторов блокируются, поэтому два одновременных
+	// понижения не оставят систему без администраторов.
+	query := `
+		WITH admins AS (
+			SELECT id FROM users WHERE role = 'admin' FOR UPDATE
+		)
+		UPDATE users SET role = $2
+		WHERE id = $1
+		  AND ($2 = 'admin' OR id NOT IN (SELECT id FROM admins) OR (SELECT COUNT(*) FROM admins) > 1)
+	`
+	result, err := r.db.ExecContext(ctx, query, id, role)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error updating user role")
+		return fmt.Errorf("failed to update role: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		var exists bool
+		if err := r.db.QueryRowContext(ctx, `SELECT EXISTS(SELECT 1 FROM users WHERE id = $1)`, id).Scan(&exists); err != nil {
+			return fmt.Errorf("failed to check user: %w", err)
+		}
+		if !exists {
+			return domain.ErrUserNotFound
+		}
+		logger.Warn().Int64("user_id", id).Msg("Refused to demote the last admin")
+		return domain.ErrLastAdmin
+	}
+
+	logger.Info().Int64("user_id", id).Msg("User role updated")
+	return nil
+}
+
+func (r *AuthRepositoryImpl) UpdateAvatar(ctx context.Context, id int64, version string) error {
+	ctx, span := startSpan(ctx, "AuthRepository.UpdateAvatar")
+	defer span.End()
+	logger := r.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().
+		Int64("user_id", id).
+		Str("avatar_version", version).
+		Msg("UpdateAvatar called")
+
+	result, err := r.db.ExecContext(ctx, `UPDATE users SET avatar_version = $2 WHERE id = $1`, id, version)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error updating user avatar")
+		return fmt.Errorf("failed to update avatar: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrUserNotFound
+	}
+
+	logger.Info().Int64("user_id", id).Msg("User avatar updated")
+	return nil
+}
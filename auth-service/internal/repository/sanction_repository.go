@@ -0,0 +1,15 @@
+package repository
+
+import (
+	"context"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+)
+
+type SanctionRepository interface {
+	Create(ctx context.Context, sanction *domain.Sanction) (int64, error)
+	GetByID(ctx context.Context, id int64) (*domain.Sanction, error)
+	ListByUser(ctx context.Context, userID int64) ([]*domain.Sanction, error)
+	ListActiveByUser(ctx context.Context, userID int64) ([]*domain.Sanction, error)
+	Revoke(ctx context.Context, id, actorID int64) error
+}
@@ -0,0 +1,183 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type SanctionRepositoryImpl struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewSanctionRepositoryImpl(db *sql.DB) *SanctionRepositoryImpl {
+	return &SanctionRepositoryImpl{
+		db:     db,
+		logger: log.With().Str("component", "sanction_repository").Logger(),
+	}
+}
+
+func (r *SanctionRepositoryImpl) Create(ctx context.Context, sanction *domain.Sanction) (int64, error) {
+	r.logger.Info().
+		Int64("user_id", sanction.UserID).
+		Str("type", sanction.Type).
+		Int64("actor_id", sanction.ActorID).
+		Msg("Create sanction called")
+
+	var expiresAt sql.NullTime
+	if sanction.ExpiresAt != "" {
+		t, err := time.Parse(time.RFC3339, sanction.ExpiresAt)
+		if err != nil {
+			return 0, fmt.Errorf("invalid expires_at: %w", err)
+		}
+		expiresAt = sql.NullTime{Time: t, Valid: true}
+	}
+
+	query := `
+		INSERT INTO user_sanctions (user_id, type, reason, actor_id, expires_at, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
+	`
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, query,
+		sanction.UserID, sanction.Type, sanction.Reason, sanction.ActorID, expiresAt, time.Now(),
+	).Scan(&id)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Error creating sanction in database")
+		return 0, fmt.Errorf("failed to create sanction: %w", err)
+	}
+
+	r.logger.Info().Int64("sanction_id", id).Msg("Sanction created in database")
+	return id, nil
+}
+
+func (r *SanctionRepositoryImpl) GetByID(ctx context.Context, id int64) (*domain.Sanction, error) {
+	r.logger.Info().Int64("sanction_id", id).Msg("GetByID called")
+
+	query := `
+		SELECT id, user_id, type, reason, actor_id, expires_at, created_at, revoked_at, revoked_by
+		FROM user_sanctions
+		WHERE id = $1
+	`
+
+	sanctions, err := r.querySanctions(ctx, query, id)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Error getting sanction by ID")
+		return nil, fmt.Errorf("failed to get sanction: %w", err)
+	}
+	if len(sanctions) == 0 {
+		return nil, nil
+	}
+
+	return sanctions[0], nil
+}
+
+func (r *SanctionRepositoryImpl) ListByUser(ctx context.Context, userID int64) ([]*domain.Sanction, error) {
+	r.logger.Info().Int64("user_id", userID).Msg("ListByUser called")
+
+	query := `
+		SELECT id, user_id, type, reason, actor_id, expires_at, created_at, revoked_at, revoked_by
+		FROM user_sanctions
+		WHERE user_id = $1
+		ORDER BY created_at DESC
+	`
+
+	sanctions, err := r.querySanctions(ctx, query, userID)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Error listing sanctions")
+		return nil, fmt.Errorf("failed to list sanctions: %w", err)
+	}
+
+	return sanctions, nil
+}
+
+func (r *SanctionRepositoryImpl) ListActiveByUser(ctx context.Context, userID int64) ([]*domain.Sanction, error) {
+	r.logger.Debug().Int64("user_id", userID).Msg("ListActiveByUser called")
+
+	query := `
+		SELECT id, user_id, type, reason, actor_id, expires_at, created_at, revoked_at, revoked_by
+		FROM user_sanctions
+		WHERE user_id = $1
+		  AND revoked_at IS NULL
+		  AND (expires_at IS NULL OR expires_at > $2)
+		ORDER BY expires_at DESC NULLS FIRST
+	`
+
+	sanctions, err := r.querySanctions(ctx, query, userID, time.Now())
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Error listing active sanctions")
+		return nil, fmt.Errorf("failed to list active sanctions: %w", err)
+	}
+
+	return sanctions, nil
+}
+
+func (r *SanctionRepositoryImpl) Revoke(ctx context.Context, id, actorID int64) error {
+	r.logger.Info().
+		Int64("sanction_id", id).
+		Int64("actor_id", actorID).
+		Msg("Revoke sanction called")
+
+	query := `
+		UPDATE user_sanctions
+		SET revoked_at = $2, revoked_by = $3
+		WHERE id = $1 AND revoked_at IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, time.Now(), actorID)
+	if err != nil {
+		r.logger.Error().Err(err).Msg("Error revoking sanction")
+		return fmt.Errorf("failed to revoke sanction: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return domain.ErrSanctionNotFound
+	}
+
+	r.logger.Info().Int64("sanction_id", id).Msg("Sanction revoked")
+	return nil
+}
+
+func (r *SanctionRepositoryImpl) querySanctions(ctx context.Context, query string, args ...interface{}) ([]*domain.Sanction, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var sanctions []*domain.Sanction
+	for rows.Next() {
+		var s domain.Sanction
+		var expiresAt, revokedAt sql.NullTime
+		var revokedBy sql.NullInt64
+		var createdAt time.Time
+
+		if err := rows.Scan(&s.ID, &s.UserID, &s.Type, &s.Reason, &s.ActorID,
+			&expiresAt, &createdAt, &revokedAt, &revokedBy); err != nil {
+			return nil, err
+		}
+
+		if expiresAt.Valid {
+			s.ExpiresAt = expiresAt.Time.Format(time.RFC3339)
+		}
+		if revokedAt.Valid {
+			s.RevokedAt = revokedAt.Time.Format(time.RFC3339)
+		}
+		s.RevokedBy = revokedBy.Int64
+		s.CreatedAt = createdAt.Format(time.RFC3339)
+		sanctions = append(sanctions, &s)
+	}
+
+	return sanctions, rows.Err()
+}
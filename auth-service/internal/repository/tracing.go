@@ -0,0 +1,20 @@
+package repository
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Frozz164/forum-app_v2/auth-service/internal/repository")
+
+// startSpan открывает span метода репозитория. Ошибки, залогированные
+// с этим ctx, помечают span через tracing.LogHook.
+func startSpan(ctx context.Context, name string) (context.Context, trace.Span) {
+	return tracer.Start(ctx, name,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(semconv.DBSystemPostgreSQL),
+	)
+}
@@ -2,6 +2,8 @@ package service
 
 import (
 	"context"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
 )
 
 type AuthService interface {
@@ -9,4 +11,7 @@ type AuthService interface {
 	Login(ctx context.Context, username, password string) (string, error)
 	ValidateToken(token string) (int64, error)
 	LoginByEmail(ctx context.Context, email string, password string) (string, error)
+	ChangeRole(ctx context.Context, userID int64, role string, actorID int64) error
+	GetProfile(ctx context.Context, userID int64) (*domain.Profile, error)
+	LookupUsers(ctx context.Context, usernames []string) ([]*domain.UserRef, error)
 }
@@ -2,45 +2,61 @@ package service
 
 import (
 	"context"
+	"errors"
 	"fmt"
 	"github.com/Frozz164/forum-app_v2/auth-service/config"
 	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
 	"github.com/Frozz164/forum-app_v2/auth-service/internal/repository"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/avatar"
 	"github.com/Frozz164/forum-app_v2/auth-service/pkg/helper"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/metrics"
+	"github.com/prometheus/client_golang/prometheus"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"strconv"
 )
 
 type AuthServiceImpl struct {
-	authRepository repository.AuthRepository
-	cfg            *config.Config
-	logger         zerolog.Logger
+	authRepository     repository.AuthRepository
+	sanctionRepository repository.SanctionRepository
+	auditor            audit.Recorder
+	cfg                *config.Config
+	// logins — счётчик попыток входа из metrics.NewLoginCounter.
+	logins *prometheus.CounterVec
+	logger zerolog.Logger
 }
 
-func NewAuthServiceImpl(authRepository *repository.AuthRepositoryImpl, cfg *config.Config) AuthService {
+func NewAuthServiceImpl(authRepository *repository.AuthRepositoryImpl, sanctionRepository *repository.SanctionRepositoryImpl, auditor audit.Recorder, cfg *config.Config, logins *prometheus.CounterVec) AuthService {
 	return &AuthServiceImpl{
-		authRepository: authRepository,
-		cfg:            cfg,
-		logger:         log.With().Str("component", "auth_service").Logger(),
+		authRepository:     authRepository,
+		sanctionRepository: sanctionRepository,
+		auditor:            auditor,
+		cfg:                cfg,
+		logins:             logins,
+		logger:             log.With().Str("component", "auth_service").Logger(),
 	}
 }
 
 func (s *AuthServiceImpl) LoginByEmail(ctx context.Context, email string, password string) (string, error) {
-	s.logger.Info().Str("email", email).Msg("LoginByEmail called")
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Str("email", email).Msg("LoginByEmail called")
 	// Реализация остается прежней
 	panic("implement me")
 }
 
 func (s *AuthServiceImpl) CreateUser(ctx context.Context, username, password, email string) (int64, error) {
-	s.logger.Info().
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().
 		Str("username", username).
 		Str("email", email).
 		Msg("CreateUser called")
 
 	hashedPassword, err := helper.GeneratePassword(password)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("Error generating password hash")
+		logger.Error().Err(err).Msg("Error generating password hash")
 		return 0, fmt.Errorf("failed to hash password: %w", err)
 	}
 
@@ -52,44 +68,205 @@ func (s *AuthServiceImpl) CreateUser(ctx context.Context, username, password, em
 
 	id, err := s.authRepository.Create(ctx, user)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("Error creating user in repository")
+		logger.Error().Err(err).Msg("Error creating user in repository")
 		return 0, fmt.Errorf("failed to create user: %w", err)
 	}
 
-	s.logger.Info().Int64("user_id", id).Msg("User created successfully")
+	s.recordAudit(ctx, &audit.Event{
+		ActorID:    id,
+		Action:     audit.ActionUserRegister,
+		TargetType: "user",
+		TargetID:   strconv.FormatInt(id, 10),
+		Metadata:   map[string]interface{}{"username": username},
+	})
+
+	logger.Info().Int64("user_id", id).Msg("User created successfully")
 	return id, nil
 }
 
 func (s *AuthServiceImpl) Login(ctx context.Context, username, password string) (string, error) {
-	s.logger.Info().Str("username", username).Msg("Login called")
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Str("username", username).Msg("Login called")
 
 	user, err := s.authRepository.GetByUsername(ctx, username)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("Error getting user by username")
+		logger.Error().Err(err).Msg("Error getting user by username")
 		return "", fmt.Errorf("failed to get user by username: %w", err)
 	}
 
 	if user == nil {
-		s.logger.Warn().Msg("User not found - invalid credentials")
+		logger.Warn().Msg("User not found - invalid credentials")
+		s.recordLoginFailure(ctx, 0, username, "unknown_user")
 		return "", fmt.Errorf("invalid credentials")
 	}
 
 	err = helper.ComparePasswords(user.Password, password)
 	if err != nil {
-		s.logger.Warn().Msg("Password mismatch - invalid credentials")
+		logger.Warn().Msg("Password mismatch - invalid credentials")
+		s.recordLoginFailure(ctx, user.ID, username, "wrong_password")
 		return "", fmt.Errorf("invalid credentials")
 	}
 
-	token, err := helper.GenerateJWT(user.ID, s.cfg.JWT.SecretKey, strconv.Itoa(s.cfg.JWT.ExpiresIn))
+	sanctions, err := s.sanctionRepository.ListActiveByUser(ctx, user.ID)
 	if err != nil {
-		s.logger.Error().Err(err).Msg("Error generating JWT")
+		logger.Error().Err(err).Msg("Error checking user sanctions")
+		return "", fmt.Errorf("failed to check sanctions: %w", err)
+	}
+	for _, sanction := range sanctions {
+		if sanction.Type == domain.SanctionBan {
+			logger.Warn().
+				Int64("user_id", user.ID).
+				Int64("sanction_id", sanction.ID).
+				Msg("Login refused - user is banned")
+			s.recordLoginFailure(ctx, user.ID, username, "banned")
+			return "", &domain.BannedError{Sanction: sanction}
+		}
+	}
+
+	token, err := helper.GenerateJWTWithClaims(user.ID, user.Username, user.Role, s.cfg.JWT.SecretKey, s.cfg.JWT.ExpiresIn)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error generating JWT")
 		return "", fmt.Errorf("failed to generate JWT: %w", err)
 	}
 
-	s.logger.Info().Msg("Login successful")
+	s.logins.WithLabelValues(metrics.LoginSuccess, "").Inc()
+	s.recordAudit(ctx, &audit.Event{
+		ActorID:    user.ID,
+		Action:     audit.ActionLoginSuccess,
+		TargetType: "user",
+		TargetID:   strconv.FormatInt(user.ID, 10),
+	})
+
+	logger.Info().Msg("Login successful")
 	return token, nil
 }
 
+func (s *AuthServiceImpl) ChangeRole(ctx context.Context, userID int64, role string, actorID int64) error {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().
+		Int64("user_id", userID).
+		Str("role", role).
+		Int64("actor_id", actorID).
+		Msg("ChangeRole called")
+
+	if !domain.IsValidRole(role) {
+		return domain.Invalid("invalid role: %q", role)
+	}
+
+	user, err := s.authRepository.GetByID(ctx, userID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting user by ID")
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return domain.ErrUserNotFound
+	}
+
+	if err := s.authRepository.UpdateRole(ctx, userID, role); err != nil {
+		if errors.Is(err, domain.ErrLastAdmin) || errors.Is(err, domain.ErrUserNotFound) {
+			return err
+		}
+		logger.Error().Err(err).Msg("Error updating role in repository")
+		return fmt.Errorf("failed to change role: %w", err)
+	}
+
+	s.recordAudit(ctx, &audit.Event{
+		ActorID:    actorID,
+		Action:     audit.ActionRoleChange,
+		TargetType: "user",
+		TargetID:   strconv.FormatInt(userID, 10),
+		Metadata:   map[string]interface{}{"from": user.Role, "to": role},
+	})
+
+	logger.Info().Int64("user_id", userID).Msg("Role changed successfully")
+	return nil
+}
+
+func (s *AuthServiceImpl) GetProfile(ctx context.Context, userID int64) (*domain.Profile, error) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Int64("user_id", userID).Msg("GetProfile called")
+
+	user, err := s.authRepository.GetByID(ctx, userID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting user by ID")
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	return &domain.Profile{
+		ID:        user.ID,
+		Username:  user.Username,
+		Email:     user.Email,
+		Role:      user.Role,
+		AvatarURL: avatar.URL(s.cfg.PublicURL, user.ID),
+	}, nil
+}
+
+// LookupUsers находит пользователей по именам. Повторы и пустые имена отбрасываются.
+func (s *AuthServiceImpl) LookupUsers(ctx context.Context, usernames []string) ([]*domain.UserRef, error) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Int("username_count", len(usernames)).Msg("LookupUsers called")
+
+	seen := make(map[string]bool, len(usernames))
+	unique := make([]string, 0, len(usernames))
+	for _, username := range usernames {
+		if username == "" || seen[username] {
+			continue
+		}
+		seen[username] = true
+		unique = append(unique, username)
+	}
+	if len(unique) == 0 {
+		return []*domain.UserRef{}, nil
+	}
+
+	users, err := s.authRepository.GetByUsernames(ctx, unique)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error looking up users")
+		return nil, fmt.Errorf("failed to look up users: %w", err)
+	}
+
+	refs := make([]*domain.UserRef, 0, len(users))
+	for _, user := range users {
+		refs = append(refs, &domain.UserRef{
+			ID:        user.ID,
+			Username:  user.Username,
+			AvatarURL: avatar.URL(s.cfg.PublicURL, user.ID),
+		})
+	}
+	return refs, nil
+}
+
+func (s *AuthServiceImpl) recordLoginFailure(ctx context.Context, userID int64, username, reason string) {
+	s.logins.WithLabelValues(metrics.LoginFailure, reason).Inc()
+
+	event := &audit.Event{
+		ActorID:  userID,
+		Action:   audit.ActionLoginFailure,
+		Metadata: map[string]interface{}{"username": username, "reason": reason},
+	}
+	if userID != 0 {
+		event.TargetType = "user"
+		event.TargetID = strconv.FormatInt(userID, 10)
+	}
+	s.recordAudit(ctx, event)
+}
+
+// recordAudit пишет событие в журнал аудита; сбой записи не прерывает операцию.
+func (s *AuthServiceImpl) recordAudit(ctx context.Context, event *audit.Event) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	if err := s.auditor.Record(ctx, event); err != nil {
+		logger.Error().Err(err).Str("action", event.Action).Msg("Failed to record audit event")
+	}
+}
+
 func (s *AuthServiceImpl) ValidateToken(token string) (int64, error) {
 	s.logger.Info().Msg("ValidateToken called")
 
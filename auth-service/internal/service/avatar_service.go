@@ -0,0 +1,12 @@
+package service
+
+import (
+	"context"
+	"io"
+)
+
+type AvatarService interface {
+	UploadAvatar(ctx context.Context, userID int64, r io.Reader) error
+	RemoveAvatar(ctx context.Context, userID int64) error
+	OpenAvatar(ctx context.Context, userID int64, size int) (io.ReadCloser, string, error)
+}
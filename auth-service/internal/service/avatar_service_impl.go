@@ -0,0 +1,191 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"strconv"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/repository"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/avatar"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/blobstore"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/imaging"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type AvatarServiceImpl struct {
+	authRepository repository.AuthRepository
+	store          blobstore.BlobStore
+	maxBytes       int64
+	logger         zerolog.Logger
+}
+
+func NewAvatarServiceImpl(authRepository *repository.AuthRepositoryImpl, store blobstore.BlobStore, maxBytes int64) AvatarService {
+	return &AvatarServiceImpl{
+		authRepository: authRepository,
+		store:          store,
+		maxBytes:       maxBytes,
+		logger:         log.With().Str("component", "avatar_service").Logger(),
+	}
+}
+
+// UploadAvatar обрезает изображение до квадрата, сохраняет его во всех размерах
+// avatar.Sizes под новой версией и только затем переключает пользователя на неё,
+// так что клиенты никогда не видят частично записанный аватар.
+func (s *AvatarServiceImpl) UploadAvatar(ctx context.Context, userID int64, r io.Reader) error {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Int64("user_id", userID).Msg("UploadAvatar called")
+
+	user, err := s.authRepository.GetByID(ctx, userID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting user by ID")
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return domain.ErrUserNotFound
+	}
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		return fmt.Errorf("failed to read avatar: %w", err)
+	}
+	if int64(len(data)) > s.maxBytes {
+		return domain.ErrAvatarTooLarge
+	}
+
+	img, format, err := imaging.Decode(bytes.NewReader(data))
+	if err != nil || (format != "png" && format != "jpeg" && format != "gif") {
+		logger.Warn().Err(err).Str("format", format).Msg("Unsupported avatar image")
+		return domain.ErrUnsupportedImage
+	}
+	square := imaging.CropSquare(img)
+
+	version, err := newAvatarVersion()
+	if err != nil {
+		return err
+	}
+
+	stored := make([]string, 0, len(avatar.Sizes))
+	for _, size := range avatar.Sizes {
+		encoded, err := imaging.EncodePNG(imaging.Resize(square, size, size))
+		if err != nil {
+			s.deleteKeys(ctx, stored)
+			return err
+		}
+
+		key := avatar.Key(userID, version, size)
+		if err := s.store.Put(ctx, key, bytes.NewReader(encoded), int64(len(encoded)), "image/png"); err != nil {
+			logger.Error().Err(err).Str("key", key).Msg("Error storing avatar")
+			s.deleteKeys(ctx, stored)
+			return fmt.Errorf("failed to store avatar: %w", err)
+		}
+		stored = append(stored, key)
+	}
+
+	if err := s.authRepository.UpdateAvatar(ctx, userID, version); err != nil {
+		s.deleteKeys(ctx, stored)
+		return err
+	}
+	s.deleteVersion(ctx, userID, user.AvatarVersion)
+
+	logger.Info().Int64("user_id", userID).Str("avatar_version", version).Msg("Avatar uploaded")
+	return nil
+}
+
+// RemoveAvatar возвращает пользователю сгенерированный identicon.
+func (s *AvatarServiceImpl) RemoveAvatar(ctx context.Context, userID int64) error {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Int64("user_id", userID).Msg("RemoveAvatar called")
+
+	user, err := s.authRepository.GetByID(ctx, userID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting user by ID")
+		return fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return domain.ErrUserNotFound
+	}
+	if user.AvatarVersion == "" {
+		return nil
+	}
+
+	if err := s.authRepository.UpdateAvatar(ctx, userID, ""); err != nil {
+		return err
+	}
+	s.deleteVersion(ctx, userID, user.AvatarVersion)
+
+	logger.Info().Int64("user_id", userID).Msg("Avatar removed")
+	return nil
+}
+
+// OpenAvatar возвращает PNG аватара нужного размера и его ETag. Если аватар не
+// загружен или файл пропал из хранилища, отдаётся identicon.
+func (s *AvatarServiceImpl) OpenAvatar(ctx context.Context, userID int64, size int) (io.ReadCloser, string, error) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	size = avatar.NormalizeSize(size)
+
+	user, err := s.authRepository.GetByID(ctx, userID)
+	if err != nil {
+		logger.Error().Err(err).Int64("user_id", userID).Msg("Error getting user by ID")
+		return nil, "", fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, "", domain.ErrUserNotFound
+	}
+
+	if user.AvatarVersion != "" {
+		body, err := s.store.Get(ctx, avatar.Key(userID, user.AvatarVersion, size))
+		if err == nil {
+			return body, fmt.Sprintf("%s-%d", user.AvatarVersion, size), nil
+		}
+		if !errors.Is(err, blobstore.ErrNotFound) {
+			logger.Error().Err(err).Int64("user_id", userID).Msg("Error opening avatar")
+			return nil, "", fmt.Errorf("failed to open avatar: %w", err)
+		}
+		logger.Warn().Int64("user_id", userID).Msg("Avatar blob missing, falling back to identicon")
+	}
+
+	encoded, err := imaging.EncodePNG(avatar.Identicon(strconv.FormatInt(userID, 10), size))
+	if err != nil {
+		return nil, "", err
+	}
+	return io.NopCloser(bytes.NewReader(encoded)), fmt.Sprintf("identicon-%d", size), nil
+}
+
+func (s *AvatarServiceImpl) deleteVersion(ctx context.Context, userID int64, version string) {
+	if version == "" {
+		return
+	}
+	keys := make([]string, 0, len(avatar.Sizes))
+	for _, size := range avatar.Sizes {
+		keys = append(keys, avatar.Key(userID, version, size))
+	}
+	s.deleteKeys(ctx, keys)
+}
+
+func (s *AvatarServiceImpl) deleteKeys(ctx context.Context, keys []string) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	for _, key := range keys {
+		if err := s.store.Delete(ctx, key); err != nil {
+			logger.Warn().Err(err).Str("key", key).Msg("Error deleting avatar blob")
+		}
+	}
+}
+
+func newAvatarVersion() (string, error) {
+	buf := make([]byte, 8)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate avatar version: %w", err)
+	}
+	return hex.EncodeToString(buf), nil
+}
@@ -0,0 +1,17 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+)
+
+type SanctionService interface {
+	IssueSanction(ctx context.Context, userID int64, sanctionType, reason string, duration time.Duration, actorID int64) (*domain.Sanction, error)
+	RevokeSanction(ctx context.Context, id, actorID int64) (*domain.Sanction, error)
+	ListSanctions(ctx context.Context, userID int64) ([]*domain.Sanction, error)
+	ActiveSanctions(ctx context.Context, userID int64) ([]*domain.Sanction, error)
+	// ActiveBan возвращает действующий бан пользователя или nil.
+	ActiveBan(ctx context.Context, userID int64) (*domain.Sanction, error)
+}
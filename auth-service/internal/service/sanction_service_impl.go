@@ -0,0 +1,194 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/repository"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type SanctionServiceImpl struct {
+	sanctionRepository repository.SanctionRepository
+	authRepository     repository.AuthRepository
+	auditor            audit.Recorder
+	logger             zerolog.Logger
+}
+
+func NewSanctionServiceImpl(sanctionRepository *repository.SanctionRepositoryImpl, authRepository *repository.AuthRepositoryImpl, auditor audit.Recorder) SanctionService {
+	return &SanctionServiceImpl{
+		sanctionRepository: sanctionRepository,
+		authRepository:     authRepository,
+		auditor:            auditor,
+		logger:             log.With().Str("component", "sanction_service").Logger(),
+	}
+}
+
+// IssueSanction назначает пользователю бан, мьют или запрет на посты.
+// Нулевая длительность означает бессрочную санкцию.
+func (s *SanctionServiceImpl) IssueSanction(ctx context.Context, userID int64, sanctionType, reason string, duration time.Duration, actorID int64) (*domain.Sanction, error) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().
+		Int64("user_id", userID).
+		Str("type", sanctionType).
+		Dur("duration", duration).
+		Int64("actor_id", actorID).
+		Msg("IssueSanction called")
+
+	reason = strings.TrimSpace(reason)
+	if !domain.IsValidSanctionType(sanctionType) {
+		return nil, domain.Invalid("invalid sanction type: %q", sanctionType)
+	}
+	if reason == "" {
+		return nil, domain.Invalid("reason is required")
+	}
+	if duration < 0 {
+		return nil, domain.Invalid("duration cannot be negative")
+	}
+	if userID == actorID {
+		return nil, domain.Invalid("cannot sanction yourself")
+	}
+
+	user, err := s.authRepository.GetByID(ctx, userID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting user by ID")
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+	if user == nil {
+		return nil, domain.ErrUserNotFound
+	}
+
+	actor, err := s.authRepository.GetByID(ctx, actorID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error getting actor by ID")
+		return nil, fmt.Errorf("failed to get actor: %w", err)
+	}
+	if actor == nil || !domain.Outranks(actor.Role, user.Role) {
+		logger.Warn().
+			Str("target_role", user.Role).
+			Msg("Sanction against an equal or higher role refused")
+		return nil, domain.ErrOutranked
+	}
+
+	sanction := &domain.Sanction{
+		UserID:  userID,
+		Type:    sanctionType,
+		Reason:  reason,
+		ActorID: actorID,
+	}
+	if duration > 0 {
+		sanction.ExpiresAt = time.Now().Add(duration).Format(time.RFC3339)
+	}
+
+	id, err := s.sanctionRepository.Create(ctx, sanction)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error creating sanction in repository")
+		return nil, fmt.Errorf("failed to create sanction: %w", err)
+	}
+
+	created, err := s.sanctionRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch created sanction: %w", err)
+	}
+
+	s.recordAudit(ctx, actorID, audit.ActionSanctionIssue, created)
+
+	logger.Info().Int64("sanction_id", id).Msg("Sanction issued successfully")
+	return created, nil
+}
+
+func (s *SanctionServiceImpl) RevokeSanction(ctx context.Context, id, actorID int64) (*domain.Sanction, error) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().
+		Int64("sanction_id", id).
+		Int64("actor_id", actorID).
+		Msg("RevokeSanction called")
+
+	if err := s.sanctionRepository.Revoke(ctx, id, actorID); err != nil {
+		if errors.Is(err, domain.ErrSanctionNotFound) {
+			return nil, err
+		}
+		logger.Error().Err(err).Msg("Error revoking sanction in repository")
+		return nil, fmt.Errorf("failed to revoke sanction: %w", err)
+	}
+
+	sanction, err := s.sanctionRepository.GetByID(ctx, id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to fetch revoked sanction: %w", err)
+	}
+
+	s.recordAudit(ctx, actorID, audit.ActionSanctionRevoke, sanction)
+
+	logger.Info().Int64("sanction_id", id).Msg("Sanction revoked successfully")
+	return sanction, nil
+}
+
+func (s *SanctionServiceImpl) recordAudit(ctx context.Context, actorID int64, action string, sanction *domain.Sanction) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	err := s.auditor.Record(ctx, &audit.Event{
+		ActorID:    actorID,
+		Action:     action,
+		TargetType: "user",
+		TargetID:   strconv.FormatInt(sanction.UserID, 10),
+		Metadata: map[string]interface{}{
+			"sanction_id": sanction.ID,
+			"type":        sanction.Type,
+			"reason":      sanction.Reason,
+			"expires_at":  sanction.ExpiresAt,
+		},
+	})
+	if err != nil {
+		logger.Error().Err(err).Str("action", action).Msg("Failed to record audit event")
+	}
+}
+
+func (s *SanctionServiceImpl) ListSanctions(ctx context.Context, userID int64) ([]*domain.Sanction, error) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Info().Int64("user_id", userID).Msg("ListSanctions called")
+
+	sanctions, err := s.sanctionRepository.ListByUser(ctx, userID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error listing sanctions")
+		return nil, fmt.Errorf("failed to list sanctions: %w", err)
+	}
+
+	return sanctions, nil
+}
+
+func (s *SanctionServiceImpl) ActiveSanctions(ctx context.Context, userID int64) ([]*domain.Sanction, error) {
+	logger := s.logger.With().Ctx(ctx).Logger()
+
+	logger.Debug().Int64("user_id", userID).Msg("ActiveSanctions called")
+
+	sanctions, err := s.sanctionRepository.ListActiveByUser(ctx, userID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Error listing active sanctions")
+		return nil, fmt.Errorf("failed to list active sanctions: %w", err)
+	}
+
+	return sanctions, nil
+}
+
+func (s *SanctionServiceImpl) ActiveBan(ctx context.Context, userID int64) (*domain.Sanction, error) {
+	sanctions, err := s.ActiveSanctions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	for _, sanction := range sanctions {
+		if sanction.Type == domain.SanctionBan {
+			return sanction, nil
+		}
+	}
+	return nil, nil
+}
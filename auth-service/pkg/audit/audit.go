@@ -0,0 +1,67 @@
+// Package audit ведёт неизменяемый журнал действий, важных для безопасности
+// и модерации. Используется и auth-service, и forum-service: у каждого своя
+// таблица audit_events в собственной базе.
+package audit
+
+import (
+	"context"
+	"time"
+)
+
+const (
+	ActionUserRegister      = "user.register"
+	ActionLoginSuccess      = "auth.login_success"
+	ActionLoginFailure      = "auth.login_failure"
+	ActionRoleChange        = "user.role_change"
+	ActionSanctionIssue     = "moderation.sanction_issue"
+	ActionSanctionRevoke    = "moderation.sanction_revoke"
+	ActionPostDelete        = "post.delete"
+	ActionMessageDelete     = "moderation.message_delete"
+	ActionPostFlagsUpdate   = "moderation.post_flags_update"
+	ActionReportCreate      = "report.create"
+	ActionReportResolve     = "moderation.report_resolve"
+	ActionContentAutoHidden = "moderation.content_auto_hidden"
+	ActionChatSlowMode      = "moderation.chat_slow_mode"
+	ActionContentFiltered   = "moderation.content_filtered"
+)
+
+type Event struct {
+	ID         int64                  `json:"id"`
+	ActorID    int64                  `json:"actor_id,omitempty"`
+	Action     string                 `json:"action"`
+	TargetType string                 `json:"target_type,omitempty"`
+	TargetID   string                 `json:"target_id,omitempty"`
+	IP         string                 `json:"ip,omitempty"`
+	UserAgent  string                 `json:"user_agent,omitempty"`
+	Metadata   map[string]interface{} `json:"metadata,omitempty"`
+	CreatedAt  string                 `json:"created_at"`
+}
+
+// Recorder записывает событие в журнал. IP и User-Agent, если не заданы явно,
+// берутся из контекста запроса (см. Middleware).
+type Recorder interface {
+	Record(ctx context.Context, event *Event) error
+}
+
+// Filter задаёт выборку журнала. Пустые поля не фильтруют; Cursor — значение
+// NextCursor с предыдущей страницы.
+type Filter struct {
+	ActorID    int64
+	Action     string
+	TargetType string
+	TargetID   string
+	Since      time.Time
+	Until      time.Time
+	Cursor     string
+	Limit      int
+}
+
+type Page struct {
+	Events     []*Event `json:"events"`
+	NextCursor string   `json:"next_cursor,omitempty"`
+}
+
+// NopRecorder ничего не записывает; удобен там, где журнал не нужен.
+type NopRecorder struct{}
+
+func (NopRecorder) Record(context.Context, *Event) error { return nil }
@@ -0,0 +1,33 @@
+package audit
+
+import (
+	"context"
+
+	"github.com/gin-gonic/gin"
+)
+
+type requestInfoKey struct{}
+
+type requestInfo struct {
+	ip        string
+	userAgent string
+}
+
+// WithRequestInfo сохраняет в контексте адрес и User-Agent клиента.
+func WithRequestInfo(ctx context.Context, ip, userAgent string) context.Context {
+	return context.WithValue(ctx, requestInfoKey{}, requestInfo{ip: ip, userAgent: userAgent})
+}
+
+func requestInfoFrom(ctx context.Context) (requestInfo, bool) {
+	info, ok := ctx.Value(requestInfoKey{}).(requestInfo)
+	return info, ok
+}
+
+// Middleware кладёт данные клиента в контекст запроса, чтобы сервисы могли
+// писать события аудита, не зная про HTTP.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		c.Request = c.Request.WithContext(WithRequestInfo(c.Request.Context(), c.ClientIP(), c.Request.UserAgent()))
+		c.Next()
+	}
+}
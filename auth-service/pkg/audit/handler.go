@@ -0,0 +1,64 @@
+package audit
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/gin-gonic/gin"
+)
+
+var errInvalidCursor = problem.New(http.StatusBadRequest, "invalid_cursor", ErrInvalidCursor.Error())
+
+// QueryHandler отдаёт журнал с фильтрами из query-параметров:
+// actor_id, action, target_type, target_id, since, until (RFC3339), cursor, limit.
+func QueryHandler(store *Store) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		filter := Filter{
+			Action:     c.Query("action"),
+			TargetType: c.Query("target_type"),
+			TargetID:   c.Query("target_id"),
+			Cursor:     c.Query("cursor"),
+		}
+
+		var err error
+		if v := c.Query("actor_id"); v != "" {
+			if filter.ActorID, err = strconv.ParseInt(v, 10, 64); err != nil {
+				problem.Abort(c, problem.ErrInvalidParam.WithDetail("invalid actor_id"))
+				return
+			}
+		}
+		if v := c.Query("limit"); v != "" {
+			if filter.Limit, err = strconv.Atoi(v); err != nil {
+				problem.Abort(c, problem.ErrInvalidParam.WithDetail("invalid limit"))
+				return
+			}
+		}
+		if v := c.Query("since"); v != "" {
+			if filter.Since, err = time.Parse(time.RFC3339, v); err != nil {
+				problem.Abort(c, problem.ErrInvalidParam.WithDetail("invalid since, expected RFC3339"))
+				return
+			}
+		}
+		if v := c.Query("until"); v != "" {
+			if filter.Until, err = time.Parse(time.RFC3339, v); err != nil {
+				problem.Abort(c, problem.ErrInvalidParam.WithDetail("invalid until, expected RFC3339"))
+				return
+			}
+		}
+
+		page, err := store.Query(c.Request.Context(), filter)
+		if err != nil {
+			if errors.Is(err, ErrInvalidCursor) {
+				problem.Abort(c, errInvalidCursor)
+				return
+			}
+			problem.Abort(c, err)
+			return
+		}
+
+		c.JSON(http.StatusOK, page)
+	}
+}
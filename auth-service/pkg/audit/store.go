@@ -0,0 +1,225 @@
+package audit
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultPageSize = 50
+	maxPageSize     = 200
+)
+
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Migrate создаёт таблицу audit_events. Триггер запрещает UPDATE и DELETE,
+// так что журнал можно только дополнять.
+func Migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS audit_events (
+			id BIGSERIAL PRIMARY KEY,
+			actor_id BIGINT,
+			action TEXT NOT NULL,
+			target_type TEXT NOT NULL DEFAULT '',
+			target_id TEXT NOT NULL DEFAULT '',
+			ip TEXT NOT NULL DEFAULT '',
+			user_agent TEXT NOT NULL DEFAULT '',
+			metadata JSONB NOT NULL DEFAULT '{}',
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_audit_events_actor ON audit_events (actor_id, id);
+		CREATE INDEX IF NOT EXISTS idx_audit_events_action ON audit_events (action, id);
+		CREATE INDEX IF NOT EXISTS idx_audit_events_target ON audit_events (target_type, target_id, id);
+
+		CREATE OR REPLACE FUNCTION audit_events_append_only() RETURNS trigger AS $$
+		BEGIN
+			RAISE EXCEPTION 'audit_events is append-only';
+		END;
+		$$ LANGUAGE plpgsql;
+
+		DROP TRIGGER IF EXISTS audit_events_no_modify ON audit_events;
+		CREATE TRIGGER audit_events_no_modify
+			BEFORE UPDATE OR DELETE ON audit_events
+			FOR EACH ROW EXECUTE FUNCTION audit_events_append_only();
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create audit_events table: %w", err)
+	}
+	return nil
+}
+
+// Store хранит журнал аудита в PostgreSQL.
+type Store struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db:     db,
+		logger: log.With().Str("component", "audit_store").Logger(),
+	}
+}
+
+func (s *Store) Record(ctx context.Context, event *Event) error {
+	if info, ok := requestInfoFrom(ctx); ok {
+		if event.IP == "" {
+			event.IP = info.ip
+		}
+		if event.UserAgent == "" {
+			event.UserAgent = info.userAgent
+		}
+	}
+
+	metadata := []byte("{}")
+	if len(event.Metadata) > 0 {
+		var err error
+		if metadata, err = json.Marshal(event.Metadata); err != nil {
+			return fmt.Errorf("failed to encode audit metadata: %w", err)
+		}
+	}
+
+	var actorID sql.NullInt64
+	if event.ActorID != 0 {
+		actorID = sql.NullInt64{Int64: event.ActorID, Valid: true}
+	}
+
+	query := `
+		INSERT INTO audit_events (actor_id, action, target_type, target_id, ip, user_agent, metadata, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	createdAt := time.Now()
+	err := s.db.QueryRowContext(ctx, query,
+		actorID, event.Action, event.TargetType, event.TargetID,
+		event.IP, event.UserAgent, metadata, createdAt,
+	).Scan(&event.ID)
+	if err != nil {
+		s.logger.Error().Err(err).Str("action", event.Action).Msg("Failed to record audit event")
+		return fmt.Errorf("failed to record audit event: %w", err)
+	}
+	event.CreatedAt = createdAt.Format(time.RFC3339)
+
+	s.logger.Debug().
+		Int64("event_id", event.ID).
+		Str("action", event.Action).
+		Msg("Audit event recorded")
+	return nil
+}
+
+// Query возвращает события от новых к старым. Курсор — ID последнего
+// события предыдущей страницы.
+func (s *Store) Query(ctx context.Context, filter Filter) (*Page, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	} else if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	var conditions []string
+	var args []interface{}
+	add := func(cond string, value interface{}) {
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf(cond, len(args)))
+	}
+
+	if filter.Cursor != "" {
+		beforeID, err := decodeCursor(filter.Cursor)
+		if err != nil {
+			return nil, err
+		}
+		add("id < $%d", beforeID)
+	}
+	if filter.ActorID != 0 {
+		add("actor_id = $%d", filter.ActorID)
+	}
+	if filter.Action != "" {
+		add("action = $%d", filter.Action)
+	}
+	if filter.TargetType != "" {
+		add("target_type = $%d", filter.TargetType)
+	}
+	if filter.TargetID != "" {
+		add("target_id = $%d", filter.TargetID)
+	}
+	if !filter.Since.IsZero() {
+		add("created_at >= $%d", filter.Since)
+	}
+	if !filter.Until.IsZero() {
+		add("created_at < $%d", filter.Until)
+	}
+
+	query := `
+		SELECT id, actor_id, action, target_type, target_id, ip, user_agent, metadata, created_at
+		FROM audit_events
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	// Берём на одну запись больше, чтобы понять, есть ли следующая страница.
+	args = append(args, filter.Limit+1)
+	query += fmt.Sprintf(" ORDER BY id DESC LIMIT $%d", len(args))
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		s.logger.Error().Err(err).Msg("Failed to query audit events")
+		return nil, fmt.Errorf("failed to query audit events: %w", err)
+	}
+	defer rows.Close()
+
+	page := &Page{Events: []*Event{}}
+	for rows.Next() {
+		var event Event
+		var actorID sql.NullInt64
+		var metadata []byte
+		var createdAt time.Time
+
+		if err := rows.Scan(&event.ID, &actorID, &event.Action, &event.TargetType, &event.TargetID,
+			&event.IP, &event.UserAgent, &metadata, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan audit event: %w", err)
+		}
+		if err := json.Unmarshal(metadata, &event.Metadata); err != nil {
+			return nil, fmt.Errorf("failed to decode audit metadata: %w", err)
+		}
+		event.ActorID = actorID.Int64
+		event.CreatedAt = createdAt.Format(time.RFC3339)
+		page.Events = append(page.Events, &event)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	if len(page.Events) > filter.Limit {
+		page.Events = page.Events[:filter.Limit]
+		page.NextCursor = encodeCursor(page.Events[filter.Limit-1].ID)
+	}
+
+	return page, nil
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,78 @@
+// Package avatar описывает размеры аватаров, их адреса и генерирует
+// identicon для пользователей без загруженного аватара.
+package avatar
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"golang.org/x/image/draw"
+)
+
+// Sizes — стороны квадратных аватаров, которые хранятся для каждого пользователя.
+var Sizes = []int{32, 64, 128, 256}
+
+const DefaultSize = 128
+
+// URL возвращает публичный адрес аватара пользователя в auth-service. Адрес
+// не зависит от того, загружен ли аватар, поэтому другие сервисы строят его сами.
+func URL(baseURL string, userID int64) string {
+	if userID <= 0 {
+		return ""
+	}
+	return strings.TrimSuffix(baseURL, "/") + "/api/v1/avatars/" + strconv.FormatInt(userID, 10)
+}
+
+// NormalizeSize возвращает ближайший поддерживаемый размер не меньше запрошенного.
+func NormalizeSize(size int) int {
+	if size <= 0 {
+		return DefaultSize
+	}
+	for _, s := range Sizes {
+		if size <= s {
+			return s
+		}
+	}
+	return Sizes[len(Sizes)-1]
+}
+
+// Key возвращает ключ хранилища для версии аватара version нужного размера.
+func Key(userID int64, version string, size int) string {
+	return fmt.Sprintf("avatars/%d/%s_%d.png", userID, version, size)
+}
+
+// Identicon рисует симметричный узор 5×5, однозначно определяемый seed.
+func Identicon(seed string, size int) image.Image {
+	const grid = 5
+	sum := sha256.Sum256([]byte(seed))
+
+	fg := color.RGBA{R: sum[0]/2 + 64, G: sum[1]/2 + 64, B: sum[2]/2 + 64, A: 255}
+	bg := color.RGBA{R: 240, G: 240, B: 240, A: 255}
+
+	const cell = 16
+	const pad = cell / 2
+	side := grid*cell + 2*pad
+	img := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: bg}, image.Point{}, draw.Src)
+
+	for row := 0; row < grid; row++ {
+		for col := 0; col < (grid+1)/2; col++ {
+			if sum[3+row*3+col]%2 == 0 {
+				continue
+			}
+			for _, x := range []int{pad + col*cell, pad + (grid-1-col)*cell} {
+				rect := image.Rect(x, pad+row*cell, x+cell, pad+(row+1)*cell)
+				draw.Draw(img, rect, &image.Uniform{C: fg}, image.Point{}, draw.Src)
+			}
+		}
+	}
+
+	// Чёткие края клеток важнее сглаживания, поэтому масштабируем без интерполяции.
+	dst := image.NewRGBA(image.Rect(0, 0, size, size))
+	draw.NearestNeighbor.Scale(dst, dst.Bounds(), img, img.Bounds(), draw.Src, nil)
+	return dst
+}
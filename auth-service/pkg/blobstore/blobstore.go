@@ -0,0 +1,60 @@
+// Package blobstore хранит загруженные пользователями файлы (вложения, аватары)
+// за общим интерфейсом, чтобы сервисы не зависели от конкретного хранилища.
+// Используется и auth-service (аватары), и forum-service (вложения), поэтому
+// лежит в pkg auth-service рядом с остальными общими пакетами.
+package blobstore
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+)
+
+var ErrNotFound = errors.New("blob not found")
+
+// BlobStore — хранилище бинарных объектов по строковому ключу вида "uploads/2025/01/abc.png".
+type BlobStore interface {
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
+
+// Config описывает выбор и настройки хранилища.
+type Config struct {
+	Backend string // "local" или "s3"
+
+	LocalDir string
+
+	S3Endpoint  string
+	S3Region    string
+	S3Bucket    string
+	S3AccessKey string
+	S3SecretKey string
+}
+
+// New создаёт хранилище по конфигурации.
+func New(cfg Config) (BlobStore, error) {
+	switch cfg.Backend {
+	case "", "local":
+		return NewLocalStore(cfg.LocalDir)
+	case "s3":
+		return NewS3Store(cfg.S3Endpoint, cfg.S3Region, cfg.S3Bucket, cfg.S3AccessKey, cfg.S3SecretKey)
+	default:
+		return nil, fmt.Errorf("unknown blob store backend: %q", cfg.Backend)
+	}
+}
+
+// validateKey отсекает пустые ключи и попытки выйти за пределы хранилища.
+func validateKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") {
+		return fmt.Errorf("invalid blob key: %q", key)
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == "" || part == "." || part == ".." {
+			return fmt.Errorf("invalid blob key: %q", key)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,173 @@
+// Package health отдаёт /healthz (процесс жив) и /readyz (зависимости
+// доступны) для балансировщика и оркестратора. Используется и auth-service,
+// и forum-service: каждый регистрирует свои проверки.
+package health
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	StatusOK          = "ok"
+	StatusDegraded    = "degraded"
+	StatusFailing     = "failing"
+	StatusUnavailable = "unavailable"
+	StatusShutdown    = "shutting_down"
+)
+
+// CheckTimeout — сколько ждать одну проверку готовности.
+const CheckTimeout = 2 * time.Second
+
+// Check проверяет одну зависимость. Ошибка, обёрнутая в Degraded, попадает
+// в ответ, но не делает сервис неготовым.
+type Check func(ctx context.Context) error
+
+type degradedError struct {
+	err error
+}
+
+func (e *degradedError) Error() string { return e.err.Error() }
+func (e *degradedError) Unwrap() error { return e.err }
+
+// Degraded помечает ошибку проверки как некритичную: сервис работает
+// с ограничениями, но трафик принимать может.
+func Degraded(err error) error {
+	if err == nil {
+		return nil
+	}
+	return &degradedError{err: err}
+}
+
+// CheckResult — итог одной проверки в ответе /readyz.
+type CheckResult struct {
+	Status     string `json:"status"`
+	Error      string `json:"error,omitempty"`
+	DurationMS int64  `json:"duration_ms"`
+}
+
+// Report — тело ответа /readyz.
+type Report struct {
+	Status string                 `json:"status"`
+	Checks map[string]CheckResult `json:"checks"`
+}
+
+type namedCheck struct {
+	name  string
+	check Check
+}
+
+// Checker хранит проверки готовности и признак остановки сервиса.
+type Checker struct {
+	checks       []namedCheck
+	shuttingDown atomic.Bool
+}
+
+func NewChecker() *Checker {
+	return &Checker{}
+}
+
+// Add регистрирует проверку; вызывается до запуска сервера.
+func (h *Checker) Add(name string, check Check) {
+	h.checks = append(h.checks, namedCheck{name: name, check: check})
+}
+
+// ShutDown переводит /readyz в 503, чтобы балансировщик перестал направлять
+// запросы раньше, чем сервер закроет соединения.
+func (h *Checker) ShutDown() {
+	h.shuttingDown.Store(true)
+}
+
+// Run выполняет все проверки параллельно, каждую — не дольше CheckTimeout.
+func (h *Checker) Run(ctx context.Context) *Report {
+	report := &Report{
+		Status: StatusOK,
+		Checks: make(map[string]CheckResult, len(h.checks)),
+	}
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for _, nc := range h.checks {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result := runCheck(ctx, nc.check)
+
+			mu.Lock()
+			defer mu.Unlock()
+			report.Checks[nc.name] = result
+			switch {
+			case result.Status == StatusFailing:
+				report.Status = StatusUnavailable
+			case result.Status == StatusDegraded && report.Status == StatusOK:
+				report.Status = StatusDegraded
+			}
+		}()
+	}
+	wg.Wait()
+
+	if h.shuttingDown.Load() {
+		report.Status = StatusShutdown
+	}
+	return report
+}
+
+func runCheck(ctx context.Context, check Check) CheckResult {
+	ctx, cancel := context.WithTimeout(ctx, CheckTimeout)
+	defer cancel()
+
+	start := time.Now()
+	err := check(ctx)
+	result := CheckResult{
+		Status:     StatusOK,
+		DurationMS: time.Since(start).Milliseconds(),
+	}
+
+	var degraded *degradedError
+	switch {
+	case err == nil:
+	case errors.As(err, &degraded):
+		result.Status = StatusDegraded
+		result.Error = err.Error()
+	default:
+		result.Status = StatusFailing
+		result.Error = err.Error()
+	}
+	return result
+}
+
+// Live — обработчик /healthz: процесс жив и обслуживает HTTP. Зависимости
+// не проверяются, чтобы оркестратор не перезапускал сервис из-за упавшей базы.
+func (h *Checker) Live(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"status": StatusOK})
+}
+
+// Ready — обработчик /readyz: 200, если все критичные проверки прошли,
+// иначе 503 с подробностями по каждой проверке.
+func (h *Checker) Ready(c *gin.Context) {
+	report := h.Run(c.Request.Context())
+
+	status := http.StatusOK
+	if report.Status == StatusUnavailable || report.Status == StatusShutdown {
+		status = http.StatusServiceUnavailable
+	}
+	c.JSON(status, report)
+}
+
+// PingDB проверяет, что база отвечает.
+func PingDB(db *sql.DB) Check {
+	return func(ctx context.Context) error {
+		if err := db.PingContext(ctx); err != nil {
+			return fmt.Errorf("database ping failed: %w", err)
+		}
+		return nil
+	}
+}
@@ -200,10 +200,11 @@ func ValidateTokenWithClaims(tokenString, secretKey string) (*CustomClaims, erro
 }
 
 // GenerateJWTWithClaims генерирует JWT с claims и логированием
-func GenerateJWTWithClaims(userID int64, username, secretKey string, expiresIn int) (string, error) {
+func GenerateJWTWithClaims(userID int64, username, role, secretKey string, expiresIn int) (string, error) {
 	log.Debug().
 		Int64("user_id", userID).
 		Str("username", username).
+		Str("role", role).
 		Int("expires_in_sec", expiresIn).
 		Msg("Starting JWT generation with claims")
 
@@ -211,8 +212,10 @@ func GenerateJWTWithClaims(userID int64, username, secretKey string, expiresIn i
 	claims := CustomClaims{
 		UserID:   userID,
 		Username: username,
+		Role:     role,
 		RegisteredClaims: jwt.RegisteredClaims{
 			ExpiresAt: jwt.NewNumericDate(time.Now().Add(time.Duration(expiresIn) * time.Second)),
+			Issuer:    "auth-service",
 		},
 	}
 
@@ -249,5 +252,6 @@ var ErrInvalidToken = errors.New("invalid token")
 type CustomClaims struct {
 	UserID   int64  `json:"user_id"`
 	Username string `json:"username"`
+	Role     string `json:"role,omitempty"`
 	jwt.RegisteredClaims
 }
@@ -0,0 +1,91 @@
+// Package imaging декодирует загруженные изображения и готовит их уменьшенные копии.
+// Используется и auth-service (аватары), и forum-service (миниатюры вложений).
+package imaging
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+
+	// Регистрация декодеров для image.Decode.
+	_ "image/gif"
+	_ "image/jpeg"
+
+	"golang.org/x/image/draw"
+	_ "golang.org/x/image/webp"
+)
+
+// MaxPixels ограничивает размер декодируемого изображения, чтобы маленький
+// файл с огромными заявленными размерами не съел память.
+const MaxPixels = 40_000_000
+
+// Decode читает PNG, JPEG, WebP или GIF (первый кадр) и возвращает изображение и его формат.
+func Decode(r io.Reader) (image.Image, string, error) {
+	var buf bytes.Buffer
+	cfg, format, err := image.DecodeConfig(io.TeeReader(r, &buf))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to read image header: %w", err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, "", fmt.Errorf("image dimensions %dx%d are not allowed", cfg.Width, cfg.Height)
+	}
+
+	img, _, err := image.Decode(io.MultiReader(&buf, r))
+	if err != nil {
+		return nil, "", fmt.Errorf("failed to decode image: %w", err)
+	}
+	return img, format, nil
+}
+
+// Fit уменьшает изображение так, чтобы оно вписалось в maxSize×maxSize
+// с сохранением пропорций. Маленькие изображения не увеличиваются.
+func Fit(src image.Image, maxSize int) image.Image {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSize && h <= maxSize {
+		return src
+	}
+	if w >= h {
+		h = max(1, h*maxSize/w)
+		w = maxSize
+	} else {
+		w = max(1, w*maxSize/h)
+		h = maxSize
+	}
+	return scale(src, b, w, h)
+}
+
+func scale(src image.Image, srcRect image.Rectangle, w, h int) image.Image {
+	dst := image.NewRGBA(image.Rect(0, 0, w, h))
+	draw.CatmullRom.Scale(dst, dst.Bounds(), src, srcRect, draw.Over, nil)
+	return dst
+}
+
+// EncodePNG кодирует изображение в PNG.
+func EncodePNG(img image.Image) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := png.Encode(&buf, img); err != nil {
+		return nil, fmt.Errorf("failed to encode png: %w", err)
+	}
+	return buf.Bytes(), nil
+}
+
+// CropSquare вырезает из центра изображения квадрат со стороной, равной меньшей стороне.
+func CropSquare(src image.Image) image.Image {
+	b := src.Bounds()
+	side := min(b.Dx(), b.Dy())
+	x0 := b.Min.X + (b.Dx()-side)/2
+	y0 := b.Min.Y + (b.Dy()-side)/2
+	rect := image.Rect(x0, y0, x0+side, y0+side)
+
+	dst := image.NewRGBA(image.Rect(0, 0, side, side))
+	draw.Copy(dst, image.Point{}, src, rect, draw.Src, nil)
+	return dst
+}
+
+// Resize масштабирует изображение ровно до w×h без сохранения пропорций.
+func Resize(src image.Image, w, h int) image.Image {
+	return scale(src, src.Bounds(), w, h)
+}
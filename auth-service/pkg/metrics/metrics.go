@@ -0,0 +1,83 @@
+// Package metrics отдаёт метрики Prometheus на /metrics. Используется и
+// auth-service, и forum-service: у каждого свой реестр, в который сервис
+// добавляет метрики своих компонентов.
+package metrics
+
+import (
+	"database/sql"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+// NewRegistry создаёт реестр с метриками рантайма Go и процесса.
+func NewRegistry() *prometheus.Registry {
+	reg := prometheus.NewRegistry()
+	reg.MustRegister(
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+	return reg
+}
+
+// Handler — обработчик /metrics для реестра reg.
+func Handler(reg *prometheus.Registry) gin.HandlerFunc {
+	h := promhttp.HandlerFor(reg, promhttp.HandlerOpts{Registry: reg})
+	return gin.WrapH(h)
+}
+
+// RegisterDB добавляет статистику пула соединений sql.DB (go_sql_*)
+// с меткой db_name.
+func RegisterDB(reg prometheus.Registerer, db *sql.DB, name string) {
+	reg.MustRegister(collectors.NewDBStatsCollector(db, name))
+}
+
+// HTTP считает запросы и их длительность по методу, маршруту и статусу.
+// Маршрут — шаблон gin (/api/posts/:id), а не путь, чтобы число рядов
+// не росло с числом постов; запросы мимо маршрутов идут как "unmatched".
+func HTTP(reg prometheus.Registerer) gin.HandlerFunc {
+	requests := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "http_requests_total",
+		Help: "HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+	duration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Name:    "http_request_duration_seconds",
+		Help:    "HTTP request latency by method, route and status.",
+		Buckets: prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+	reg.MustRegister(requests, duration)
+
+	return func(c *gin.Context) {
+		start := time.Now()
+		c.Next()
+
+		route := c.FullPath()
+		if route == "" {
+			route = "unmatched"
+		}
+		status := strconv.Itoa(c.Writer.Status())
+		requests.WithLabelValues(c.Request.Method, route, status).Inc()
+		duration.WithLabelValues(c.Request.Method, route, status).Observe(time.Since(start).Seconds())
+	}
+}
+
+// Результаты входа для NewLoginCounter.
+const (
+	LoginSuccess = "success"
+	LoginFailure = "failure"
+)
+
+// NewLoginCounter регистрирует счётчик попыток входа auth_logins_total
+// с метками result (success, failure) и reason (причина отказа).
+func NewLoginCounter(reg prometheus.Registerer) *prometheus.CounterVec {
+	logins := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Name: "auth_logins_total",
+		Help: "Login attempts by result and failure reason.",
+	}, []string{"result", "reason"})
+	reg.MustRegister(logins)
+	return logins
+}
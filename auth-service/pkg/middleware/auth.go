@@ -0,0 +1,61 @@
+package middleware
+
+import (
+	"strings"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/helper"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// RequireAuth проверяет Bearer-токен и кладёт userID, username и role в контекст gin.
+func RequireAuth(secretKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := log.With().
+			Ctx(c.Request.Context()).
+			Str("middleware", "RequireAuth").
+			Str("path", c.Request.URL.Path).
+			Logger()
+
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" || parts[1] == "" {
+			logger.Warn().Msg("Missing or malformed authorization header")
+			problem.Abort(c, problem.ErrUnauthorized)
+			return
+		}
+
+		claims, err := helper.ValidateTokenWithClaims(parts[1], secretKey)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Token validation failed")
+			problem.Abort(c, problem.ErrInvalidToken)
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+		c.Next()
+	}
+}
+
+// RequireRole пропускает запрос только для перечисленных ролей. Ставится после RequireAuth.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		log.Warn().
+			Ctx(c.Request.Context()).
+			Str("middleware", "RequireRole").
+			Int64("user_id", c.GetInt64("userID")).
+			Str("role", role).
+			Msg("Insufficient role")
+		problem.Abort(c, problem.ErrForbidden)
+	}
+}
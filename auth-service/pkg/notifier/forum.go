@@ -0,0 +1,75 @@
+package notifier
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/requestid"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/tracing"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// ForumNotifier сообщает forum-service об изменении санкций, чтобы тот сразу
+// применил их к живым WebSocket-подключениям.
+type ForumNotifier struct {
+	baseURL string
+	client  *http.Client
+	logger  zerolog.Logger
+}
+
+func NewForumNotifier(baseURL string) *ForumNotifier {
+	return &ForumNotifier{
+		baseURL: baseURL,
+		client: &http.Client{
+			Timeout:   5 * time.Second,
+			Transport: requestid.Transport(tracing.Transport(http.DefaultTransport)),
+		},
+		logger: log.With().Str("component", "forum_notifier").Logger(),
+	}
+}
+
+// SanctionChanged отправляет санкцию в forum-service от имени модератора
+// (authHeader — его заголовок Authorization). Ошибки только логируются:
+// forum-service всё равно проверит санкции при следующем подключении.
+func (n *ForumNotifier) SanctionChanged(ctx context.Context, authHeader string, sanction *domain.Sanction) {
+	logger := n.logger.With().
+		Ctx(ctx).
+		Int64("sanction_id", sanction.ID).
+		Int64("user_id", sanction.UserID).
+		Str("type", sanction.Type).
+		Logger()
+
+	body, err := json.Marshal(sanction)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to encode sanction")
+		return
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.baseURL+"/api/moderation/sanctions/notify", bytes.NewReader(body))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to build notify request")
+		return
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Authorization", authHeader)
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to notify forum service")
+		return
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		logger.Warn().Err(fmt.Errorf("unexpected status %d", resp.StatusCode)).Msg("Forum service rejected sanction notification")
+		return
+	}
+
+	logger.Debug().Msg("Forum service notified")
+}
@@ -0,0 +1,137 @@
+// Package problem отдаёт ошибки API в формате RFC 7807
+// (application/problem+json). У каждой ошибки есть стабильный машиночитаемый
+// код, по которому клиенты различают ошибки, и HTTP-статус. Используется
+// и auth-service, и forum-service: общие ошибки объявлены здесь, ошибки
+// предметной области — в обработчиках сервисов.
+package problem
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/requestid"
+	"github.com/gin-gonic/gin"
+)
+
+// ContentType — тип тела ответа с ошибкой.
+const ContentType = "application/problem+json"
+
+// typePrefix — начало URI поля type; за ним следует код ошибки.
+const typePrefix = "urn:forum-app:problem:"
+
+// Error — ошибка API. Detail уходит клиенту как есть, поэтому в него нельзя
+// класть текст ошибок базы и других внутренних подробностей.
+type Error struct {
+	Status int
+	Code   string
+	Detail string
+	// Extensions — дополнительные поля ответа (например, причина бана).
+	Extensions map[string]interface{}
+}
+
+func New(status int, code, detail string) *Error {
+	return &Error{Status: status, Code: code, Detail: detail}
+}
+
+func (e *Error) Error() string {
+	return e.Code + ": " + e.Detail
+}
+
+// WithDetail возвращает копию ошибки с другим описанием.
+func (e *Error) WithDetail(detail string) *Error {
+	copied := *e
+	copied.Detail = detail
+	return &copied
+}
+
+// With возвращает копию ошибки с дополнительным полем ответа.
+func (e *Error) With(key string, value interface{}) *Error {
+	copied := *e
+	copied.Extensions = make(map[string]interface{}, len(e.Extensions)+1)
+	for k, v := range e.Extensions {
+		copied.Extensions[k] = v
+	}
+	copied.Extensions[key] = value
+	return &copied
+}
+
+// Общие ошибки обоих сервисов.
+var (
+	ErrInvalidRequest = New(http.StatusBadRequest, "invalid_request", "invalid request format")
+	ErrInvalidParam   = New(http.StatusBadRequest, "invalid_parameter", "invalid parameter")
+	ErrValidation     = New(http.StatusBadRequest, "validation_failed", "request validation failed")
+	ErrUnauthorized   = New(http.StatusUnauthorized, "unauthorized", "authentication required")
+	ErrInvalidToken   = New(http.StatusUnauthorized, "invalid_token", "invalid or expired token")
+	ErrForbidden      = New(http.StatusForbidden, "forbidden", "insufficient permissions")
+	ErrRouteNotFound  = New(http.StatusNotFound, "route_not_found", "no such endpoint")
+	ErrRateLimited    = New(http.StatusTooManyRequests, "rate_limited", "too many requests")
+	ErrInternal       = New(http.StatusInternalServerError, "internal_error", "internal server error")
+)
+
+// Problem — тело ответа по RFC 7807 с расширениями code и request_id.
+type Problem struct {
+	Type       string
+	Title      string
+	Status     int
+	Detail     string
+	Instance   string
+	Code       string
+	RequestID  string
+	Extensions map[string]interface{}
+}
+
+func (p *Problem) MarshalJSON() ([]byte, error) {
+	body := make(map[string]interface{}, len(p.Extensions)+7)
+	for k, v := range p.Extensions {
+		body[k] = v
+	}
+	body["type"] = p.Type
+	body["title"] = p.Title
+	body["status"] = p.Status
+	body["code"] = p.Code
+	if p.Detail != "" {
+		body["detail"] = p.Detail
+	}
+	if p.Instance != "" {
+		body["instance"] = p.Instance
+	}
+	if p.RequestID != "" {
+		body["request_id"] = p.RequestID
+	}
+	return json.Marshal(body)
+}
+
+// Abort прерывает обработку запроса и отвечает problem+json. Ошибка, не
+// являющаяся *Error, считается внутренней: клиент получает internal_error
+// без подробностей, а сама ошибка попадает в c.Errors для лога запроса.
+func Abort(c *gin.Context, err error) {
+	var e *Error
+	if !errors.As(err, &e) {
+		_ = c.Error(err)
+		e = ErrInternal
+	}
+
+	c.Header("Content-Type", ContentType)
+	c.AbortWithStatusJSON(e.Status, &Problem{
+		Type:       typePrefix + e.Code,
+		Title:      http.StatusText(e.Status),
+		Status:     e.Status,
+		Detail:     e.Detail,
+		Instance:   c.Request.URL.Path,
+		Code:       e.Code,
+		RequestID:  requestid.FromContext(c.Request.Context()),
+		Extensions: e.Extensions,
+	})
+}
+
+// NoRoute — обработчик для router.NoRoute.
+func NoRoute(c *gin.Context) {
+	Abort(c, ErrRouteNotFound)
+}
+
+// Recover — обработчик для gin.CustomRecovery: паника отдаётся как
+// internal_error, а не пустым ответом 500.
+func Recover(c *gin.Context, _ interface{}) {
+	Abort(c, ErrInternal)
+}
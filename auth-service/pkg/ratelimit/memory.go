@@ -0,0 +1,44 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// MemoryStore хранит лимиты в памяти процесса. Подходит для одного
+// экземпляра: у каждой реплики были бы свои счётчики.
+type MemoryStore struct {
+	mu  sync.Mutex
+	tat map[string]time.Time
+}
+
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{
+		tat: make(map[string]time.Time),
+	}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, policy Policy) (Result, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	res, tat := decide(s.tat[key], time.Now(), policy)
+	if res.Allowed {
+		s.tat[key] = tat
+	}
+	return res, nil
+}
+
+func (s *MemoryStore) Evict(_ context.Context) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	now := time.Now()
+	for key, tat := range s.tat {
+		if !tat.After(now) {
+			delete(s.tat, key)
+		}
+	}
+	return nil
+}
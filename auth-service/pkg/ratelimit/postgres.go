@@ -0,0 +1,82 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Migrate создаёт таблицу rate_limits для PostgresStore.
+func Migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS rate_limits (
+			key TEXT PRIMARY KEY,
+			tat TIMESTAMPTZ NOT NULL
+		);
+		CREATE INDEX IF NOT EXISTS idx_rate_limits_tat ON rate_limits (tat);
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create rate_limits table: %w", err)
+	}
+	return nil
+}
+
+// PostgresStore хранит лимиты в общей базе, так что все реплики сервиса
+// считают запросы вместе. Время берётся из базы, чтобы расхождение часов
+// между репликами не влияло на решения.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// Take решает и обновляет TAT одним запросом: строка меняется, только если
+// запрос укладывается в лимит, иначе RETURNING ничего не возвращает.
+func (s *PostgresStore) Take(ctx context.Context, key string, policy Policy) (Result, error) {
+	interval := policy.interval().Seconds()
+	period := policy.Period.Seconds()
+
+	var reset float64
+	err := s.db.QueryRowContext(ctx, `
+		INSERT INTO rate_limits AS r (key, tat)
+		VALUES ($1, now() + make_interval(secs => $2::double precision))
+		ON CONFLICT (key) DO UPDATE
+			SET tat = GREATEST(r.tat, now()) + make_interval(secs => $2::double precision)
+			WHERE GREATEST(r.tat, now()) + make_interval(secs => $2::double precision)
+				<= now() + make_interval(secs => $3::double precision)
+		RETURNING EXTRACT(EPOCH FROM tat - now())
+	`, key, interval, period).Scan(&reset)
+	if err == nil {
+		return result(toDuration(reset), policy), nil
+	}
+	if !errors.Is(err, sql.ErrNoRows) {
+		return Result{}, fmt.Errorf("failed to take rate limit: %w", err)
+	}
+
+	err = s.db.QueryRowContext(ctx,
+		`SELECT EXTRACT(EPOCH FROM tat - now()) FROM rate_limits WHERE key = $1`, key,
+	).Scan(&reset)
+	if err != nil {
+		return Result{}, fmt.Errorf("failed to read rate limit: %w", err)
+	}
+	return Result{
+		Limit:      policy.Limit,
+		Reset:      toDuration(reset),
+		RetryAfter: toDuration(reset) + policy.interval() - policy.Period,
+	}, nil
+}
+
+func (s *PostgresStore) Evict(ctx context.Context) error {
+	if _, err := s.db.ExecContext(ctx, `DELETE FROM rate_limits WHERE tat <= now()`); err != nil {
+		return fmt.Errorf("failed to evict rate limits: %w", err)
+	}
+	return nil
+}
+
+func toDuration(seconds float64) time.Duration {
+	return time.Duration(seconds * float64(time.Second))
+}
@@ -0,0 +1,176 @@
+// Package ratelimit ограничивает частоту запросов к отдельным маршрутам:
+// токен-бакет на ключ (пользователь или IP) с заголовками X-RateLimit-*.
+// Состояние хранится в памяти (один экземпляр) или в PostgreSQL (общие
+// лимиты для всех реплик). Используется и auth-service, и forum-service.
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+// Policy — лимит для одного маршрута: не больше Limit запросов за Period,
+// причём весь Limit можно израсходовать сразу. Нулевой Limit отключает
+// ограничение.
+type Policy struct {
+	Name   string
+	Limit  int
+	Period time.Duration
+}
+
+// ParsePolicy разбирает лимит вида "10/1m" (10 запросов в минуту);
+// "off" отключает ограничение.
+func ParsePolicy(name, spec string) (Policy, error) {
+	if spec == "off" {
+		return Policy{Name: name}, nil
+	}
+
+	count, period, ok := strings.Cut(spec, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("rate limit %q: expected LIMIT/PERIOD", spec)
+	}
+	limit, err := strconv.Atoi(count)
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: limit must be a positive integer", spec)
+	}
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return Policy{}, fmt.Errorf("rate limit %q: invalid period", spec)
+	}
+	return Policy{Name: name, Limit: limit, Period: d}, nil
+}
+
+func (p Policy) enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// interval — время, за которое восстанавливается один запрос.
+func (p Policy) interval() time.Duration {
+	return p.Period / time.Duration(p.Limit)
+}
+
+// Result — решение по одному запросу.
+type Result struct {
+	Allowed   bool
+	Limit     int
+	Remaining int
+	// Reset — через сколько лимит восстановится полностью.
+	Reset time.Duration
+	// RetryAfter — через сколько можно повторить отклонённый запрос.
+	RetryAfter time.Duration
+}
+
+// Store хранит состояние лимитов. Алгоритм — GCRA: на ключ хранится одно
+// время (TAT, theoretical arrival time), к которому лимит восстановится
+// полностью, так что обе реализации дают одинаковые решения.
+type Store interface {
+	// Take учитывает один запрос по ключу key.
+	Take(ctx context.Context, key string, policy Policy) (Result, error)
+	// Evict удаляет ключи, лимит которых уже восстановился: хранить их
+	// незачем, отсутствующий ключ означает полный лимит.
+	Evict(ctx context.Context) error
+}
+
+// decide применяет GCRA к сохранённому TAT и возвращает решение и новый TAT.
+func decide(tat, now time.Time, policy Policy) (Result, time.Time) {
+	interval := policy.interval()
+	if tat.Before(now) {
+		tat = now
+	}
+	next := tat.Add(interval)
+
+	if next.Sub(now) > policy.Period {
+		return Result{
+			Limit:      policy.Limit,
+			Reset:      tat.Sub(now),
+			RetryAfter: next.Sub(now) - policy.Period,
+		}, tat
+	}
+	return result(next.Sub(now), policy), next
+}
+
+// result собирает Result разрешённого запроса по времени до полного
+// восстановления лимита.
+func result(reset time.Duration, policy Policy) Result {
+	return Result{
+		Allowed:   true,
+		Limit:     policy.Limit,
+		Remaining: int((policy.Period - reset) / policy.interval()),
+		Reset:     reset,
+	}
+}
+
+// RunEviction вызывает store.Evict раз в interval, пока не отменён ctx.
+func RunEviction(ctx context.Context, store Store, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := store.Evict(ctx); err != nil && ctx.Err() == nil {
+				log.Warn().Err(err).Str("component", "ratelimit").Msg("Failed to evict rate limit keys")
+			}
+		}
+	}
+}
+
+// Middleware ограничивает маршрут политикой policy. Ключ — userID из
+// контекста gin, если перед ним стоит middleware авторизации, иначе IP
+// клиента. Если хранилище недоступно, запрос пропускается.
+func Middleware(store Store, policy Policy) gin.HandlerFunc {
+	if !policy.enabled() {
+		return func(c *gin.Context) { c.Next() }
+	}
+
+	return func(c *gin.Context) {
+		logger := log.With().
+			Ctx(c.Request.Context()).
+			Str("middleware", "RateLimit").
+			Str("policy", policy.Name).
+			Logger()
+
+		res, err := store.Take(c.Request.Context(), key(c, policy), policy)
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to check rate limit, allowing request")
+			c.Next()
+			return
+		}
+
+		c.Header("X-RateLimit-Limit", strconv.Itoa(res.Limit))
+		c.Header("X-RateLimit-Remaining", strconv.Itoa(res.Remaining))
+		c.Header("X-RateLimit-Reset", seconds(res.Reset))
+		if !res.Allowed {
+			logger.Warn().
+				Int64("user_id", c.GetInt64("userID")).
+				Str("ip", c.ClientIP()).
+				Dur("retry_after", res.RetryAfter).
+				Msg("Rate limit exceeded")
+			c.Header("Retry-After", seconds(res.RetryAfter))
+			problem.Abort(c, problem.ErrRateLimited.With("retry_after", int64(math.Ceil(res.RetryAfter.Seconds()))))
+			return
+		}
+		c.Next()
+	}
+}
+
+func key(c *gin.Context, policy Policy) string {
+	if userID := c.GetInt64("userID"); userID != 0 {
+		return policy.Name + ":user:" + strconv.FormatInt(userID, 10)
+	}
+	return policy.Name + ":ip:" + c.ClientIP()
+}
+
+// seconds округляет d вверх до целых секунд для заголовков ответа.
+func seconds(d time.Duration) string {
+	return strconv.FormatInt(int64(math.Ceil(d.Seconds())), 10)
+}
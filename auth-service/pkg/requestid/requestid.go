@@ -0,0 +1,111 @@
+// Package requestid присваивает каждому HTTP-запросу идентификатор
+// X-Request-ID: принимает его от клиента или балансировщика либо создаёт
+// новый, возвращает в ответе, передаёт в исходящие запросы к другим
+// сервисам и добавляет в строки zerolog. Используется и auth-service,
+// и forum-service.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+)
+
+// Header — заголовок с идентификатором запроса.
+const Header = "X-Request-ID"
+
+// maxLength ограничивает длину принятого от клиента идентификатора, чтобы
+// он не раздувал логи.
+const maxLength = 128
+
+type contextKey struct{}
+
+// NewContext сохраняет идентификатор запроса в контексте.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext возвращает идентификатор запроса или пустую строку.
+func FromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	id, _ := ctx.Value(contextKey{}).(string)
+	return id
+}
+
+// New создаёт случайный идентификатор из 32 шестнадцатеричных символов.
+func New() string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return hex.EncodeToString(b)
+}
+
+// Middleware берёт X-Request-ID из запроса (если он есть и выглядит
+// корректно) или создаёт новый, кладёт его в контекст запроса и возвращает
+// клиенту тем же заголовком. Ставится первым, чтобы идентификатор был
+// во всех строках лога, включая логи остальных middleware.
+func Middleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		id := c.GetHeader(Header)
+		if !valid(id) {
+			id = New()
+		}
+
+		c.Request = c.Request.WithContext(NewContext(c.Request.Context(), id))
+		c.Header(Header, id)
+		c.Next()
+	}
+}
+
+// valid допускает только печатные символы, которые встречаются в UUID,
+// hex и идентификаторах распространённых балансировщиков.
+func valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for _, r := range id {
+		switch {
+		case r >= 'a' && r <= 'z', r >= 'A' && r <= 'Z', r >= '0' && r <= '9':
+		case r == '-', r == '_', r == '.', r == ':', r == '=', r == '+', r == '/':
+		default:
+			return false
+		}
+	}
+	return true
+}
+
+// Transport оборачивает base так, что исходящий запрос несёт X-Request-ID
+// из своего ctx и логи обоих сервисов можно связать по одному значению.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base}
+}
+
+type transport struct {
+	base http.RoundTripper
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	id := FromContext(req.Context())
+	if id == "" || req.Header.Get(Header) != "" {
+		return t.base.RoundTrip(req)
+	}
+
+	req = req.Clone(req.Context())
+	req.Header.Set(Header, id)
+	return t.base.RoundTrip(req)
+}
+
+// LogHook добавляет request_id в события zerolog, у которых есть контекст
+// запроса (Event.Ctx или Context.Ctx).
+type LogHook struct{}
+
+func (LogHook) Run(e *zerolog.Event, _ zerolog.Level, _ string) {
+	if id := FromContext(e.GetCtx()); id != "" {
+		e.Str("request_id", id)
+	}
+}
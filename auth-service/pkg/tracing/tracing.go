@@ -0,0 +1,174 @@
+// Package tracing настраивает OpenTelemetry для auth-service и forum-service:
+// экспорт span'ов, распространение W3C trace context между сервисами
+// и trace_id в строках zerolog.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"os"
+
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Экспортёры span'ов для Config.Exporter.
+const (
+	ExporterNone   = "none"
+	ExporterStdout = "stdout"
+	ExporterOTLP   = "otlp"
+)
+
+type Config struct {
+	ServiceName string
+	// Exporter — none (span'ы не собираются), stdout (JSON в stdout, для
+	// локального запуска) или otlp (OTLP/HTTP; адрес берётся из стандартных
+	// переменных OTEL_EXPORTER_OTLP_ENDPOINT и OTEL_EXPORTER_OTLP_TRACES_ENDPOINT).
+	Exporter string
+	// SampleRatio — доля новых трасс, которые записываются; входящие
+	// запросы следуют решению вызывающего сервиса.
+	SampleRatio float64
+}
+
+// Setup регистрирует глобальные TracerProvider и пропагатор W3C trace context.
+// Возвращённая функция дописывает накопленные span'ы и останавливает экспорт.
+func Setup(ctx context.Context, cfg Config) (func(context.Context) error, error) {
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{},
+		propagation.Baggage{},
+	))
+
+	var exporter sdktrace.SpanExporter
+	var err error
+	switch cfg.Exporter {
+	case ExporterNone, "":
+		return func(context.Context) error { return nil }, nil
+	case ExporterStdout:
+		exporter, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case ExporterOTLP:
+		exporter, err = otlptracehttp.New(ctx)
+	default:
+		return nil, fmt.Errorf("unknown tracing exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to create %s exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(), resource.NewWithAttributes(
+		semconv.SchemaURL,
+		semconv.ServiceName(cfg.ServiceName),
+	))
+	if err != nil {
+		return nil, fmt.Errorf("failed to build resource: %w", err)
+	}
+
+	provider := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exporter),
+		sdktrace.WithResource(res),
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(provider)
+	return provider.Shutdown, nil
+}
+
+// Middleware продолжает трассу из заголовка traceparent (или начинает новую)
+// и открывает серверный span на запрос с шаблоном маршрута gin в имени.
+func Middleware(serviceName string) gin.HandlerFunc {
+	tracer := otel.Tracer(serviceName)
+	propagator := otel.GetTextMapPropagator()
+
+	return func(c *gin.Context) {
+		ctx := propagator.Extract(c.Request.Context(), propagation.HeaderCarrier(c.Request.Header))
+
+		route := c.FullPath()
+		name := c.Request.Method
+		if route != "" {
+			name += " " + route
+		}
+		ctx, span := tracer.Start(ctx, name,
+			trace.WithSpanKind(trace.SpanKindServer),
+			trace.WithAttributes(
+				semconv.HTTPRequestMethodKey.String(c.Request.Method),
+				semconv.HTTPRoute(route),
+			),
+		)
+		defer span.End()
+
+		c.Request = c.Request.WithContext(ctx)
+		c.Next()
+
+		status := c.Writer.Status()
+		span.SetAttributes(semconv.HTTPResponseStatusCode(status))
+		if status >= http.StatusInternalServerError {
+			span.SetStatus(codes.Error, http.StatusText(status))
+		}
+	}
+}
+
+// Transport оборачивает base так, что каждый исходящий запрос получает
+// клиентский span и заголовки трассы из своего ctx, а сервис-получатель
+// продолжает ту же трассу.
+func Transport(base http.RoundTripper) http.RoundTripper {
+	return &transport{base: base, tracer: otel.Tracer("github.com/Frozz164/forum-app_v2/auth-service/pkg/tracing")}
+}
+
+type transport struct {
+	base   http.RoundTripper
+	tracer trace.Tracer
+}
+
+func (t *transport) RoundTrip(req *http.Request) (*http.Response, error) {
+	ctx, span := t.tracer.Start(req.Context(), req.Method+" "+req.URL.Host,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			semconv.HTTPRequestMethodKey.String(req.Method),
+			semconv.ServerAddress(req.URL.Hostname()),
+		),
+	)
+	defer span.End()
+
+	req = req.Clone(ctx)
+	otel.GetTextMapPropagator().Inject(ctx, propagation.HeaderCarrier(req.Header))
+
+	resp, err := t.base.RoundTrip(req)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+		return nil, err
+	}
+	span.SetAttributes(semconv.HTTPResponseStatusCode(resp.StatusCode))
+	if resp.StatusCode >= http.StatusInternalServerError {
+		span.SetStatus(codes.Error, resp.Status)
+	}
+	return resp, nil
+}
+
+// LogHook добавляет trace_id и span_id в события zerolog, у которых есть
+// контекст со span'ом (Event.Ctx или Context.Ctx). Событие уровня error
+// помечает этот span как ошибочный — так ошибки репозиториев видны в трассе
+// без отдельной обработки в каждом методе.
+type LogHook struct{}
+
+func (LogHook) Run(e *zerolog.Event, level zerolog.Level, msg string) {
+	span := trace.SpanFromContext(e.GetCtx())
+	spanContext := span.SpanContext()
+	if !spanContext.IsValid() {
+		return
+	}
+
+	e.Str("trace_id", spanContext.TraceID().String()).
+		Str("span_id", spanContext.SpanID().String())
+	if level >= zerolog.ErrorLevel && level < zerolog.NoLevel {
+		span.SetStatus(codes.Error, msg)
+	}
+}
@@ -28,10 +28,10 @@ export default function Posts({ token, user }) {
         }
 
         try {
-            await forumAPI.createPost({ title, content });
+            const response = await forumAPI.createPost({ title, content });
             setTitle('');
             setContent('');
-            setError('');
+            setError(response.data?.held ? 'Your post is awaiting moderator approval' : '');
             await fetchPosts();
         } catch (err) {
             console.error('Error creating post:', err);
@@ -41,7 +41,7 @@ forumClient.interceptors.response.use(
 const handleError = (error) => {
     if (error.response) {
         console.error('API Error:', error.response.data);
-        throw new Error(error.response.data.error || 'Request failed');
+        throw new Error(error.response.data.detail || 'Request failed');
     } else {
         console.error('Network Error:', error.message);
         throw new Error('Network error. Please try again.');
@@ -1,11 +1,26 @@
 package main
 
 import (
+	"context"
+	"errors"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/blobstore"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/health"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/metrics"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/ratelimit"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/requestid"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/tracing"
 	"github.com/Frozz164/forum-app_v2/forum-service/config"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/contentfilter"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/handler"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/migrations"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/repository"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/authclient"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/markdown"
 	"github.com/Frozz164/forum-app_v2/forum-service/pkg/middleware"
 	"github.com/Frozz164/forum-app_v2/forum-service/pkg/websocket"
 	"github.com/gin-contrib/cors"
@@ -13,7 +28,10 @@ import (
 	_ "github.com/lib/pq"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"net/http"
 	"os"
+	"os/signal"
+	"syscall"
 	"time"
 )
 
@@ -22,7 +40,7 @@ func initLogger() {
 		Out:        os.Stdout,
 		TimeFormat: time.RFC3339,
 	}
-	log.Logger = log.Output(output).With().Caller().Logger()
+	log.Logger = log.Output(output).With().Caller().Logger().Hook(tracing.LogHook{}, requestid.LogHook{})
 	zerolog.SetGlobalLevel(zerolog.InfoLevel)
 }
 
@@ -50,54 +68,260 @@ func main() {
 		log.Fatal().Err(err).Msg("Failed to migrate database")
 	}
 
+	shutdownTracing, err := tracing.Setup(context.Background(), tracing.Config{
+		ServiceName: "forum-service",
+		Exporter:    cfg.Tracing.Exporter,
+		SampleRatio: cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to set up tracing")
+	}
+	defer func() {
+		if err := shutdownTracing(context.Background()); err != nil {
+			log.Error().Err(err).Msg("Failed to flush traces")
+		}
+	}()
+
+	registry := metrics.NewRegistry()
+	metrics.RegisterDB(registry, db, cfg.Database.Name)
+
 	// Initialize layers
 	log.Info().Msg("Initializing application layers")
 	postRepo := repository.NewPostRepository(db)
 	chatRepo := repository.NewChatRepository(db)
+	reportRepo := repository.NewReportRepository(db)
+	commentRepo := repository.NewCommentRepository(db)
+	attachmentRepo := repository.NewAttachmentRepository(db)
+	reactionRepo := repository.NewReactionRepository(db)
+	mentionRepo := repository.NewMentionRepository(db)
 
-	postService := service.NewPostService(postRepo)
-	chatService := service.NewChatService(chatRepo)
+	blobStore, err := blobstore.New(cfg.Uploads.Storage)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Failed to initialize blob store")
+	}
+
+	auditStore := audit.NewStore(db)
+	renderer := markdown.NewRenderer()
+	authClient := authclient.New(cfg.AuthServiceURL, cfg.JWT.SecretKey)
+	sanctionCache := authclient.NewSanctionCache(authClient, cfg.Moderation.SanctionCacheTTL)
+	roleCache := authclient.NewRoleCache(authClient, cfg.Moderation.RoleCacheTTL)
+	notificationService := notifications.NewService(notifications.NewStore(db))
+	mentionService := service.NewMentionService(mentionRepo, authClient, notificationService)
 
-	pool := websocket.NewPool(chatService)
+	contentScreen := service.NewContentScreen(
+		contentfilter.New(cfg.ContentFilter, repository.NewContentHistoryRepository(db)),
+		reportRepo,
+		auditStore,
+	)
+
+	postService := service.NewPostService(postRepo, attachmentRepo, reactionRepo, mentionService, notificationService, auditStore, contentScreen, renderer, cfg.AvatarBaseURL)
+	chatService := service.NewChatService(chatRepo, reactionRepo, mentionService, notificationService, auditStore, contentScreen, cfg.Chat.EditWindow)
+	commentService := service.NewCommentService(commentRepo, postRepo, mentionService, notificationService, contentScreen, renderer, cfg.AvatarBaseURL)
+	uploadService := service.NewUploadService(attachmentRepo, blobStore, cfg.Uploads.MaxBytes, cfg.Uploads.URLSecret, cfg.Uploads.URLTTL)
+	reportService := service.NewReportService(reportRepo, postRepo, chatRepo, commentRepo, auditStore, notificationService, cfg.Moderation.ReportHideThreshold)
+	reactionService := service.NewReactionService(reactionRepo, postRepo, chatRepo)
+
+	var broker websocket.Broker
+	switch cfg.Chat.Broker {
+	case "postgres":
+		pgBroker, err := websocket.NewPostgresBroker(db, cfg.Database.ConnString())
+		if err != nil {
+			log.Fatal().Err(err).Msg("Failed to start chat broker")
+		}
+		broker = pgBroker
+	case "memory":
+		broker = websocket.NewMemoryBroker()
+	default:
+		log.Fatal().Str("broker", cfg.Chat.Broker).Msg("Unknown CHAT_BROKER, expected memory or postgres")
+	}
+	defer func() {
+		if err := broker.Close(); err != nil {
+			log.Error().Err(err).Msg("Failed to close chat broker")
+		}
+	}()
+
+	var limitStore ratelimit.Store
+	switch cfg.RateLimit.Store {
+	case "postgres":
+		limitStore = ratelimit.NewPostgresStore(db)
+	case "memory":
+		limitStore = ratelimit.NewMemoryStore()
+	default:
+		log.Fatal().Str("store", cfg.RateLimit.Store).Msg("Unknown RATE_LIMIT_STORE, expected memory or postgres")
+	}
+
+	slowConsumerPolicy, err := websocket.ParseSlowConsumerPolicy(cfg.Chat.SlowConsumerPolicy)
+	if err != nil {
+		log.Fatal().Err(err).Msg("Invalid CHAT_SLOW_CONSUMER_POLICY")
+	}
+
+	pool := websocket.NewPool(chatService, reportService, reactionService, notificationService, broker, cfg.AvatarBaseURL)
+	pool.SlowConsumerPolicy = slowConsumerPolicy
+	pool.FloodControl = websocket.FloodControl{
+		MessageInterval: cfg.Chat.MessageInterval,
+		Burst:           cfg.Chat.MessageBurst,
+		DuplicateWindow: cfg.Chat.DuplicateWindow,
+		SlowMode:        cfg.Chat.SlowMode,
+	}
+	pool.SlowModeStore = repository.NewChatSettingsRepository(db)
+	registry.MustRegister(pool.Collector())
 	go pool.Start()
 
-	postHandler := handler.NewPostHandler(postService)
-	chatHandler := handler.NewChatHandler(chatService, pool, cfg.JWT.SecretKey)
+	checker := health.NewChecker()
+	checker.Add("database", health.PingDB(db))
+	checker.Add("migrations", func(ctx context.Context) error {
+		return migrations.CheckVersion(ctx, db)
+	})
+	checker.Add("auth_service", func(ctx context.Context) error {
+		if cfg.JWT.SecretKey == "" {
+			return errors.New("JWT_SECRET is not configured")
+		}
+		// Токены проверяются локально по JWT_SECRET, так что без auth-service
+		// перестают работать только санкции и упоминания.
+		if err := authClient.Ping(ctx); err != nil {
+			return health.Degraded(err)
+		}
+		return nil
+	})
+	checker.Add("chat_pool", pool.Check)
+
+	postHandler := handler.NewPostHandler(postService, uploadService)
+	uploadHandler := handler.NewUploadHandler(uploadService, cfg.Uploads.MaxBytes)
+	chatHandler := handler.NewChatHandler(chatService, pool, sanctionCache, roleCache, cfg.JWT.SecretKey)
+	reportHandler := handler.NewReportHandler(reportService)
+	commentHandler := handler.NewCommentHandler(commentService)
+	moderationHandler := handler.NewModerationHandler(pool, sanctionCache, notificationService, auditStore)
+	reactionHandler := handler.NewReactionHandler(reactionService, pool)
+	notificationHandler := notifications.NewHandler(notificationService)
 
 	// Gin setup
 	router := gin.Default()
+	// Без списка доверенных прокси gin верит X-Forwarded-For от любого
+	// клиента, и c.ClientIP() (ключ лимитов и аудита) можно подделать.
+	if err := router.SetTrustedProxies(cfg.TrustedProxies); err != nil {
+		log.Fatal().Err(err).Msg("Invalid TRUSTED_PROXIES")
+	}
+	router.Use(requestid.Middleware())
+	router.Use(tracing.Middleware("forum-service"))
 	router.Use(middleware.GinLogger())
+	router.Use(metrics.HTTP(registry))
+	router.Use(audit.Middleware())
 
 	// CORS configuration
 	router.Use(cors.New(cors.Config{
 		AllowOrigins:     []string{"http://localhost:3000"},
-		AllowMethods:     []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization"},
-		ExposeHeaders:    []string{"Content-Length"},
+		AllowMethods:     []string{"GET", "POST", "PUT", "PATCH", "DELETE", "OPTIONS"},
+		AllowHeaders:     []string{"Origin", "Content-Type", "Authorization", requestid.Header},
+		ExposeHeaders:    []string{"Content-Length", requestid.Header, "Retry-After", "X-RateLimit-Limit", "X-RateLimit-Remaining", "X-RateLimit-Reset"},
 		AllowCredentials: true,
 		AllowWebSockets:  true,
 		MaxAge:           12 * time.Hour,
 	}))
 
+	router.NoRoute(problem.NoRoute)
+
 	router.Static("/static", "../web")
 	router.StaticFile("/", "../web/index.html")
 
+	router.GET("/healthz", checker.Live)
+	router.GET("/readyz", checker.Ready)
+	router.GET("/metrics", metrics.Handler(registry))
+
 	// Public routes
-	router.GET("/api/posts", postHandler.GetAllPosts)
-	router.GET("/api/posts/:id", postHandler.GetPost)
-	router.GET("/ws", middleware.AuthWebSocketMiddleware(cfg.JWT.SecretKey), chatHandler.WebsocketHandler)
+	// Скрытые фильтром посты видны автору, поэтому чтение постов учитывает токен, если он есть.
+	optionalAuth := middleware.OptionalAuthMiddleware(cfg.JWT.SecretKey)
+	router.GET("/api/posts", optionalAuth, postHandler.GetAllPosts)
+	router.GET("/api/posts/:id", optionalAuth, postHandler.GetPost)
+	router.GET("/api/posts/:id/comments", optionalAuth, commentHandler.GetComments)
+	router.GET("/api/announcements", postHandler.GetAnnouncements)
+	router.GET("/api/uploads/:id", uploadHandler.Download)
+	router.GET("/api/chat/online", chatHandler.Online)
+	router.GET("/ws", middleware.AuthWebSocketMiddleware(cfg.JWT.SecretKey), ratelimit.Middleware(limitStore, cfg.RateLimit.ChatConnect), chatHandler.WebsocketHandler)
+	router.GET("/api/stream", middleware.AuthWebSocketMiddleware(cfg.JWT.SecretKey), ratelimit.Middleware(limitStore, cfg.RateLimit.ChatConnect), chatHandler.Stream)
 
 	// Protected routes
 	authGroup := router.Group("/api")
-	authGroup.Use(middleware.AuthMiddleware(cfg.JWT.SecretKey))
+	authGroup.Use(middleware.AuthMiddleware(cfg.JWT.SecretKey), middleware.LoadSanctions(sanctionCache))
 	{
-		authGroup.POST("/posts", postHandler.CreatePost)
+		authGroup.POST("/posts", ratelimit.Middleware(limitStore, cfg.RateLimit.CreatePost), middleware.RejectSanctioned(domain.SanctionPostSuspension), postHandler.CreatePost)
 		authGroup.DELETE("/posts/:id", postHandler.DeletePost)
+		authGroup.POST("/posts/:id/report", reportHandler.ReportPost)
+		authGroup.POST("/posts/:id/reactions", reactionHandler.TogglePostReaction)
+		authGroup.POST("/uploads", middleware.RejectSanctioned(domain.SanctionPostSuspension), uploadHandler.Upload)
+		authGroup.POST("/posts/:id/comments", middleware.RejectSanctioned(domain.SanctionPostSuspension), commentHandler.CreateComment)
+		authGroup.POST("/chat/messages", middleware.RejectSanctioned(domain.SanctionMute), chatHandler.PostMessage)
+		authGroup.GET("/notifications", notificationHandler.List)
+		authGroup.GET("/notifications/unread-count", notificationHandler.UnreadCount)
+		authGroup.POST("/notifications/:id/read", notificationHandler.MarkRead)
+		authGroup.POST("/notifications/read-all", notificationHandler.MarkAllRead)
+	}
+
+	// Moderator routes
+	modGroup := router.Group("/api/moderation")
+	modGroup.Use(middleware.AuthMiddleware(cfg.JWT.SecretKey), middleware.LoadSanctions(sanctionCache), middleware.LoadCurrentRole(roleCache), middleware.RequireRole(domain.RoleModerator, domain.RoleAdmin))
+	{
+		modGroup.GET("/reports", reportHandler.ListReports)
+		modGroup.PATCH("/reports/:id", reportHandler.ResolveReport)
+		modGroup.PATCH("/posts/:id", postHandler.UpdateFlags)
+		modGroup.POST("/sanctions/notify", moderationHandler.SanctionNotify)
+		modGroup.GET("/chat/slow-mode", moderationHandler.GetSlowMode)
+		modGroup.PUT("/chat/slow-mode", moderationHandler.SetSlowMode)
+	}
+
+	// Admin routes
+	adminGroup := router.Group("/api/admin")
+	adminGroup.Use(middleware.AuthMiddleware(cfg.JWT.SecretKey), middleware.LoadSanctions(sanctionCache), middleware.LoadCurrentRole(roleCache), middleware.RequireRole(domain.RoleAdmin))
+	{
+		adminGroup.GET("/audit", audit.QueryHandler(auditStore))
 	}
 
 	// Start server
-	log.Info().Str("port", cfg.Port).Msg("Starting HTTP server")
-	if err := router.Run(":" + cfg.Port); err != nil {
+	srv := &http.Server{
+		Addr:    ":" + cfg.Port,
+		Handler: router,
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	go ratelimit.RunEviction(ctx, limitStore, time.Minute)
+
+	serverErr := make(chan error, 1)
+	go func() {
+		log.Info().Str("port", cfg.Port).Msg("Starting HTTP server")
+		if err := srv.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			serverErr <- err
+		}
+	}()
+
+	select {
+	case err := <-serverErr:
 		log.Fatal().Err(err).Msg("Failed to start server")
+	case <-ctx.Done():
+	}
+	stop()
+
+	checker.ShutDown()
+	log.Info().Dur("delay", cfg.ShutdownDelay).Msg("Readiness set to failing, waiting for load balancer")
+	time.Sleep(cfg.ShutdownDelay)
+
+	// Остановка: HTTP-сервер перестаёт принимать соединения и дожидается
+	// текущих запросов, а пул тем временем закрывает соединения чата
+	// (среди них и SSE-запросы, которых ждёт сервер). Брокер и база
+	// закрываются отложенными вызовами выше — база последней.
+	log.Info().Dur("timeout", cfg.ShutdownTimeout).Msg("Shutting down forum service")
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), cfg.ShutdownTimeout)
+	defer cancel()
+
+	poolDone := make(chan error, 1)
+	go func() {
+		poolDone <- pool.Shutdown(shutdownCtx)
+	}()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		log.Error().Err(err).Msg("Failed to drain in-flight requests")
+	}
+	if err := <-poolDone; err != nil {
+		log.Error().Err(err).Msg("Failed to shut down WebSocket pool")
 	}
+	log.Info().Msg("Forum service stopped")
 }
@@ -0,0 +1,304 @@
+// Command poolbench — нагрузочный тест рассылки websocket.Pool без сети и базы.
+//
+// Клиенты подключаются как SSE-клиенты пула и пишут в память; медленные
+// клиенты задерживают запись каждого сообщения, как забитый сокет. Набор сценариев по умолчанию: 10 000
+// быстрых клиентов, затем 1% медленных при каждой политике медленного
+// потребителя. Для отслеживания регрессий в CI — бенчмарки BenchmarkFanout*
+// в pkg/websocket; poolbench дополняет их задержками доставки и большими
+// прогонами.
+//
+//	go run ./cmd/poolbench
+//	go run ./cmd/poolbench -scenario custom -clients 20000 -messages 500 -slow 0.05 -policy coalesce
+package main
+
+import (
+	"bytes"
+	"context"
+	"flag"
+	"fmt"
+	"net/http"
+	"os"
+	"runtime"
+	"slices"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"text/tabwriter"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/websocket"
+	"github.com/rs/zerolog"
+)
+
+type scenario struct {
+	name      string
+	clients   int
+	messages  int
+	slow      float64
+	slowDelay time.Duration
+	policy    websocket.SlowConsumerPolicy
+}
+
+type result struct {
+	scenario
+	duration   time.Duration
+	delivered  int64
+	expected   int64
+	complete   bool
+	p50, p99   time.Duration
+	stats      websocket.PoolStats
+	allocBytes uint64
+	mallocs    uint64
+}
+
+func main() {
+	name := flag.String("scenario", "suite", "suite — стандартный набор, custom — параметры из флагов")
+	clients := flag.Int("clients", 10000, "число клиентов")
+	messages := flag.Int("messages", 1000, "число рассылаемых сообщений")
+	slow := flag.Float64("slow", 0, "доля медленных клиентов (0..1)")
+	slowDelay := flag.Duration("slow-delay", 50*time.Millisecond, "задержка записи сообщения у медленного клиента")
+	rate := flag.Int("rate", 0, "сообщений в секунду, 0 — без ограничения")
+	policyName := flag.String("policy", "disconnect", "политика медленного потребителя: disconnect, drop_oldest, coalesce")
+	probes := flag.Int("probes", 100, "сколько быстрых клиентов замеряют задержку доставки")
+	timeout := flag.Duration("timeout", time.Minute, "сколько ждать доставки после последней рассылки")
+	flag.Parse()
+
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+
+	var scenarios []scenario
+	switch *name {
+	case "suite":
+		scenarios = []scenario{
+			{name: "fast", clients: 10000, messages: 1000},
+			{name: "slow/disconnect", clients: 10000, messages: 1000, slow: 0.01, slowDelay: 50 * time.Millisecond, policy: websocket.PolicyDisconnect},
+			{name: "slow/drop_oldest", clients: 10000, messages: 1000, slow: 0.01, slowDelay: 50 * time.Millisecond, policy: websocket.PolicyDropOldest},
+			{name: "slow/coalesce", clients: 10000, messages: 1000, slow: 0.01, slowDelay: 50 * time.Millisecond, policy: websocket.PolicyCoalesce},
+		}
+	case "custom":
+		policy, err := websocket.ParseSlowConsumerPolicy(*policyName)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+		scenarios = []scenario{{
+			name: "custom", clients: *clients, messages: *messages,
+			slow: *slow, slowDelay: *slowDelay, policy: policy,
+		}}
+	default:
+		fmt.Fprintf(os.Stderr, "unknown scenario %q\n", *name)
+		os.Exit(2)
+	}
+
+	results := make([]result, 0, len(scenarios))
+	for _, sc := range scenarios {
+		fmt.Fprintf(os.Stderr, "running %s: %d clients, %d messages\n", sc.name, sc.clients, sc.messages)
+		results = append(results, run(sc, *rate, *probes, *timeout))
+	}
+	report(results)
+}
+
+// run подключает клиентов к новому пулу, рассылает сообщения и ждёт, пока
+// все быстрые клиенты их получат.
+func run(sc scenario, rate, probes int, timeout time.Duration) result {
+	pool := websocket.NewPool(historyless{}, nil, nil, nil, nil, "")
+	pool.SlowConsumerPolicy = sc.policy
+	go pool.Start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slowCount := int(float64(sc.clients) * sc.slow)
+	writers := make([]*sinkWriter, sc.clients)
+	var fastDelivered atomic.Int64
+	for i := range writers {
+		w := &sinkWriter{header: make(http.Header), fastDelivered: &fastDelivered}
+		switch {
+		case i < slowCount:
+			w.delay = sc.slowDelay
+		case i < slowCount+probes:
+			w.probe = true
+		}
+		writers[i] = w
+
+		client := websocket.NewStreamClient(pool, "bench_"+strconv.Itoa(i), 0, "", true, 0)
+		pool.Register <- client
+		go client.Stream(ctx, w)
+	}
+	for pool.ClientCount() < sc.clients {
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	var before runtime.MemStats
+	runtime.GC()
+	runtime.ReadMemStats(&before)
+
+	var tick <-chan time.Time
+	if rate > 0 {
+		ticker := time.NewTicker(time.Second / time.Duration(rate))
+		defer ticker.Stop()
+		tick = ticker.C
+	}
+
+	start := time.Now()
+	for i := 1; i <= sc.messages; i++ {
+		if tick != nil {
+			<-tick
+		}
+		pool.Broadcast <- websocket.Message{
+			ID:        int64(i),
+			Type:      websocket.MsgTypeChat,
+			Content:   strconv.FormatInt(time.Now().UnixNano(), 10),
+			Sender:    "bench",
+			Timestamp: time.Now().Unix(),
+		}
+	}
+
+	expected := int64(sc.clients-slowCount) * int64(sc.messages)
+	deadline := time.Now().Add(timeout)
+	for fastDelivered.Load() < expected && time.Now().Before(deadline) {
+		time.Sleep(time.Millisecond)
+	}
+	duration := time.Since(start)
+
+	var after runtime.MemStats
+	runtime.ReadMemStats(&after)
+
+	var latencies []time.Duration
+	var delivered int64
+	for _, w := range writers {
+		w.mu.Lock()
+		latencies = append(latencies, w.latencies...)
+		delivered += w.delivered
+		w.mu.Unlock()
+	}
+	slices.Sort(latencies)
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 2*websocket.WriteTimeout)
+	defer cancelShutdown()
+	if err := pool.Shutdown(shutdownCtx); err != nil {
+		fmt.Fprintf(os.Stderr, "%s: pool shutdown: %v\n", sc.name, err)
+	}
+
+	return result{
+		scenario:   sc,
+		duration:   duration,
+		delivered:  delivered,
+		expected:   expected,
+		complete:   fastDelivered.Load() >= expected,
+		p50:        percentile(latencies, 0.50),
+		p99:        percentile(latencies, 0.99),
+		stats:      pool.Stats(),
+		allocBytes: after.TotalAlloc - before.TotalAlloc,
+		mallocs:    after.Mallocs - before.Mallocs,
+	}
+}
+
+func report(results []result) {
+	tw := tabwriter.NewWriter(os.Stdout, 0, 0, 2, ' ', tabwriter.AlignRight)
+	fmt.Fprintln(tw, "scenario\tclients\tmsgs\tduration\tmsgs/s\tdeliveries/s\tp50\tp99\tdropped\tcoalesced\tslow_disc\talloc/msg\tcomplete\t")
+	for _, r := range results {
+		seconds := r.duration.Seconds()
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%.0f\t%.0f\t%s\t%s\t%d\t%d\t%d\t%s\t%t\t\n",
+			r.name, r.clients, r.messages,
+			r.duration.Round(time.Millisecond),
+			float64(r.messages)/seconds,
+			float64(r.delivered)/seconds,
+			r.p50.Round(time.Microsecond), r.p99.Round(time.Microsecond),
+			r.stats.Dropped, r.stats.Coalesced, r.stats.SlowDisconnects,
+			formatBytes(r.allocBytes/uint64(r.messages)),
+			r.complete,
+		)
+	}
+	tw.Flush()
+}
+
+func percentile(sorted []time.Duration, p float64) time.Duration {
+	if len(sorted) == 0 {
+		return 0
+	}
+	return sorted[int(float64(len(sorted)-1)*p)]
+}
+
+func formatBytes(n uint64) string {
+	switch {
+	case n >= 1<<20:
+		return fmt.Sprintf("%.1fMiB", float64(n)/(1<<20))
+	case n >= 1<<10:
+		return fmt.Sprintf("%.1fKiB", float64(n)/(1<<10))
+	default:
+		return fmt.Sprintf("%dB", n)
+	}
+}
+
+// sinkWriter — http.ResponseWriter SSE-клиента, который считает полученные
+// сообщения чата; probe-клиенты ещё и замеряют задержку по времени отправки
+// в content.
+type sinkWriter struct {
+	header        http.Header
+	delay         time.Duration
+	probe         bool
+	fastDelivered *atomic.Int64
+
+	mu        sync.Mutex
+	delivered int64
+	latencies []time.Duration
+}
+
+var (
+	chatEventPrefix = []byte("event: chat\n")
+	contentField    = []byte(`"content":"`)
+)
+
+func (w *sinkWriter) Header() http.Header { return w.header }
+
+func (w *sinkWriter) WriteHeader(int) {}
+
+func (w *sinkWriter) Write(p []byte) (int, error) {
+	if !bytes.HasPrefix(p, chatEventPrefix) {
+		return len(p), nil
+	}
+
+	var latency time.Duration
+	if w.probe {
+		if i := bytes.Index(p, contentField); i >= 0 {
+			rest := p[i+len(contentField):]
+			if end := bytes.IndexByte(rest, '"'); end > 0 {
+				if sent, err := strconv.ParseInt(string(rest[:end]), 10, 64); err == nil {
+					latency = time.Since(time.Unix(0, sent))
+				}
+			}
+		}
+	}
+
+	if w.delay > 0 {
+		time.Sleep(w.delay)
+	}
+
+	w.mu.Lock()
+	w.delivered++
+	if latency > 0 {
+		w.latencies = append(w.latencies, latency)
+	}
+	w.mu.Unlock()
+	if w.delay == 0 {
+		w.fastDelivered.Add(1)
+	}
+	return len(p), nil
+}
+
+func (w *sinkWriter) Flush() {}
+
+// historyless — ChatService без истории: клиенты пула подключаются сразу.
+type historyless struct {
+	service.ChatService
+}
+
+func (historyless) GetRecentMessages(context.Context, int, int64) ([]*domain.Message, error) {
+	return nil, nil
+}
+
+func (historyless) GetMessagesAfter(context.Context, int64, int, int64) ([]*domain.Message, error) {
+	return nil, nil
+}
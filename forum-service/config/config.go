@@ -5,14 +5,39 @@ import (
 	"fmt"
 	"log"
 	"os"
+	"strconv"
+	"strings"
+	"time"
 
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/blobstore"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/ratelimit"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/contentfilter"
 	"github.com/joho/godotenv"
 )
 
 type Config struct {
-	Port     string
-	Database DatabaseConfig
-	JWT      JWTConfig
+	Port           string
+	Database       DatabaseConfig
+	JWT            JWTConfig
+	Moderation     ModerationConfig
+	Chat           ChatConfig
+	Uploads        UploadConfig
+	Tracing        TracingConfig
+	RateLimit      RateLimitConfig
+	ContentFilter  contentfilter.Config
+	AuthServiceURL string
+	// AvatarBaseURL — адрес auth-service для браузера, из которого строятся avatar_url.
+	AvatarBaseURL string
+	// ShutdownTimeout — сколько при остановке ждать текущие запросы и закрытие
+	// соединений чата.
+	ShutdownTimeout time.Duration
+	// ShutdownDelay — сколько после сигнала остановки /readyz отвечает 503, а
+	// сервер ещё принимает запросы: за это время балансировщик уводит трафик.
+	ShutdownDelay time.Duration
+	// TrustedProxies — адреса или подсети прокси, которым можно верить
+	// в X-Forwarded-For. Пустой список: клиентом считается адрес соединения,
+	// и подделанный заголовок не обходит лимиты по IP.
+	TrustedProxies []string
 }
 
 type DatabaseConfig struct {
@@ -23,16 +48,158 @@ type DatabaseConfig struct {
 	Name     string
 }
 
+type TracingConfig struct {
+	// Exporter — none, stdout (для локального запуска) или otlp; адрес
+	// коллектора задаётся стандартной OTEL_EXPORTER_OTLP_ENDPOINT.
+	Exporter    string
+	SampleRatio float64
+}
+
+type RateLimitConfig struct {
+	// Store — memory (у каждого экземпляра свои счётчики) или postgres
+	// (общие лимиты для всех реплик).
+	Store string
+	// CreatePost ограничивает создание постов, ChatConnect — подключения
+	// к чату по WebSocket и SSE.
+	CreatePost  ratelimit.Policy
+	ChatConnect ratelimit.Policy
+}
+
 type JWTConfig struct {
 	SecretKey string
 }
 
+type ModerationConfig struct {
+	// ReportHideThreshold — число открытых жалоб, после которого контент скрывается.
+	ReportHideThreshold int
+	// SanctionCacheTTL — сколько санкции пользователя берутся из кэша,
+	// прежде чем снова спросить auth-service.
+	SanctionCacheTTL time.Duration
+	// RoleCacheTTL — сколько текущая роль пользователя берётся из кэша на
+	// маршрутах модераторов и администраторов: настолько позже вступает
+	// в силу смена роли.
+	RoleCacheTTL time.Duration
+}
+
+type ChatConfig struct {
+	// EditWindow — сколько времени после отправки автор может править сообщение.
+	EditWindow time.Duration
+	// Broker — шина между экземплярами: memory (один экземпляр) или postgres
+	// (LISTEN/NOTIFY, для нескольких реплик).
+	Broker string
+	// SlowConsumerPolicy — что делать с клиентом, который не успевает читать:
+	// disconnect, drop_oldest или coalesce.
+	SlowConsumerPolicy string
+	// MessageInterval и MessageBurst ограничивают частоту сообщений одного
+	// подключения: в среднем одно за интервал, MessageBurst подряд.
+	MessageInterval time.Duration
+	MessageBurst    int
+	// DuplicateWindow — сколько времени отклоняется повтор своего же сообщения.
+	DuplicateWindow time.Duration
+	// SlowMode — интервал медленного режима, пока модераторы его не задали;
+	// заданный ими хранится в базе.
+	SlowMode time.Duration
+}
+
+type UploadConfig struct {
+	MaxBytes int64
+	// URLSecret подписывает ссылки на скачивание; по умолчанию совпадает с JWT_SECRET.
+	URLSecret string
+	URLTTL    time.Duration
+	Storage   blobstore.Config
+}
+
 func Load() *Config {
 	err := godotenv.Load()
 	if err != nil {
 		log.Printf("Warning: Could not load .env file: %v", err)
 	}
 
+	hideThreshold, _ := strconv.Atoi(getEnv("REPORT_HIDE_THRESHOLD", "5"))
+	sanctionCacheTTL, err := time.ParseDuration(getEnv("SANCTION_CACHE_TTL", "30s"))
+	if err != nil || sanctionCacheTTL <= 0 {
+		log.Printf("Warning: invalid SANCTION_CACHE_TTL, using 30s: %v", err)
+		sanctionCacheTTL = 30 * time.Second
+	}
+	roleCacheTTL, err := time.ParseDuration(getEnv("ROLE_CACHE_TTL", "30s"))
+	if err != nil || roleCacheTTL <= 0 {
+		log.Printf("Warning: invalid ROLE_CACHE_TTL, using 30s: %v", err)
+		roleCacheTTL = 30 * time.Second
+	}
+	editWindow, err := time.ParseDuration(getEnv("CHAT_EDIT_WINDOW", "15m"))
+	if err != nil {
+		log.Printf("Warning: invalid CHAT_EDIT_WINDOW, using 15m: %v", err)
+		editWindow = 15 * time.Minute
+	}
+	messageInterval, err := time.ParseDuration(getEnv("CHAT_MESSAGE_INTERVAL", "1s"))
+	if err != nil {
+		log.Printf("Warning: invalid CHAT_MESSAGE_INTERVAL, using 1s: %v", err)
+		messageInterval = time.Second
+	}
+	messageBurst, err := strconv.Atoi(getEnv("CHAT_MESSAGE_BURST", "5"))
+	if err != nil {
+		log.Printf("Warning: invalid CHAT_MESSAGE_BURST, using 5: %v", err)
+		messageBurst = 5
+	}
+	duplicateWindow, err := time.ParseDuration(getEnv("CHAT_DUPLICATE_WINDOW", "30s"))
+	if err != nil {
+		log.Printf("Warning: invalid CHAT_DUPLICATE_WINDOW, using 30s: %v", err)
+		duplicateWindow = 30 * time.Second
+	}
+	slowMode, err := time.ParseDuration(getEnv("CHAT_SLOW_MODE", "0s"))
+	if err != nil {
+		log.Printf("Warning: invalid CHAT_SLOW_MODE, using 0s: %v", err)
+		slowMode = 0
+	}
+	maxLinks, err := strconv.Atoi(getEnv("FILTER_MAX_LINKS", "5"))
+	if err != nil {
+		log.Printf("Warning: invalid FILTER_MAX_LINKS, using 5: %v", err)
+		maxLinks = 5
+	}
+	newAccountAge, err := time.ParseDuration(getEnv("FILTER_NEW_ACCOUNT_AGE", "24h"))
+	if err != nil {
+		log.Printf("Warning: invalid FILTER_NEW_ACCOUNT_AGE, using 24h: %v", err)
+		newAccountAge = 24 * time.Hour
+	}
+	// Одна-две ссылки — обычное дело и в первом посте новичка
+	// (скриншот, документация); спамеры обычно вставляют больше.
+	newAccountMaxLinks, err := strconv.Atoi(getEnv("FILTER_NEW_ACCOUNT_MAX_LINKS", "2"))
+	if err != nil {
+		log.Printf("Warning: invalid FILTER_NEW_ACCOUNT_MAX_LINKS, using 2: %v", err)
+		newAccountMaxLinks = 2
+	}
+	repeatWindow, err := time.ParseDuration(getEnv("FILTER_REPEAT_WINDOW", "1h"))
+	if err != nil {
+		log.Printf("Warning: invalid FILTER_REPEAT_WINDOW, using 1h: %v", err)
+		repeatWindow = time.Hour
+	}
+	repeatCount, err := strconv.Atoi(getEnv("FILTER_REPEAT_COUNT", "3"))
+	if err != nil {
+		log.Printf("Warning: invalid FILTER_REPEAT_COUNT, using 3: %v", err)
+		repeatCount = 3
+	}
+	uploadMaxBytes, _ := strconv.ParseInt(getEnv("UPLOAD_MAX_BYTES", "10485760"), 10, 64)
+	uploadURLTTL, err := time.ParseDuration(getEnv("UPLOAD_URL_TTL", "15m"))
+	if err != nil {
+		log.Printf("Warning: invalid UPLOAD_URL_TTL, using 15m: %v", err)
+		uploadURLTTL = 15 * time.Minute
+	}
+	sampleRatio, err := strconv.ParseFloat(getEnv("TRACING_SAMPLE_RATIO", "1"), 64)
+	if err != nil {
+		log.Printf("Warning: invalid TRACING_SAMPLE_RATIO, using 1: %v", err)
+		sampleRatio = 1
+	}
+	shutdownTimeout, err := time.ParseDuration(getEnv("SHUTDOWN_TIMEOUT", "15s"))
+	if err != nil {
+		log.Printf("Warning: invalid SHUTDOWN_TIMEOUT, using 15s: %v", err)
+		shutdownTimeout = 15 * time.Second
+	}
+	shutdownDelay, err := time.ParseDuration(getEnv("SHUTDOWN_DELAY", "5s"))
+	if err != nil {
+		log.Printf("Warning: invalid SHUTDOWN_DELAY, using 5s: %v", err)
+		shutdownDelay = 5 * time.Second
+	}
+
 	return &Config{
 		Port: getEnv("PORT", "8081"),
 		Database: DatabaseConfig{
@@ -45,14 +212,72 @@ func Load() *Config {
 		JWT: JWTConfig{
 			SecretKey: getEnv("JWT_SECRET", ""),
 		},
+		Moderation: ModerationConfig{
+			ReportHideThreshold: hideThreshold,
+			SanctionCacheTTL:    sanctionCacheTTL,
+			RoleCacheTTL:        roleCacheTTL,
+		},
+		Chat: ChatConfig{
+			EditWindow:         editWindow,
+			Broker:             getEnv("CHAT_BROKER", "memory"),
+			SlowConsumerPolicy: getEnv("CHAT_SLOW_CONSUMER_POLICY", "disconnect"),
+			MessageInterval:    messageInterval,
+			MessageBurst:       messageBurst,
+			DuplicateWindow:    duplicateWindow,
+			SlowMode:           slowMode,
+		},
+		Uploads: UploadConfig{
+			MaxBytes:  uploadMaxBytes,
+			URLSecret: getEnv("UPLOAD_URL_SECRET", getEnv("JWT_SECRET", "")),
+			URLTTL:    uploadURLTTL,
+			Storage: blobstore.Config{
+				Backend:     getEnv("BLOB_BACKEND", "local"),
+				LocalDir:    getEnv("UPLOAD_DIR", "./uploads"),
+				S3Endpoint:  getEnv("S3_ENDPOINT", ""),
+				S3Region:    getEnv("S3_REGION", "us-east-1"),
+				S3Bucket:    getEnv("S3_BUCKET", ""),
+				S3AccessKey: getEnv("S3_ACCESS_KEY", ""),
+				S3SecretKey: getEnv("S3_SECRET_KEY", ""),
+			},
+		},
+		AuthServiceURL: getEnv("AUTH_SERVICE_URL", "http://localhost:8080"),
+		AvatarBaseURL:  getEnv("AVATAR_BASE_URL", getEnv("AUTH_SERVICE_URL", "http://localhost:8080")),
+		TrustedProxies: getList("TRUSTED_PROXIES"),
+		Tracing: TracingConfig{
+			Exporter:    getEnv("TRACING_EXPORTER", "none"),
+			SampleRatio: sampleRatio,
+		},
+		RateLimit: RateLimitConfig{
+			Store:       getEnv("RATE_LIMIT_STORE", "memory"),
+			CreatePost:  loadPolicy("create_post", "RATE_LIMIT_CREATE_POST", "10/1m"),
+			ChatConnect: loadPolicy("chat_connect", "RATE_LIMIT_CHAT_CONNECT", "30/1m"),
+		},
+		ContentFilter: contentfilter.Config{
+			RejectWords:        getList("FILTER_REJECT_WORDS"),
+			HoldWords:          getList("FILTER_HOLD_WORDS"),
+			ShadowWords:        getList("FILTER_SHADOW_WORDS"),
+			MaxLinks:           maxLinks,
+			LinksAction:        loadAction("FILTER_LINKS_ACTION", "hold"),
+			NewAccountAge:      newAccountAge,
+			NewAccountMaxLinks: newAccountMaxLinks,
+			NewAccountAction:   loadAction("FILTER_NEW_ACCOUNT_ACTION", "hold"),
+			RepeatWindow:       repeatWindow,
+			RepeatCount:        repeatCount,
+			RepeatAction:       loadAction("FILTER_REPEAT_ACTION", "shadow_hide"),
+		},
+		ShutdownTimeout: shutdownTimeout,
+		ShutdownDelay:   shutdownDelay,
 	}
 }
 
-func (dbConfig *DatabaseConfig) Connect() (*sql.DB, error) {
-	connStr := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
+// ConnString возвращает строку подключения к базе в формате lib/pq.
+func (dbConfig *DatabaseConfig) ConnString() string {
+	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
 		dbConfig.Host, dbConfig.Port, dbConfig.User, dbConfig.Password, dbConfig.Name)
+}
 
-	db, err := sql.Open("postgres", connStr)
+func (dbConfig *DatabaseConfig) Connect() (*sql.DB, error) {
+	db, err := sql.Open("postgres", dbConfig.ConnString())
 	if err != nil {
 		return nil, fmt.Errorf("failed to open database connection: %w", err)
 	}
@@ -73,3 +298,34 @@ func getEnv(key, defaultValue string) string {
 	}
 	return value
 }
+
+// loadPolicy читает лимит маршрута вида "10/1m" или "off".
+func loadPolicy(name, key, defaultValue string) ratelimit.Policy {
+	policy, err := ratelimit.ParsePolicy(name, getEnv(key, defaultValue))
+	if err != nil {
+		log.Printf("Warning: invalid %s, using %s: %v", key, defaultValue, err)
+		policy, _ = ratelimit.ParsePolicy(name, defaultValue)
+	}
+	return policy
+}
+
+// getList читает список через запятую; пустые элементы отбрасываются.
+func getList(key string) []string {
+	var list []string
+	for _, item := range strings.Split(os.Getenv(key), ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			list = append(list, item)
+		}
+	}
+	return list
+}
+
+// loadAction читает действие фильтра контента: reject, hold или shadow_hide.
+func loadAction(key, defaultValue string) contentfilter.Action {
+	action, err := contentfilter.ParseAction(getEnv(key, defaultValue))
+	if err != nil {
+		log.Printf("Warning: invalid %s, using %s: %v", key, defaultValue, err)
+		action, _ = contentfilter.ParseAction(defaultValue)
+	}
+	return action
+}
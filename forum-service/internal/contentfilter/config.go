@@ -0,0 +1,52 @@
+package contentfilter
+
+import "time"
+
+// Config описывает фильтры цепочки. Нулевые значения отключают
+// соответствующий фильтр.
+type Config struct {
+	// Списки слов и фраз по действию.
+	RejectWords []string
+	HoldWords   []string
+	ShadowWords []string
+
+	// MaxLinks — сколько ссылок допустимо в одном посте или сообщении.
+	MaxLinks    int
+	LinksAction Action
+
+	// NewAccountAge — сколько автор должен пробыть на форуме, чтобы
+	// публиковать больше NewAccountMaxLinks ссылок.
+	NewAccountAge      time.Duration
+	NewAccountMaxLinks int
+	NewAccountAction   Action
+
+	// RepeatCount — сколько похожих текстов автора за RepeatWindow
+	// считается спамом.
+	RepeatWindow time.Duration
+	RepeatCount  int
+	RepeatAction Action
+}
+
+// New собирает цепочку фильтров по конфигурации.
+func New(cfg Config, history History) *Chain {
+	var filters []Filter
+	for _, list := range []*WordList{
+		NewWordList("reject_words", cfg.RejectWords, ActionReject),
+		NewWordList("hold_words", cfg.HoldWords, ActionHold),
+		NewWordList("shadow_words", cfg.ShadowWords, ActionShadowHide),
+	} {
+		if list.Len() > 0 {
+			filters = append(filters, list)
+		}
+	}
+	if cfg.MaxLinks > 0 {
+		filters = append(filters, NewLinkLimit(cfg.MaxLinks, cfg.LinksAction))
+	}
+	if cfg.NewAccountAge > 0 {
+		filters = append(filters, NewNewAccountLinks(history, cfg.NewAccountAge, cfg.NewAccountMaxLinks, cfg.NewAccountAction))
+	}
+	if cfg.RepeatWindow > 0 && cfg.RepeatCount > 0 {
+		filters = append(filters, NewRepeatedContent(history, cfg.RepeatWindow, cfg.RepeatCount, cfg.RepeatAction))
+	}
+	return NewChain(filters...)
+}
@@ -0,0 +1,162 @@
+// Package contentfilter проверяет новые посты и сообщения чата на спам
+// и запрещённый контент. Фильтры объединяются в цепочку (Chain); каждый
+// сработавший фильтр возвращает вердикт с кодом причины и действием,
+// а цепочка выбирает самое строгое из них. Само действие (отказ, скрытие,
+// очередь модерации) и запись в журнал аудита выполняют сервисы.
+package contentfilter
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// Action — что сделать с контентом. Значения упорядочены по строгости:
+// из нескольких вердиктов применяется наибольший.
+type Action int
+
+const (
+	// ActionAllow — опубликовать как обычно.
+	ActionAllow Action = iota
+	// ActionHold — сохранить скрытым и поставить в очередь модерации;
+	// автор знает, что контент ждёт проверки.
+	ActionHold
+	// ActionShadowHide — сохранить скрытым без ведома автора: ему контент
+	// показывается как опубликованный, остальным не виден.
+	ActionShadowHide
+	// ActionReject — не сохранять, автор получает ошибку с кодом причины.
+	ActionReject
+)
+
+func (a Action) String() string {
+	switch a {
+	case ActionAllow:
+		return "allow"
+	case ActionHold:
+		return "hold"
+	case ActionShadowHide:
+		return "shadow_hide"
+	case ActionReject:
+		return "reject"
+	}
+	return fmt.Sprintf("Action(%d)", int(a))
+}
+
+// ParseAction разбирает действие из конфигурации: reject, hold или shadow_hide.
+func ParseAction(s string) (Action, error) {
+	switch s {
+	case "reject":
+		return ActionReject, nil
+	case "hold":
+		return ActionHold, nil
+	case "shadow_hide":
+		return ActionShadowHide, nil
+	}
+	return ActionAllow, fmt.Errorf("unknown content filter action %q, expected reject, hold or shadow_hide", s)
+}
+
+// Коды причин. Попадают в журнал аудита и в ответ автору при отказе.
+const (
+	ReasonBlockedWord     = "blocked_word"
+	ReasonTooManyLinks    = "too_many_links"
+	ReasonNewAccountLinks = "new_account_links"
+	ReasonRepeatedContent = "repeated_content"
+)
+
+// Content — проверяемый контент.
+type Content struct {
+	// Kind — post, message или comment (domain.ReportTarget*).
+	Kind     string
+	AuthorID int64
+	Title    string
+	Text     string
+}
+
+// Verdict — результат сработавшего фильтра.
+type Verdict struct {
+	Filter string
+	Action Action
+	Reason string
+	// Detail — подробность для модераторов (например, найденное слово);
+	// автору не показывается.
+	Detail string
+}
+
+// Filter — одна проверка цепочки. Check возвращает nil, если контент
+// прошёл проверку.
+type Filter interface {
+	Name() string
+	Check(ctx context.Context, content *Content) (*Verdict, error)
+}
+
+// Decision — итог цепочки: самое строгое действие и все сработавшие вердикты.
+type Decision struct {
+	Action   Action
+	Verdicts []Verdict
+}
+
+// Reasons возвращает коды причин сработавших фильтров.
+func (d Decision) Reasons() []string {
+	reasons := make([]string, 0, len(d.Verdicts))
+	for _, v := range d.Verdicts {
+		reasons = append(reasons, v.Reason)
+	}
+	return reasons
+}
+
+// Chain прогоняет контент через все фильтры. Nil-цепочка пропускает всё.
+type Chain struct {
+	filters []Filter
+	logger  zerolog.Logger
+}
+
+func NewChain(filters ...Filter) *Chain {
+	return &Chain{
+		filters: filters,
+		logger:  log.With().Str("component", "content_filter").Logger(),
+	}
+}
+
+// Check применяет все фильтры, даже после первого сработавшего, чтобы
+// в журнал попали все причины. Ошибка фильтра (например, недоступна база)
+// не блокирует публикацию: фильтр пропускается.
+func (c *Chain) Check(ctx context.Context, content *Content) Decision {
+	var decision Decision
+	if c == nil {
+		return decision
+	}
+
+	for _, filter := range c.filters {
+		verdict, err := filter.Check(ctx, content)
+		if err != nil {
+			c.logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Str("filter", filter.Name()).
+				Int64("author_id", content.AuthorID).
+				Msg("Content filter failed, skipping")
+			continue
+		}
+		if verdict == nil {
+			continue
+		}
+		verdict.Filter = filter.Name()
+		decision.Verdicts = append(decision.Verdicts, *verdict)
+		decision.Action = max(decision.Action, verdict.Action)
+	}
+	return decision
+}
+
+// History — прошлые посты, комментарии и сообщения автора для эвристик
+// новых аккаунтов и повторов.
+type History interface {
+	// FirstSeen возвращает время первого поста, комментария или сообщения
+	// автора; нулевое время, если их ещё нет.
+	FirstSeen(ctx context.Context, authorID int64) (time.Time, error)
+	// Recent возвращает тексты постов, комментариев и сообщений автора
+	// не старше since, не больше limit, новые первыми.
+	Recent(ctx context.Context, authorID int64, since time.Time, limit int) ([]string, error)
+}
@@ -0,0 +1,87 @@
+package contentfilter
+
+import (
+	"context"
+	"fmt"
+	"regexp"
+	"time"
+)
+
+// linkPattern находит ссылки: с протоколом, на www. и голые домены
+// популярных зон, которыми обходят проверку на "http".
+var linkPattern = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+|\b[a-z0-9-]+\.(?:com|net|org|ru|io|xyz|top|info|biz|me|co|ly)\b(?:/\S*)?`)
+
+// CountLinks возвращает число ссылок в тексте.
+func CountLinks(text string) int {
+	return len(linkPattern.FindAllStringIndex(text, -1))
+}
+
+// LinkLimit срабатывает, когда ссылок в контенте больше maxLinks.
+type LinkLimit struct {
+	maxLinks int
+	action   Action
+}
+
+func NewLinkLimit(maxLinks int, action Action) *LinkLimit {
+	return &LinkLimit{maxLinks: maxLinks, action: action}
+}
+
+func (f *LinkLimit) Name() string {
+	return "link_limit"
+}
+
+func (f *LinkLimit) Check(_ context.Context, content *Content) (*Verdict, error) {
+	links := CountLinks(content.Title + " " + content.Text)
+	if links <= f.maxLinks {
+		return nil, nil
+	}
+	return &Verdict{
+		Action: f.action,
+		Reason: ReasonTooManyLinks,
+		Detail: fmt.Sprintf("%d links, limit %d", links, f.maxLinks),
+	}, nil
+}
+
+// NewAccountLinks срабатывает на ссылки от новых авторов — типичный спам
+// с только что зарегистрированных аккаунтов. Новым считается автор, чей
+// первый пост или сообщение на форуме моложе minAge (или которых ещё нет):
+// дату регистрации auth-service не отдаёт, а стаж на форуме для этой
+// эвристики даже точнее.
+type NewAccountLinks struct {
+	history  History
+	minAge   time.Duration
+	maxLinks int
+	action   Action
+}
+
+// NewNewAccountLinks создаёт фильтр: новым авторам разрешено не больше
+// maxLinks ссылок.
+func NewNewAccountLinks(history History, minAge time.Duration, maxLinks int, action Action) *NewAccountLinks {
+	return &NewAccountLinks{history: history, minAge: minAge, maxLinks: maxLinks, action: action}
+}
+
+func (f *NewAccountLinks) Name() string {
+	return "new_account_links"
+}
+
+func (f *NewAccountLinks) Check(ctx context.Context, content *Content) (*Verdict, error) {
+	links := CountLinks(content.Title + " " + content.Text)
+	if links <= f.maxLinks {
+		return nil, nil
+	}
+
+	firstSeen, err := f.history.FirstSeen(ctx, content.AuthorID)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get author history: %w", err)
+	}
+	age := time.Since(firstSeen)
+	if !firstSeen.IsZero() && age >= f.minAge {
+		return nil, nil
+	}
+
+	detail := fmt.Sprintf("%d links from an author with no earlier content", links)
+	if !firstSeen.IsZero() {
+		detail = fmt.Sprintf("%d links from an author first seen %s ago", links, age.Round(time.Minute))
+	}
+	return &Verdict{Action: f.action, Reason: ReasonNewAccountLinks, Detail: detail}, nil
+}
@@ -0,0 +1,129 @@
+package contentfilter
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// confusables — буквы других алфавитов, которые выглядят как латинские
+// (после приведения к нижнему регистру).
+var confusables = map[rune]rune{
+	// Кириллица
+	'а': 'a', 'в': 'b', 'е': 'e', 'з': '3', 'і': 'i', 'ј': 'j', 'к': 'k',
+	'м': 'm', 'н': 'h', 'о': 'o', 'р': 'p', 'с': 'c', 'т': 't', 'у': 'y',
+	'х': 'x', 'ѕ': 's', 'ԁ': 'd', 'ԛ': 'q', 'ԝ': 'w', 'ь': 'b',
+	// Греческий
+	'α': 'a', 'β': 'b', 'ε': 'e', 'η': 'n', 'ι': 'i', 'κ': 'k', 'ν': 'v',
+	'ο': 'o', 'ρ': 'p', 'τ': 't', 'υ': 'u', 'χ': 'x', 'ω': 'w',
+}
+
+// leet — цифры и символы, которыми заменяют буквы. Применяется только
+// внутри слов, где есть хотя бы одна буква, чтобы не трогать числа.
+var leet = map[rune]rune{
+	'0': 'o', '1': 'i', '3': 'e', '4': 'a', '5': 's', '7': 't', '8': 'b', '9': 'g',
+	'@': 'a', '$': 's', '!': 'i', '|': 'l',
+}
+
+// stretchedRun — с какой длины серия одинаковых букв сжимается в одну.
+const stretchedRun = 3
+
+// Normalize приводит текст к виду, в котором обходы фильтра дают то же, что
+// и исходное слово: регистр, диакритика, полноширинные и «математические»
+// буквы, похожие буквы кириллицы и греческого, leet, невидимые символы,
+// растянутые буквы ("spaaam", но не двойные: "good" остаётся) и разрядка ("s p a m", "s.p.a.m").
+// Слова в результате разделены одним пробелом.
+func Normalize(text string) string {
+	var tokens []string
+	var token []rune
+	flush := func() {
+		if len(token) > 0 {
+			tokens = append(tokens, normalizeToken(token))
+			token = token[:0]
+		}
+	}
+
+	for _, r := range norm.NFKD.String(text) {
+		switch {
+		case unicode.Is(unicode.Mn, r), unicode.Is(unicode.Cf, r):
+			// Диакритика после NFKD и невидимые символы (zero-width, soft hyphen).
+		case unicode.IsLetter(r), unicode.IsDigit(r):
+			token = append(token, unicode.ToLower(r))
+		case leet[r] != 0:
+			token = append(token, r)
+		default:
+			flush()
+		}
+	}
+	flush()
+
+	return strings.Join(joinSpaced(tokens), " ")
+}
+
+func normalizeToken(token []rune) string {
+	// Восклицательные знаки в конце слова — пунктуация, а не "i".
+	for len(token) > 1 && token[len(token)-1] == '!' {
+		token = token[:len(token)-1]
+	}
+
+	hasLetter := false
+	for _, r := range token {
+		if unicode.IsLetter(r) {
+			hasLetter = true
+			break
+		}
+	}
+
+	mapped := make([]rune, 0, len(token))
+	for _, r := range token {
+		if c, ok := confusables[r]; ok {
+			r = c
+		}
+		if l, ok := leet[r]; ok && hasLetter {
+			r = l
+		}
+		mapped = append(mapped, r)
+	}
+	if !hasLetter {
+		return string(mapped)
+	}
+
+	// Растянутой считается буква, повторённая минимум stretchedRun раз
+	// подряд: двойные буквы ("good", "class") встречаются в обычных словах.
+	var b strings.Builder
+	for i := 0; i < len(mapped); {
+		j := i + 1
+		for j < len(mapped) && mapped[j] == mapped[i] {
+			j++
+		}
+		if j-i >= stretchedRun {
+			b.WriteRune(mapped[i])
+		} else {
+			b.WriteString(string(mapped[i:j]))
+		}
+		i = j
+	}
+	return b.String()
+}
+
+// joinSpaced склеивает подряд идущие однобуквенные слова: "s p a m" → "spam".
+func joinSpaced(tokens []string) []string {
+	joined := tokens[:0]
+	run := ""
+	for _, t := range tokens {
+		if len([]rune(t)) == 1 {
+			run += t
+			continue
+		}
+		if run != "" {
+			joined = append(joined, run)
+			run = ""
+		}
+		joined = append(joined, t)
+	}
+	if run != "" {
+		joined = append(joined, run)
+	}
+	return joined
+}
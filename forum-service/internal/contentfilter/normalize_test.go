@@ -0,0 +1,76 @@
+package contentfilter
+
+import (
+	"context"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"case and spaces", "  Hello   WORLD ", "hello world"},
+		{"diacritics", "Crème brûlée", "creme brulee"},
+		{"fullwidth", "ｓｐａｍ", "spam"},
+		{"cyrillic lookalikes", "ѕрам", "spam"},
+		{"leet", "$p4m", "spam"},
+		{"numbers stay numbers", "call 1337", "call 1337"},
+		{"trailing exclamation", "spam!!!", "spam"},
+		{"zero width", "sp\u200bam", "spam"},
+		{"stretched letters", "spaaaam", "spam"},
+		{"double letters kept", "good class too", "good class too"},
+		{"spaced letters", "s p a m", "spam"},
+		{"dotted letters", "s.p.a.m", "spam"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Normalize(tt.text); got != tt.want {
+				t.Errorf("Normalize(%q) = %q, want %q", tt.text, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWordList(t *testing.T) {
+	list := NewWordList("blocked_words", []string{"spam", "ass", "buy now", " "}, ActionReject)
+	if list.Len() != 3 {
+		t.Fatalf("Len() = %d, want 3", list.Len())
+	}
+
+	tests := []struct {
+		name string
+		text string
+		want string
+	}{
+		{"exact word", "this is spam", "spam"},
+		{"obfuscated word", "this is Sp@aaм", "spam"},
+		{"phrase", "Buy   NOW!", "buy now"},
+		{"word inside another word", "spammer in the classroom", ""},
+		{"double letters in blocked word kept", "as soon as possible", ""},
+		{"clean text", "hello world", ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			verdict, err := list.Check(context.Background(), &Content{Text: tt.text})
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if tt.want == "" {
+				if verdict != nil {
+					t.Errorf("Check(%q) = %+v, want no verdict", tt.text, verdict)
+				}
+				return
+			}
+			if verdict == nil {
+				t.Fatalf("Check(%q) = nil, want %q", tt.text, tt.want)
+			}
+			if verdict.Reason != ReasonBlockedWord || verdict.Detail != tt.want || verdict.Action != ActionReject {
+				t.Errorf("Check(%q) = %+v, want blocked word %q", tt.text, verdict, tt.want)
+			}
+		})
+	}
+}
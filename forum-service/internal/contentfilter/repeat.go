@@ -0,0 +1,92 @@
+package contentfilter
+
+import (
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+const (
+	// repeatMinLength — короче этого (после Normalize) текст не проверяется
+	// на повторы: "ok" и "+1" в чате повторяются законно.
+	repeatMinLength = 20
+	// repeatSimilarity — начиная с какой похожести тексты считаются повтором.
+	repeatSimilarity = 0.8
+	// repeatHistoryLimit — сколько последних текстов автора сравнивается.
+	repeatHistoryLimit = 50
+)
+
+// RepeatedContent срабатывает, когда автор уже публиковал тот же или почти
+// тот же текст (в постах, комментариях или чате) не меньше threshold раз
+// за window.
+type RepeatedContent struct {
+	history   History
+	window    time.Duration
+	threshold int
+	action    Action
+}
+
+func NewRepeatedContent(history History, window time.Duration, threshold int, action Action) *RepeatedContent {
+	return &RepeatedContent{history: history, window: window, threshold: threshold, action: action}
+}
+
+func (f *RepeatedContent) Name() string {
+	return "repeated_content"
+}
+
+func (f *RepeatedContent) Check(ctx context.Context, content *Content) (*Verdict, error) {
+	text := Normalize(content.Title + " " + content.Text)
+	if len(text) < repeatMinLength {
+		return nil, nil
+	}
+
+	recent, err := f.history.Recent(ctx, content.AuthorID, time.Now().Add(-f.window), repeatHistoryLimit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get author history: %w", err)
+	}
+
+	shingles := wordPairs(text)
+	repeats := 0
+	for _, earlier := range recent {
+		if similarity(shingles, wordPairs(Normalize(earlier))) >= repeatSimilarity {
+			repeats++
+		}
+	}
+	if repeats < f.threshold {
+		return nil, nil
+	}
+	return &Verdict{
+		Action: f.action,
+		Reason: ReasonRepeatedContent,
+		Detail: fmt.Sprintf("%d similar texts in the last %s", repeats, f.window),
+	}, nil
+}
+
+// wordPairs возвращает множество пар соседних слов; для текста из одного
+// слова — само слово.
+func wordPairs(text string) map[string]struct{} {
+	words := strings.Fields(text)
+	pairs := make(map[string]struct{}, len(words))
+	if len(words) == 1 {
+		pairs[words[0]] = struct{}{}
+	}
+	for i := 1; i < len(words); i++ {
+		pairs[words[i-1]+" "+words[i]] = struct{}{}
+	}
+	return pairs
+}
+
+// similarity — коэффициент Жаккара двух множеств.
+func similarity(a, b map[string]struct{}) float64 {
+	if len(a) == 0 || len(b) == 0 {
+		return 0
+	}
+	common := 0
+	for pair := range a {
+		if _, ok := b[pair]; ok {
+			common++
+		}
+	}
+	return float64(common) / float64(len(a)+len(b)-common)
+}
@@ -0,0 +1,111 @@
+package contentfilter
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+)
+
+// fakeHistory — History с заранее заданными текстами автора.
+type fakeHistory struct {
+	recent []string
+	err    error
+	// since и limit — аргументы последнего вызова Recent.
+	since time.Time
+	limit int
+}
+
+func (h *fakeHistory) FirstSeen(context.Context, int64) (time.Time, error) {
+	return time.Time{}, nil
+}
+
+func (h *fakeHistory) Recent(_ context.Context, _ int64, since time.Time, limit int) ([]string, error) {
+	h.since, h.limit = since, limit
+	return h.recent, h.err
+}
+
+func TestRepeatedContent(t *testing.T) {
+	const spam = "Check out my amazing crypto giveaway at the link below today"
+
+	tests := []struct {
+		name   string
+		text   string
+		recent []string
+		want   bool
+	}{
+		{
+			name:   "repeated enough times",
+			text:   spam,
+			recent: []string{spam, spam},
+			want:   true,
+		},
+		{
+			name:   "near duplicates count",
+			text:   spam,
+			recent: []string{"check out my AMAZING crypto giveaway at the link below today!!", "Check out my amazing crypto giveaway at the link below now"},
+			want:   true,
+		},
+		{
+			name:   "below threshold",
+			text:   spam,
+			recent: []string{spam, "something completely different about gardening and tomatoes"},
+			want:   false,
+		},
+		{
+			name:   "short text is not checked",
+			text:   "ok thanks",
+			recent: []string{"ok thanks", "ok thanks", "ok thanks"},
+			want:   false,
+		},
+		{
+			name: "no history",
+			text: spam,
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			history := &fakeHistory{recent: tt.recent}
+			filter := NewRepeatedContent(history, time.Hour, 2, ActionHold)
+
+			verdict, err := filter.Check(context.Background(), &Content{AuthorID: 1, Text: tt.text})
+			if err != nil {
+				t.Fatalf("Check() error = %v", err)
+			}
+			if got := verdict != nil; got != tt.want {
+				t.Fatalf("Check() = %+v, want verdict %v", verdict, tt.want)
+			}
+			if verdict != nil && (verdict.Reason != ReasonRepeatedContent || verdict.Action != ActionHold) {
+				t.Errorf("verdict = %+v, want %s with hold", verdict, ReasonRepeatedContent)
+			}
+		})
+	}
+}
+
+func TestRepeatedContentQueriesWindow(t *testing.T) {
+	history := &fakeHistory{}
+	filter := NewRepeatedContent(history, time.Hour, 2, ActionHold)
+
+	before := time.Now()
+	if _, err := filter.Check(context.Background(), &Content{AuthorID: 1, Text: "a long enough text to be checked for repeats"}); err != nil {
+		t.Fatalf("Check() error = %v", err)
+	}
+	if history.limit != repeatHistoryLimit {
+		t.Errorf("limit = %d, want %d", history.limit, repeatHistoryLimit)
+	}
+	if history.since.Before(before.Add(-time.Hour)) || history.since.After(time.Now().Add(-time.Hour)) {
+		t.Errorf("since = %s, want about an hour ago", history.since)
+	}
+}
+
+func TestRepeatedContentHistoryError(t *testing.T) {
+	history := &fakeHistory{err: errors.New("connection refused")}
+	filter := NewRepeatedContent(history, time.Hour, 2, ActionHold)
+
+	_, err := filter.Check(context.Background(), &Content{AuthorID: 1, Text: "a long enough text to be checked for repeats"})
+	if !errors.Is(err, history.err) {
+		t.Errorf("Check() error = %v, want wrapped history error", err)
+	}
+}
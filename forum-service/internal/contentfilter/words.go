@@ -0,0 +1,46 @@
+package contentfilter
+
+import (
+	"context"
+	"strings"
+)
+
+// WordList срабатывает на слова и фразы из списка. Сравнение идёт по целым
+// словам после Normalize, так что обходы вроде "Sp@aaм" находятся, а слово
+// внутри другого слова — нет.
+type WordList struct {
+	name   string
+	action Action
+	// words — нормализованные фразы (" " + фраза + " ") и их исходный вид.
+	words map[string]string
+}
+
+// NewWordList создаёт фильтр с действием action. Пустые записи пропускаются.
+func NewWordList(name string, words []string, action Action) *WordList {
+	list := &WordList{name: name, action: action, words: make(map[string]string, len(words))}
+	for _, word := range words {
+		if normalized := Normalize(word); normalized != "" {
+			list.words[" "+normalized+" "] = strings.TrimSpace(word)
+		}
+	}
+	return list
+}
+
+func (l *WordList) Name() string {
+	return l.name
+}
+
+// Len возвращает число фраз в списке.
+func (l *WordList) Len() int {
+	return len(l.words)
+}
+
+func (l *WordList) Check(_ context.Context, content *Content) (*Verdict, error) {
+	text := " " + Normalize(content.Title+" "+content.Text) + " "
+	for normalized, word := range l.words {
+		if strings.Contains(text, normalized) {
+			return &Verdict{Action: l.action, Reason: ReasonBlockedWord, Detail: word}, nil
+		}
+	}
+	return nil, nil
+}
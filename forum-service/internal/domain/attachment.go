@@ -0,0 +1,34 @@
+package domain
+
+import "errors"
+
+var (
+	ErrAttachmentNotFound  = errors.New("attachment not found")
+	ErrUploadTooLarge      = errors.New("upload too large")
+	ErrUnsupportedFileType = errors.New("unsupported file type")
+	ErrInvalidDownloadLink = errors.New("download link is invalid or expired")
+)
+
+// Варианты файла вложения, доступные для скачивания.
+const (
+	AttachmentVariantOriginal  = "original"
+	AttachmentVariantThumbnail = "thumb"
+)
+
+// Attachment — загруженный файл. Пока PostID равен нулю, вложение не привязано
+// ни к одному посту и может быть прикреплено только загрузившим его пользователем.
+type Attachment struct {
+	ID           int64  `json:"id"`
+	PostID       int64  `json:"post_id,omitempty"`
+	UploaderID   int64  `json:"uploader_id"`
+	Filename     string `json:"filename"`
+	ContentType  string `json:"content_type"`
+	Size         int64  `json:"size"`
+	Width        int    `json:"width,omitempty"`
+	Height       int    `json:"height,omitempty"`
+	BlobKey      string `json:"-"`
+	ThumbnailKey string `json:"-"`
+	URL          string `json:"url,omitempty"`
+	ThumbnailURL string `json:"thumbnail_url,omitempty"`
+	CreatedAt    string `json:"created_at"`
+}
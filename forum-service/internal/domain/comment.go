@@ -0,0 +1,18 @@
+package domain
+
+type Comment struct {
+	ID          int64  `json:"id"`
+	PostID      int64  `json:"post_id"`
+	AuthorID    int64  `json:"author_id"`
+	Author      string `json:"author"`
+	AvatarURL   string `json:"avatar_url"`
+	Content     string `json:"content"`
+	ContentHTML string `json:"content_html"`
+	RenderVer   int    `json:"-"`
+	CreatedAt   string `json:"created_at"`
+
+	// Hidden — комментарий сохранён скрытым по решению фильтра контента;
+	// Held — он ждёт проверки модератором (автору об этом сообщается).
+	Hidden bool `json:"-"`
+	Held   bool `json:"held,omitempty"`
+}
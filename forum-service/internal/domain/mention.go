@@ -0,0 +1,67 @@
+package domain
+
+import "regexp"
+
+const (
+	MentionSourcePost    = "post"
+	MentionSourceComment = "comment"
+	MentionSourceMessage = "message"
+
+	// MaxMentionsPerText — сколько разных пользователей можно упомянуть в одном тексте;
+	// остальные упоминания остаются обычным текстом.
+	MaxMentionsPerText = 20
+	// ExcerptLength — длина фрагмента текста, который показывается в уведомлении.
+	ExcerptLength = 140
+)
+
+// mentionPattern совпадает с @username, если перед @ не стоит буква, цифра или
+// другая @ (чтобы не ловить адреса почты). Формат имени повторяет правила
+// регистрации в auth-service.
+var mentionPattern = regexp.MustCompile(`(?:^|[^\w@])@(\w{3,20})\b`)
+
+// Mention — упоминание пользователя в посте, комментарии или сообщении чата.
+type Mention struct {
+	ID              int64  `json:"id"`
+	SourceType      string `json:"source_type"`
+	SourceID        int64  `json:"source_id"`
+	PostID          int64  `json:"post_id,omitempty"` // пост, к которому относится комментарий
+	MentionedUserID int64  `json:"mentioned_user_id"`
+	AuthorID        int64  `json:"author_id"`
+	Author          string `json:"author"`
+	Excerpt         string `json:"excerpt"`
+	CreatedAt       string `json:"created_at"`
+}
+
+// MentionSource — текст, в котором ищутся упоминания, и его происхождение.
+type MentionSource struct {
+	Type     string
+	ID       int64
+	PostID   int64
+	AuthorID int64
+	Author   string
+	Text     string
+}
+
+// ExtractMentions возвращает имена, упомянутые в тексте, без повторов и
+// в порядке появления, не больше MaxMentionsPerText.
+func ExtractMentions(text string) []string {
+	var usernames []string
+	seen := make(map[string]bool)
+	for _, match := range mentionPattern.FindAllStringSubmatch(text, -1) {
+		username := match[1]
+		if seen[username] {
+			continue
+		}
+		seen[username] = true
+		usernames = append(usernames, username)
+		if len(usernames) == MaxMentionsPerText {
+			break
+		}
+	}
+	return usernames
+}
+
+// Excerpt обрезает текст до фрагмента, который показывается в уведомлении.
+func Excerpt(text string) string {
+	return truncateRunes(text, ExcerptLength)
+}
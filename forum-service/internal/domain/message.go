@@ -1,11 +1,62 @@
 package domain
 
-import _ "time"
+import "errors"
+
+var (
+	ErrMessageNotFound     = errors.New("message not found")
+	ErrNotMessageAuthor    = errors.New("only the author can change this message")
+	ErrEditWindowExpired   = errors.New("message can no longer be edited")
+	ErrReplyTargetNotFound = errors.New("message being replied to not found")
+)
+
+// QuotePreviewLength — сколько символов цитируемого сообщения попадает в превью ответа.
+const QuotePreviewLength = 100
 
 type Message struct {
 	ID        int64  `json:"id"`
 	Content   string `json:"content"`
 	Username  string `json:"username"`
 	UserID    int64  `json:"user_id,omitempty"`
-	CreatedAt string `json:"created_at"` 
+	CreatedAt string `json:"created_at"`
+	EditedAt  string `json:"edited_at,omitempty"`
+	// Deleted — сообщение удалено; в истории от него остаётся только «надгробие» без текста.
+	Deleted   bool            `json:"deleted,omitempty"`
+	Reactions []ReactionCount `json:"reactions,omitempty"`
+	// ReplyTo — ID сообщения, на которое это сообщение отвечает; Quote — его превью.
+	ReplyTo int64         `json:"reply_to,omitempty"`
+	Quote   *MessageQuote `json:"quote,omitempty"`
+	// Hidden — сообщение сохранено скрытым по решению фильтра контента
+	// и не рассылается; Held — оно ждёт проверки модератором.
+	Hidden bool `json:"-"`
+	Held   bool `json:"held,omitempty"`
+}
+
+// MessageQuote — компактное превью сообщения, на которое ответили. Если исходное
+// сообщение удалено или скрыто, остаются только ID и Deleted.
+type MessageQuote struct {
+	ID       int64  `json:"id"`
+	Username string `json:"username,omitempty"`
+	UserID   int64  `json:"user_id,omitempty"`
+	Content  string `json:"content,omitempty"`
+	Deleted  bool   `json:"deleted,omitempty"`
+}
+
+// NewMessageQuote строит превью сообщения, обрезая текст до QuotePreviewLength символов.
+func NewMessageQuote(message *Message) *MessageQuote {
+	quote := &MessageQuote{ID: message.ID, Deleted: message.Deleted}
+	if message.Deleted {
+		return quote
+	}
+	quote.Username = message.Username
+	quote.UserID = message.UserID
+	quote.Content = truncateRunes(message.Content, QuotePreviewLength)
+	return quote
+}
+
+func truncateRunes(s string, maxLen int) string {
+	runes := []rune(s)
+	if len(runes) <= maxLen {
+		return s
+	}
+	return string(runes[:maxLen]) + "…"
 }
@@ -0,0 +1,17 @@
+package domain
+
+import "fmt"
+
+// FilterReporterID — автор жалоб, которые открывает фильтр контента,
+// задержав пост или сообщение до проверки модератором.
+const FilterReporterID = 0
+
+// ContentRejectedError — фильтр контента отклонил пост или сообщение.
+// Reasons — коды причин (contentfilter.Reason*).
+type ContentRejectedError struct {
+	Reasons []string
+}
+
+func (e *ContentRejectedError) Error() string {
+	return fmt.Sprintf("content rejected by filter: %v", e.Reasons)
+}
@@ -1,10 +1,44 @@
 package domain
 
+import "errors"
+
+var (
+	ErrPostNotFound = errors.New("post not found")
+	ErrPostLocked   = errors.New("post is locked")
+)
+
 type Post struct {
-	ID        int64  `json:"id"`
-	Title     string `json:"title" validate:"required,min=3,max=100"`
-	Content   string `json:"content" validate:"required,min=10"`
-	AuthorID  int64  `json:"author_id"`
-	CreatedAt string `json:"created_at"`
-	Author    string `json:"author"` // Добавлено для фронтенда
+	ID           int64  `json:"id"`
+	Title        string `json:"title" validate:"required,min=3,max=100"`
+	Content      string `json:"content" validate:"required,min=10"`
+	ContentHTML  string `json:"content_html"`
+	RenderVer    int    `json:"-"` // версия markdown-рендерера, которой получен ContentHTML
+	AuthorID     int64  `json:"author_id"`
+	CreatedAt    string `json:"created_at"`
+	Author       string `json:"author"` // Добавлено для фронтенда
+	AvatarURL    string `json:"avatar_url"`
+	Pinned       bool   `json:"pinned"`
+	Locked       bool   `json:"locked"`
+	Announcement bool   `json:"announcement"`
+
+	Reactions []ReactionCount `json:"reactions,omitempty"`
+
+	AttachmentIDs []int64       `json:"attachment_ids,omitempty"` // ID загрузок, которые нужно прикрепить при создании
+	Attachments   []*Attachment `json:"attachments,omitempty"`
+
+	// Hidden — пост сохранён скрытым по решению фильтра контента; Held —
+	// он ждёт проверки модератором (автору об этом сообщается).
+	Hidden bool `json:"-"`
+	Held   bool `json:"held,omitempty"`
+}
+
+// PostFlagsUpdate — изменение модераторских флагов поста; nil-поля не меняются.
+type PostFlagsUpdate struct {
+	Pinned       *bool `json:"pinned"`
+	Locked       *bool `json:"locked"`
+	Announcement *bool `json:"announcement"`
+}
+
+func (u PostFlagsUpdate) IsEmpty() bool {
+	return u.Pinned == nil && u.Locked == nil && u.Announcement == nil
 }
@@ -0,0 +1,43 @@
+package domain
+
+import "errors"
+
+const (
+	ReactionTargetPost    = "post"
+	ReactionTargetMessage = "message"
+)
+
+var ErrInvalidReaction = errors.New("unsupported reaction")
+
+// AllowedReactions — набор эмодзи, которыми можно реагировать. Список короткий
+// намеренно: реакции должны оставаться лёгкими и одинаково выглядеть у всех.
+var AllowedReactions = []string{"👍", "👎", "❤️", "😂", "😮", "😢", "🎉", "🔥"}
+
+// ReactionCount — сколько пользователей поставили эмодзи на пост или сообщение.
+type ReactionCount struct {
+	Emoji string `json:"emoji"`
+	Count int    `json:"count"`
+}
+
+// ReactionUpdate — результат переключения реакции вместе с новыми итогами по цели.
+type ReactionUpdate struct {
+	TargetType string          `json:"target_type"`
+	TargetID   int64           `json:"target_id"`
+	UserID     int64           `json:"user_id"`
+	Emoji      string          `json:"emoji"`
+	Added      bool            `json:"added"`
+	Reactions  []ReactionCount `json:"reactions"`
+}
+
+func IsValidReaction(emoji string) bool {
+	for _, allowed := range AllowedReactions {
+		if emoji == allowed {
+			return true
+		}
+	}
+	return false
+}
+
+func IsValidReactionTarget(targetType string) bool {
+	return targetType == ReactionTargetPost || targetType == ReactionTargetMessage
+}
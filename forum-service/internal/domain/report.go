@@ -0,0 +1,65 @@
+package domain
+
+import "errors"
+
+const (
+	ReportTargetPost    = "post"
+	ReportTargetMessage = "message"
+	// ReportTargetComment — жалобы на комментарии создаёт только фильтр
+	// контента, задерживая комментарий до проверки модератором.
+	ReportTargetComment = "comment"
+
+	ReportReasonSpam       = "spam"
+	ReportReasonHarassment = "harassment"
+	ReportReasonHate       = "hate"
+	ReportReasonNSFW       = "nsfw"
+	ReportReasonOther      = "other"
+
+	ReportStatusOpen      = "open"
+	ReportStatusActioned  = "actioned"
+	ReportStatusDismissed = "dismissed"
+)
+
+var (
+	ErrAlreadyReported = errors.New("content already reported by this user")
+	ErrReportNotFound  = errors.New("report not found")
+	ErrTargetNotFound  = errors.New("reported content not found")
+)
+
+type Report struct {
+	ID         int64  `json:"id"`
+	TargetType string `json:"target_type"`
+	TargetID   int64  `json:"target_id"`
+	ReporterID int64  `json:"reporter_id"`
+	Reason     string `json:"reason"`
+	Details    string `json:"details,omitempty"`
+	Status     string `json:"status"`
+	ResolvedBy int64  `json:"resolved_by,omitempty"`
+	ResolvedAt string `json:"resolved_at,omitempty"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// ReportFilter описывает выборку очереди модерации. Пустые поля не фильтруют.
+type ReportFilter struct {
+	Status     string
+	TargetType string
+	Reason     string
+	Limit      int
+	Offset     int
+}
+
+func IsValidReportReason(reason string) bool {
+	switch reason {
+	case ReportReasonSpam, ReportReasonHarassment, ReportReasonHate, ReportReasonNSFW, ReportReasonOther:
+		return true
+	}
+	return false
+}
+
+func IsValidReportStatus(status string) bool {
+	switch status {
+	case ReportStatusOpen, ReportStatusActioned, ReportStatusDismissed:
+		return true
+	}
+	return false
+}
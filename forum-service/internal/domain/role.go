@@ -0,0 +1,12 @@
+package domain
+
+// Роли пользователей; выдаются auth-service и приходят в JWT.
+const (
+	RoleUser      = "user"
+	RoleModerator = "moderator"
+	RoleAdmin     = "admin"
+)
+
+func IsModerator(role string) bool {
+	return role == RoleModerator || role == RoleAdmin
+}
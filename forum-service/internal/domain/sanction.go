@@ -0,0 +1,52 @@
+package domain
+
+import "time"
+
+// Санкции выдаёт auth-service; здесь — их зеркало для проверки на стороне форума.
+const (
+	SanctionBan            = "ban"
+	SanctionMute           = "mute"
+	SanctionPostSuspension = "post_suspension"
+)
+
+type Sanction struct {
+	ID        int64  `json:"id"`
+	UserID    int64  `json:"user_id"`
+	Type      string `json:"type"`
+	Reason    string `json:"reason"`
+	ActorID   int64  `json:"actor_id"`
+	ExpiresAt string `json:"expires_at,omitempty"`
+	CreatedAt string `json:"created_at"`
+	RevokedAt string `json:"revoked_at,omitempty"`
+	RevokedBy int64  `json:"revoked_by,omitempty"`
+}
+
+// IsActive сообщает, действует ли санкция в момент now.
+func (s *Sanction) IsActive(now time.Time) bool {
+	if s.RevokedAt != "" {
+		return false
+	}
+	if s.ExpiresAt == "" {
+		return true
+	}
+	expiresAt, err := time.Parse(time.RFC3339, s.ExpiresAt)
+	return err != nil || expiresAt.After(now)
+}
+
+// Expiry возвращает момент окончания санкции или нулевое время для бессрочной.
+func (s *Sanction) Expiry() time.Time {
+	expiresAt, _ := time.Parse(time.RFC3339, s.ExpiresAt)
+	return expiresAt
+}
+
+// FindSanction возвращает первую санкцию одного из указанных типов.
+func FindSanction(sanctions []Sanction, types ...string) *Sanction {
+	for i := range sanctions {
+		for _, t := range types {
+			if sanctions[i].Type == t {
+				return &sanctions[i]
+			}
+		}
+	}
+	return nil
+}
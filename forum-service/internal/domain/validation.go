@@ -0,0 +1,18 @@
+package domain
+
+import "fmt"
+
+// ValidationError — данные запроса не прошли проверку. Текст адресован
+// пользователю и возвращается в ответе API как есть.
+type ValidationError struct {
+	Message string
+}
+
+func (e *ValidationError) Error() string {
+	return e.Message
+}
+
+// Invalid создаёт ValidationError; аргументы — как у fmt.Sprintf.
+func Invalid(format string, args ...interface{}) error {
+	return &ValidationError{Message: fmt.Sprintf(format, args...)}
+}
@@ -1,45 +1,60 @@
 package handler
 
 import (
+	"fmt"
 	"github.com/rs/zerolog"
+	"math"
 	"net/http"
+	"strconv"
+	"strings"
 	"time"
 
 	"github.com/Frozz164/forum-app_v2/auth-service/pkg/helper"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/authclient"
 	"github.com/Frozz164/forum-app_v2/forum-service/pkg/websocket"
 	"github.com/gin-gonic/gin"
 	"github.com/rs/zerolog/log"
-	"golang.org/x/time/rate"
 )
 
 type ChatHandler struct {
 	chatService service.ChatService
 	pool        *websocket.Pool
+	sanctions   *authclient.SanctionCache
+	roles       *authclient.RoleCache
 	jwtSecret   string
-	rateLimiter *rate.Limiter
 	logger      zerolog.Logger
 }
 
-func NewChatHandler(chatService service.ChatService, pool *websocket.Pool, jwtSecret string) *ChatHandler {
+func NewChatHandler(chatService service.ChatService, pool *websocket.Pool, sanctions *authclient.SanctionCache, roles *authclient.RoleCache, jwtSecret string) *ChatHandler {
 	return &ChatHandler{
 		chatService: chatService,
 		pool:        pool,
+		sanctions:   sanctions,
+		roles:       roles,
 		jwtSecret:   jwtSecret,
-		rateLimiter: rate.NewLimiter(rate.Every(time.Second), 1),
 		logger:      log.With().Str("component", "chat_handler").Logger(),
 	}
 }
 
 func (h *ChatHandler) WebsocketHandler(c *gin.Context) {
 	logger := h.logger.With().
+		Ctx(c.Request.Context()).
 		Str("method", "WebsocketHandler").
 		Str("remote_addr", c.Request.RemoteAddr).
 		Logger()
 
-	if !h.rateLimiter.Allow() {
-		logger.Warn().Msg("Rate limit exceeded")
-		c.AbortWithStatus(http.StatusTooManyRequests)
+	since, err := parseSince(c.Query("since"))
+	if err != nil {
+		logger.Warn().Err(err).Msg("Invalid since parameter")
+		problem.Abort(c, errInvalidSince)
+		return
+	}
+
+	identity, ok := h.identify(c, c.Query("token"), logger)
+	if !ok {
 		return
 	}
 
@@ -49,41 +64,220 @@ func (h *ChatHandler) WebsocketHandler(c *gin.Context) {
 		return
 	}
 
+	client := websocket.NewClient(conn, h.pool, identity.username, identity.userID, identity.role, identity.readOnly)
+	client.ResumeAfter(since)
+	for _, mute := range identity.mutes {
+		client.Mute(mute)
+	}
+
+	h.pool.Register <- client
+
+	logger.Info().
+		Str("username", identity.username).
+		Int64("user_id", identity.userID).
+		Bool("read_only", identity.readOnly).
+		Int64("since", since).
+		Msg("New WebSocket client registered")
+
+	go client.Read(h.chatService)
+	go client.Write()
+}
+
+// Stream отдаёт события чата (сообщения, присутствие, уведомления) как
+// text/event-stream — альтернатива WebSocket для клиентов, которым достаточно
+// читать. Заголовок Last-Event-ID (или ?last_event_id=) задаёт ID последнего
+// полученного сообщения: вместо недавней истории досылаются только более новые.
+func (h *ChatHandler) Stream(c *gin.Context) {
+	logger := h.logger.With().
+		Ctx(c.Request.Context()).
+		Str("method", "Stream").
+		Str("remote_addr", c.Request.RemoteAddr).
+		Logger()
+
+	lastEventID, err := parseLastEventID(c)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Invalid Last-Event-ID")
+		problem.Abort(c, errInvalidLastEventID)
+		return
+	}
+
 	token := c.Query("token")
-	var username string
-	var userID int64
-	var readOnly = true
+	if token == "" {
+		token = strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+	}
+	identity, ok := h.identify(c, token, logger)
+	if !ok {
+		return
+	}
+
+	client := websocket.NewStreamClient(h.pool, identity.username, identity.userID, identity.role, identity.readOnly, lastEventID)
+	for _, mute := range identity.mutes {
+		client.Mute(mute)
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	h.pool.Register <- client
+
+	logger.Info().
+		Str("username", identity.username).
+		Int64("user_id", identity.userID).
+		Bool("read_only", identity.readOnly).
+		Int64("last_event_id", lastEventID).
+		Msg("New stream client registered")
+
+	client.Stream(c.Request.Context(), c.Writer)
+}
+
+// PostMessage публикует сообщение в чат обычным POST-запросом — так пишут
+// клиенты, читающие чат через Stream.
+func (h *ChatHandler) PostMessage(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "PostMessage").Logger()
+
+	var req struct {
+		Content string `json:"content" binding:"required"`
+		ReplyTo int64  `json:"reply_to"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		badRequest(c, err)
+		return
+	}
+
+	message := &domain.Message{
+		Content:  req.Content,
+		Username: c.GetString("username"),
+		UserID:   c.GetInt64("userID"),
+		ReplyTo:  req.ReplyTo,
+	}
+	logger = logger.With().Int64("user_id", message.UserID).Logger()
+
+	// Ограничения частоты общие с WebSocket: HTTP не даёт обойти их.
+	refund, flood := h.pool.CheckFlood(message.UserID, c.GetString("role"), message.Content)
+	if flood != nil {
+		h.pool.FloodRejected()
+		logger.Debug().Str("reason", flood.Reason).Msg("Message rejected by flood control")
+		c.Header("Retry-After", strconv.Itoa(int(math.Ceil(flood.RetryAfter.Seconds()))))
+		problem.Abort(c, errChatFlood.WithDetail(flood.Explanation).With("reason", flood.Reason))
+		return
+	}
+
+	err := h.chatService.ProcessMessage(c.Request.Context(), message)
+	if err != nil {
+		refund()
+		logger.Warn().Err(err).Msg("Failed to process message")
+		fail(c, err)
+		return
+	}
+
+	// Скрытое фильтром сообщение остальным не рассылается; автор получает
+	// его в ответе, а задержанное — с held: true.
+	if !message.Hidden && !h.pool.PublishMessage(c.Request.Context(), message) {
+		logger.Warn().Int64("message_id", message.ID).Msg("Broadcast queue full")
+	}
+
+	logger.Info().Int64("message_id", message.ID).Msg("Message posted")
+	c.JSON(http.StatusCreated, message)
+}
+
+// chatIdentity — кем клиент подключается к чату.
+type chatIdentity struct {
+	username string
+	userID   int64
+	role     string
+	readOnly bool
+	mutes    []domain.Sanction
+}
+
+// identify определяет пользователя по токену; без валидного токена клиент
+// подключается гостем только для чтения. Забаненным отвечает 403 и возвращает false.
+func (h *ChatHandler) identify(c *gin.Context, token string, logger zerolog.Logger) (*chatIdentity, bool) {
+	identity := &chatIdentity{readOnly: true}
 
 	if token != "" {
 		claims, err := helper.ValidateTokenWithClaims(token, h.jwtSecret)
 		if err != nil {
 			logger.Warn().Err(err).Str("token_prefix", token[:min(10, len(token))]).Msg("Token validation failed")
 		} else {
-			readOnly = false
-			username = claims.Username
-			userID = claims.UserID
+			sanctions, err := h.sanctions.Active(c.Request.Context(), claims.UserID, token)
+			if err != nil {
+				logger.Error().Err(err).Int64("user_id", claims.UserID).Msg("Failed to check sanctions, allowing connection unchecked")
+			}
+			if ban := domain.FindSanction(sanctions, domain.SanctionBan); ban != nil {
+				logger.Warn().Int64("sanction_id", ban.ID).Msg("Banned user refused chat connection")
+				problem.Abort(c, errAccountBanned.With("reason", ban.Reason))
+				return nil, false
+			}
+			now := time.Now()
+			for _, sanction := range sanctions {
+				if sanction.Type == domain.SanctionMute && sanction.IsActive(now) {
+					identity.mutes = append(identity.mutes, sanction)
+				}
+			}
+
+			identity.readOnly = false
+			identity.username = claims.Username
+			identity.userID = claims.UserID
+			identity.role = claims.Role
+			// Права модератора в чате (удаление чужих сообщений, обход
+			// медленного режима) даёт только текущая роль, а не роль в токене.
+			if domain.IsModerator(claims.Role) {
+				role, err := h.roles.Current(c.Request.Context(), claims.UserID, token)
+				if err != nil {
+					logger.Error().Err(err).Int64("user_id", claims.UserID).Msg("Failed to check current role, connecting as user")
+					role = domain.RoleUser
+				}
+				identity.role = role
+			}
 		}
 	}
 
-	if readOnly {
-		username = "Guest_" + generateRandomID()
+	if identity.readOnly {
+		identity.username = "Guest_" + generateRandomID()
 		logger.Debug().
-			Str("username", username).
+			Str("username", identity.username).
 			Msg("Assigning guest username")
 	}
+	return identity, true
+}
 
-	client := websocket.NewClient(conn, h.pool, username, userID, readOnly)
+// parseLastEventID читает ID последнего полученного события из заголовка
+// Last-Event-ID, который EventSource шлёт при переподключении, или из query.
+func parseLastEventID(c *gin.Context) (int64, error) {
+	raw := c.GetHeader("Last-Event-ID")
+	if raw == "" {
+		raw = c.Query("last_event_id")
+	}
+	return parseSince(raw)
+}
 
-	h.pool.Register <- client
+// parseSince разбирает ID сообщения, после которого клиенту нужно дослать
+// пропущенное; пустая строка означает обычную недавнюю историю.
+func parseSince(raw string) (int64, error) {
+	if raw == "" {
+		return 0, nil
+	}
+	id, err := strconv.ParseInt(raw, 10, 64)
+	if err != nil || id < 0 {
+		return 0, fmt.Errorf("invalid message ID %q", raw)
+	}
+	return id, nil
+}
 
-	logger.Info().
-		Str("username", username).
-		Int64("user_id", userID).
-		Bool("read_only", readOnly).
-		Msg("New WebSocket client registered")
+// Online возвращает пользователей, подключённых к чату прямо сейчас.
+func (h *ChatHandler) Online(c *gin.Context) {
+	users := h.pool.OnlineUsers()
 
-	go client.Read(h.chatService)
-	go client.Write()
+	h.logger.Debug().
+		Ctx(c.Request.Context()).
+		Str("method", "Online").
+		Int("user_count", len(users)).
+		Msg("Retrieved online users")
+	c.JSON(http.StatusOK, users)
 }
 
 func generateRandomID() string {
@@ -0,0 +1,102 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type CommentHandler struct {
+	service service.CommentService
+	logger  zerolog.Logger
+}
+
+func NewCommentHandler(service service.CommentService) *CommentHandler {
+	return &CommentHandler{
+		service: service,
+		logger:  log.With().Str("component", "comment_handler").Logger(),
+	}
+}
+
+func (h *CommentHandler) CreateComment(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "CreateComment").Logger()
+
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Str("post_id_param", c.Param("id")).Msg("Invalid post ID format")
+		problem.Abort(c, errInvalidPostID)
+		return
+	}
+
+	authorID, exists := c.Get("userID")
+	if !exists {
+		logger.Warn().Msg("Unauthorized attempt to create comment")
+		problem.Abort(c, problem.ErrUnauthorized)
+		return
+	}
+
+	var req struct {
+		Content string `json:"content" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		badRequest(c, err)
+		return
+	}
+
+	logger = logger.With().Int64("post_id", postID).Int64("author_id", authorID.(int64)).Logger()
+	comment, err := h.service.CreateComment(c.Request.Context(), &domain.Comment{
+		PostID:   postID,
+		AuthorID: authorID.(int64),
+		Author:   c.GetString("username"),
+		Content:  req.Content,
+	})
+	switch {
+	case errors.Is(err, domain.ErrPostNotFound):
+		fail(c, err)
+		return
+	case errors.Is(err, domain.ErrPostLocked):
+		logger.Warn().Msg("Attempt to comment on locked post")
+		fail(c, err)
+		return
+	case err != nil:
+		logger.Error().Err(err).Msg("Failed to create comment")
+		fail(c, err)
+		return
+	}
+
+	logger.Info().Int64("comment_id", comment.ID).Msg("Comment created successfully")
+	c.JSON(http.StatusCreated, comment)
+}
+
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "GetComments").Logger()
+
+	postID, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Str("post_id_param", c.Param("id")).Msg("Invalid post ID format")
+		problem.Abort(c, errInvalidPostID)
+		return
+	}
+
+	comments, err := h.service.GetComments(c.Request.Context(), postID, c.GetInt64("userID"))
+	if errors.Is(err, domain.ErrPostNotFound) {
+		fail(c, err)
+		return
+	}
+	if err != nil {
+		logger.Error().Err(err).Int64("post_id", postID).Msg("Failed to get comments")
+		fail(c, err)
+		return
+	}
+
+	logger.Debug().Int("comment_count", len(comments)).Msg("Retrieved comments")
+	c.JSON(http.StatusOK, comments)
+}
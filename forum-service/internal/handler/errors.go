@@ -0,0 +1,90 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+// Ошибки API forum-service. Коды стабильны — клиенты различают ошибки по ним,
+// а не по тексту detail.
+var (
+	errInvalidPostID       = problem.ErrInvalidParam.WithDetail("invalid post ID")
+	errInvalidReportID     = problem.ErrInvalidParam.WithDetail("invalid report ID")
+	errInvalidUploadID     = problem.ErrInvalidParam.WithDetail("invalid upload ID")
+	errInvalidSince        = problem.ErrInvalidParam.WithDetail("invalid since parameter")
+	errInvalidLastEventID  = problem.ErrInvalidParam.WithDetail("invalid Last-Event-ID")
+	errFileRequired        = problem.ErrValidation.WithDetail("file is required")
+	errUserIDRequired      = problem.ErrValidation.WithDetail("user_id is required")
+	errInvalidSlowMode     = problem.ErrValidation.WithDetail("seconds must be between 0 and 3600")
+	errAttachmentsInvalid  = problem.New(http.StatusBadRequest, "attachments_unavailable", "attachments not found or already attached")
+	errPostNotFound        = problem.New(http.StatusNotFound, "post_not_found", "post not found")
+	errPostLocked          = problem.New(http.StatusLocked, "post_locked", "post is locked")
+	errReportNotFound      = problem.New(http.StatusNotFound, "report_not_found", "report not found")
+	errAlreadyReported     = problem.New(http.StatusConflict, "already_reported", "content already reported by this user")
+	errInvalidReaction     = problem.New(http.StatusBadRequest, "invalid_reaction", "unsupported reaction").With("allowed", domain.AllowedReactions)
+	errMessageNotFound     = problem.New(http.StatusNotFound, "message_not_found", "message not found")
+	errReplyTargetNotFound = problem.New(http.StatusNotFound, "reply_target_not_found", "message being replied to not found")
+	errNotMessageAuthor    = problem.New(http.StatusForbidden, "not_message_author", "only the author can change this message")
+	errEditWindowExpired   = problem.New(http.StatusConflict, "edit_window_expired", "message can no longer be edited")
+	errAttachmentNotFound  = problem.New(http.StatusNotFound, "attachment_not_found", "attachment not found")
+	errUploadTooLarge      = problem.New(http.StatusRequestEntityTooLarge, "upload_too_large", "upload too large")
+	errUnsupportedFileType = problem.New(http.StatusUnsupportedMediaType, "unsupported_file_type", "unsupported file type")
+	errInvalidDownloadLink = problem.New(http.StatusForbidden, "invalid_download_link", "download link is invalid or expired")
+	errAccountBanned       = problem.New(http.StatusForbidden, "account_banned", "account is banned")
+	errContentRejected     = problem.New(http.StatusUnprocessableEntity, "content_rejected", "content was rejected by the spam filter")
+	errChatFlood           = problem.ErrRateLimited.WithDetail("you are sending messages too fast")
+)
+
+// fail отвечает ошибкой в формате problem+json. Ошибки domain получают свой
+// код и статус, ошибки проверки ввода — validation_failed с текстом проверки;
+// всё остальное уходит клиенту как internal_error без подробностей.
+func fail(c *gin.Context, err error) {
+	problem.Abort(c, fromDomain(err))
+}
+
+// badRequest отвечает invalid_request на тело, которое не удалось разобрать.
+func badRequest(c *gin.Context, err error) {
+	problem.Abort(c, problem.ErrInvalidRequest.WithDetail(err.Error()))
+}
+
+func fromDomain(err error) error {
+	var validation *domain.ValidationError
+	var rejected *domain.ContentRejectedError
+	switch {
+	case errors.As(err, &validation):
+		return problem.ErrValidation.WithDetail(validation.Message)
+	case errors.As(err, &rejected):
+		return errContentRejected.With("reasons", rejected.Reasons)
+	case errors.Is(err, domain.ErrPostNotFound):
+		return errPostNotFound
+	case errors.Is(err, domain.ErrPostLocked):
+		return errPostLocked
+	case errors.Is(err, domain.ErrReportNotFound):
+		return errReportNotFound
+	case errors.Is(err, domain.ErrAlreadyReported):
+		return errAlreadyReported
+	case errors.Is(err, domain.ErrInvalidReaction):
+		return errInvalidReaction
+	case errors.Is(err, domain.ErrMessageNotFound):
+		return errMessageNotFound
+	case errors.Is(err, domain.ErrReplyTargetNotFound):
+		return errReplyTargetNotFound
+	case errors.Is(err, domain.ErrNotMessageAuthor):
+		return errNotMessageAuthor
+	case errors.Is(err, domain.ErrEditWindowExpired):
+		return errEditWindowExpired
+	case errors.Is(err, domain.ErrAttachmentNotFound):
+		return errAttachmentNotFound
+	case errors.Is(err, domain.ErrUploadTooLarge):
+		return errUploadTooLarge
+	case errors.Is(err, domain.ErrUnsupportedFileType):
+		return errUnsupportedFileType
+	case errors.Is(err, domain.ErrInvalidDownloadLink):
+		return errInvalidDownloadLink
+	}
+	return err
+}
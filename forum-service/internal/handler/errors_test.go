@@ -0,0 +1,110 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/gin-gonic/gin"
+)
+
+func TestFailMapsDomainErrors(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	tests := []struct {
+		name       string
+		err        error
+		wantStatus int
+		wantCode   string
+		wantDetail string
+	}{
+		{"validation", domain.Invalid("title is required"), http.StatusBadRequest, "validation_failed", "title is required"},
+		{"post not found", domain.ErrPostNotFound, http.StatusNotFound, "post_not_found", "post not found"},
+		{"wrapped post not found", fmt.Errorf("failed to get post: %w", domain.ErrPostNotFound), http.StatusNotFound, "post_not_found", "post not found"},
+		{"post locked", domain.ErrPostLocked, http.StatusLocked, "post_locked", "post is locked"},
+		{"report not found", domain.ErrReportNotFound, http.StatusNotFound, "report_not_found", "report not found"},
+		{"already reported", domain.ErrAlreadyReported, http.StatusConflict, "already_reported", "content already reported by this user"},
+		{"invalid reaction", domain.ErrInvalidReaction, http.StatusBadRequest, "invalid_reaction", "unsupported reaction"},
+		{"message not found", domain.ErrMessageNotFound, http.StatusNotFound, "message_not_found", "message not found"},
+		{"reply target not found", domain.ErrReplyTargetNotFound, http.StatusNotFound, "reply_target_not_found", "message being replied to not found"},
+		{"not message author", domain.ErrNotMessageAuthor, http.StatusForbidden, "not_message_author", "only the author can change this message"},
+		{"edit window expired", domain.ErrEditWindowExpired, http.StatusConflict, "edit_window_expired", "message can no longer be edited"},
+		{"attachment not found", domain.ErrAttachmentNotFound, http.StatusNotFound, "attachment_not_found", "attachment not found"},
+		{"upload too large", domain.ErrUploadTooLarge, http.StatusRequestEntityTooLarge, "upload_too_large", "upload too large"},
+		{"unsupported file type", domain.ErrUnsupportedFileType, http.StatusUnsupportedMediaType, "unsupported_file_type", "unsupported file type"},
+		{"invalid download link", domain.ErrInvalidDownloadLink, http.StatusForbidden, "invalid_download_link", "download link is invalid or expired"},
+		{"content rejected", &domain.ContentRejectedError{Reasons: []string{"blocked_word"}}, http.StatusUnprocessableEntity, "content_rejected", "content was rejected by the spam filter"},
+		{"api error passes through", errPostNotFound, http.StatusNotFound, "post_not_found", "post not found"},
+		{"internal error hides details", errors.New("pq: connection refused"), http.StatusInternalServerError, "internal_error", "internal server error"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			body := serveFail(t, tt.err)
+
+			if body.Status != tt.wantStatus {
+				t.Errorf("status = %d, want %d", body.Status, tt.wantStatus)
+			}
+			if body.Code != tt.wantCode {
+				t.Errorf("code = %q, want %q", body.Code, tt.wantCode)
+			}
+			if body.Detail != tt.wantDetail {
+				t.Errorf("detail = %q, want %q", body.Detail, tt.wantDetail)
+			}
+			if body.Type != "urn:forum-app:problem:"+tt.wantCode {
+				t.Errorf("type = %q, want code %q in it", body.Type, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestFailContentRejectedReasons(t *testing.T) {
+	gin.SetMode(gin.TestMode)
+
+	body := serveFail(t, fmt.Errorf("create post: %w", &domain.ContentRejectedError{Reasons: []string{"blocked_word", "too_many_links"}}))
+
+	want := []interface{}{"blocked_word", "too_many_links"}
+	got, ok := body.Extra["reasons"].([]interface{})
+	if !ok || len(got) != len(want) || got[0] != want[0] || got[1] != want[1] {
+		t.Errorf("reasons = %v, want %v", body.Extra["reasons"], want)
+	}
+}
+
+// problemBody — разобранный ответ problem+json.
+type problemBody struct {
+	Type   string `json:"type"`
+	Status int    `json:"status"`
+	Code   string `json:"code"`
+	Detail string `json:"detail"`
+	Extra  map[string]interface{}
+}
+
+func serveFail(t *testing.T, err error) problemBody {
+	t.Helper()
+
+	rec := httptest.NewRecorder()
+	c, _ := gin.CreateTestContext(rec)
+	c.Request = httptest.NewRequest(http.MethodGet, "/api/test", nil)
+	fail(c, err)
+
+	if got := rec.Header().Get("Content-Type"); got != problem.ContentType {
+		t.Errorf("Content-Type = %q, want %q", got, problem.ContentType)
+	}
+
+	var body problemBody
+	if err := json.Unmarshal(rec.Body.Bytes(), &body); err != nil {
+		t.Fatalf("failed to decode body %q: %v", rec.Body.String(), err)
+	}
+	if err := json.Unmarshal(rec.Body.Bytes(), &body.Extra); err != nil {
+		t.Fatalf("failed to decode body %q: %v", rec.Body.String(), err)
+	}
+	if rec.Code != body.Status {
+		t.Errorf("HTTP status %d differs from body status %d", rec.Code, body.Status)
+	}
+	return body
+}
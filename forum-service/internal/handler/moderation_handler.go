@@ -0,0 +1,132 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/authclient"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type ModerationHandler struct {
+	pool      *websocket.Pool
+	sanctions *authclient.SanctionCache
+	notifier  notifications.Notifier
+	auditor   audit.Recorder
+	logger    zerolog.Logger
+}
+
+func NewModerationHandler(pool *websocket.Pool, sanctions *authclient.SanctionCache, notifier notifications.Notifier, auditor audit.Recorder) *ModerationHandler {
+	return &ModerationHandler{
+		pool:      pool,
+		sanctions: sanctions,
+		notifier:  notifier,
+		auditor:   auditor,
+		logger:    log.With().Str("component", "moderation_handler").Logger(),
+	}
+}
+
+// maxSlowMode — самый длинный интервал медленного режима.
+const maxSlowMode = time.Hour
+
+// GetSlowMode возвращает текущий интервал медленного режима чата.
+func (h *ModerationHandler) GetSlowMode(c *gin.Context) {
+	c.JSON(http.StatusOK, gin.H{"seconds": int64(h.pool.SlowMode().Seconds())})
+}
+
+// SetSlowMode включает медленный режим чата на всех экземплярах: обычные
+// пользователи могут отправлять одно сообщение в seconds секунд; 0 выключает.
+func (h *ModerationHandler) SetSlowMode(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "SetSlowMode").Logger()
+
+	var req struct {
+		Seconds *int64 `json:"seconds" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		badRequest(c, err)
+		return
+	}
+	interval := time.Duration(*req.Seconds) * time.Second
+	if interval < 0 || interval > maxSlowMode {
+		problem.Abort(c, errInvalidSlowMode)
+		return
+	}
+
+	moderatorID := c.GetInt64("userID")
+	if err := h.pool.SetSlowMode(c.Request.Context(), interval); err != nil {
+		logger.Error().Err(err).Msg("Failed to set slow mode")
+		fail(c, err)
+		return
+	}
+
+	if err := h.auditor.Record(c.Request.Context(), &audit.Event{
+		ActorID:    moderatorID,
+		Action:     audit.ActionChatSlowMode,
+		TargetType: "chat",
+		Metadata:   map[string]interface{}{"seconds": *req.Seconds},
+	}); err != nil {
+		logger.Error().Err(err).Msg("Failed to record audit event")
+	}
+
+	logger.Info().
+		Int64("moderator_id", moderatorID).
+		Dur("interval", interval).
+		Msg("Slow mode set")
+	c.JSON(http.StatusOK, gin.H{"seconds": *req.Seconds})
+}
+
+// SanctionNotify принимает от auth-service выданную или отозванную санкцию
+// и сразу применяет её к WebSocket-подключениям пользователя и к его
+// следующим запросам.
+func (h *ModerationHandler) SanctionNotify(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "SanctionNotify").Logger()
+
+	var sanction domain.Sanction
+	if err := c.ShouldBindJSON(&sanction); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		badRequest(c, err)
+		return
+	}
+	if sanction.UserID <= 0 {
+		problem.Abort(c, errUserIDRequired)
+		return
+	}
+
+	h.sanctions.Invalidate(sanction.UserID)
+	h.pool.ApplySanction(sanction)
+
+	if err := h.notifier.Notify(c.Request.Context(), &notifications.Notification{
+		UserID:     sanction.UserID,
+		Kind:       notifications.KindModeration,
+		ActorID:    sanction.ActorID,
+		TargetType: "sanction",
+		TargetID:   sanction.ID,
+		Text:       describeSanction(sanction),
+	}); err != nil {
+		logger.Error().Err(err).Int64("sanction_id", sanction.ID).Msg("Failed to notify sanctioned user")
+	}
+
+	c.JSON(http.StatusOK, gin.H{"message": "sanction applied"})
+}
+
+func describeSanction(sanction domain.Sanction) string {
+	kind := strings.ReplaceAll(sanction.Type, "_", " ")
+	if sanction.RevokedAt != "" {
+		return fmt.Sprintf("Your %s was lifted by a moderator", kind)
+	}
+	text := fmt.Sprintf("You received a %s: %s", kind, sanction.Reason)
+	if sanction.ExpiresAt != "" {
+		text += " (until " + sanction.ExpiresAt + ")"
+	}
+	return text
+}
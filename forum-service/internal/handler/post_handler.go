@@ -1,9 +1,11 @@
 package handler
 
 import (
+	"errors"
 	"net/http"
 	"strconv"
 
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
 	"github.com/gin-gonic/gin"
@@ -13,83 +15,94 @@ import (
 
 type PostHandler struct {
 	service service.PostService
+	uploads service.UploadService
 	logger  zerolog.Logger
 }
 
-func NewPostHandler(service service.PostService) *PostHandler {
+func NewPostHandler(service service.PostService, uploads service.UploadService) *PostHandler {
 	return &PostHandler{
 		service: service,
+		uploads: uploads,
 		logger:  log.With().Str("component", "post_handler").Logger(),
 	}
 }
 
 func (h *PostHandler) CreatePost(c *gin.Context) {
-	logger := h.logger.With().Str("method", "CreatePost").Logger()
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "CreatePost").Logger()
 
 	// Получаем ID автора из JWT
 	authorID, exists := c.Get("userID")
 	if !exists {
 		logger.Warn().Msg("Unauthorized attempt to create post")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		problem.Abort(c, problem.ErrUnauthorized)
 		return
 	}
 
 	var post domain.Post
 	if err := c.ShouldBindJSON(&post); err != nil {
 		logger.Warn().Err(err).Msg("Invalid request format")
-		c.JSON(http.StatusBadRequest, gin.H{"error": err.Error()})
+		badRequest(c, err)
 		return
 	}
 
 	post.AuthorID = authorID.(int64)
+	post.Author = c.GetString("username")
 	logger = logger.With().Int64("author_id", post.AuthorID).Str("title", post.Title).Logger()
 
 	createdPost, err := h.service.CreatePost(c.Request.Context(), &post)
+	if errors.Is(err, domain.ErrAttachmentNotFound) {
+		logger.Warn().Err(err).Msg("Invalid attachments")
+		problem.Abort(c, errAttachmentsInvalid)
+		return
+	}
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to create post")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 
+	h.uploads.SignURLs(createdPost.Attachments...)
+
 	logger.Info().Int64("post_id", createdPost.ID).Msg("Post created successfully")
 	c.JSON(http.StatusCreated, createdPost)
 }
 
 func (h *PostHandler) GetPost(c *gin.Context) {
-	logger := h.logger.With().Str("method", "GetPost").Logger()
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "GetPost").Logger()
 
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		logger.Warn().Err(err).Str("post_id_param", c.Param("id")).Msg("Invalid post ID format")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		problem.Abort(c, errInvalidPostID)
 		return
 	}
 
 	logger = logger.With().Int64("post_id", id).Logger()
-	post, err := h.service.GetPost(c.Request.Context(), id)
+	post, err := h.service.GetPost(c.Request.Context(), id, c.GetInt64("userID"))
+	if errors.Is(err, domain.ErrPostNotFound) {
+		logger.Debug().Msg("Post not found")
+		fail(c, err)
+		return
+	}
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get post")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 
-	if post == nil {
-		logger.Debug().Msg("Post not found")
-		c.JSON(http.StatusNotFound, gin.H{"error": "post not found"})
-		return
-	}
+	h.uploads.SignURLs(post.Attachments...)
 
 	logger.Debug().Msg("Post retrieved successfully")
 	c.JSON(http.StatusOK, post)
 }
 
 func (h *PostHandler) GetAllPosts(c *gin.Context) {
-	logger := h.logger.With().Str("method", "GetAllPosts").Logger()
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "GetAllPosts").Logger()
 
-	posts, err := h.service.GetAllPosts(c.Request.Context())
+	posts, err := h.service.GetAllPosts(c.Request.Context(), c.GetInt64("userID"))
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get all posts")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 
@@ -98,30 +111,81 @@ func (h *PostHandler) GetAllPosts(c *gin.Context) {
 }
 
 func (h *PostHandler) DeletePost(c *gin.Context) {
-	logger := h.logger.With().Str("method", "DeletePost").Logger()
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "DeletePost").Logger()
 
 	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
 	if err != nil {
 		logger.Warn().Err(err).Str("post_id_param", c.Param("id")).Msg("Invalid post ID format")
-		c.JSON(http.StatusBadRequest, gin.H{"error": "invalid post ID"})
+		problem.Abort(c, errInvalidPostID)
 		return
 	}
 
 	authorID, exists := c.Get("userID")
 	if !exists {
 		logger.Warn().Msg("Unauthorized attempt to delete post")
-		c.JSON(http.StatusUnauthorized, gin.H{"error": "unauthorized"})
+		problem.Abort(c, problem.ErrUnauthorized)
 		return
 	}
 
 	logger = logger.With().Int64("post_id", id).Int64("author_id", authorID.(int64)).Logger()
 	err = h.service.DeletePost(c.Request.Context(), id, authorID.(int64))
+	if errors.Is(err, domain.ErrPostLocked) {
+		logger.Warn().Msg("Attempt to delete locked post")
+		fail(c, err)
+		return
+	}
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to delete post")
-		c.JSON(http.StatusInternalServerError, gin.H{"error": err.Error()})
+		fail(c, err)
 		return
 	}
 
 	logger.Info().Msg("Post deleted successfully")
 	c.JSON(http.StatusOK, gin.H{"message": "post deleted successfully"})
 }
+
+func (h *PostHandler) GetAnnouncements(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "GetAnnouncements").Logger()
+
+	posts, err := h.service.GetAnnouncements(c.Request.Context())
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get announcements")
+		fail(c, err)
+		return
+	}
+
+	logger.Debug().Int("post_count", len(posts)).Msg("Retrieved announcements")
+	c.JSON(http.StatusOK, posts)
+}
+
+// UpdateFlags закрепляет, закрывает или делает объявлением пост (только модераторы).
+func (h *PostHandler) UpdateFlags(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "UpdateFlags").Logger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Str("post_id_param", c.Param("id")).Msg("Invalid post ID format")
+		problem.Abort(c, errInvalidPostID)
+		return
+	}
+
+	var update domain.PostFlagsUpdate
+	if err := c.ShouldBindJSON(&update); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		badRequest(c, err)
+		return
+	}
+
+	moderatorID := c.GetInt64("userID")
+	logger = logger.With().Int64("post_id", id).Int64("moderator_id", moderatorID).Logger()
+
+	post, err := h.service.UpdateFlags(c.Request.Context(), id, update, moderatorID)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to update post flags")
+		fail(c, err)
+		return
+	}
+
+	logger.Info().Msg("Post flags updated")
+	c.JSON(http.StatusOK, post)
+}
@@ -0,0 +1,75 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/websocket"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type ReactionHandler struct {
+	service service.ReactionService
+	pool    *websocket.Pool
+	logger  zerolog.Logger
+}
+
+func NewReactionHandler(service service.ReactionService, pool *websocket.Pool) *ReactionHandler {
+	return &ReactionHandler{
+		service: service,
+		pool:    pool,
+		logger:  log.With().Str("component", "reaction_handler").Logger(),
+	}
+}
+
+// TogglePostReaction ставит или снимает реакцию текущего пользователя на пост
+// и рассылает новые итоги подключённым клиентам.
+func (h *ReactionHandler) TogglePostReaction(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "TogglePostReaction").Logger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Str("post_id_param", c.Param("id")).Msg("Invalid post ID format")
+		problem.Abort(c, errInvalidPostID)
+		return
+	}
+
+	userID, exists := c.Get("userID")
+	if !exists {
+		logger.Warn().Msg("Unauthorized attempt to react to post")
+		problem.Abort(c, problem.ErrUnauthorized)
+		return
+	}
+
+	var req struct {
+		Emoji string `json:"emoji" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		badRequest(c, err)
+		return
+	}
+
+	logger = logger.With().Int64("post_id", id).Int64("user_id", userID.(int64)).Logger()
+	update, err := h.service.ToggleReaction(c.Request.Context(), domain.ReactionTargetPost, id, userID.(int64), req.Emoji)
+	switch {
+	case errors.Is(err, domain.ErrInvalidReaction), errors.Is(err, domain.ErrPostNotFound):
+		fail(c, err)
+		return
+	case err != nil:
+		logger.Error().Err(err).Msg("Failed to toggle reaction")
+		fail(c, err)
+		return
+	}
+
+	h.pool.BroadcastReaction(update)
+
+	logger.Debug().Bool("added", update.Added).Msg("Post reaction toggled")
+	c.JSON(http.StatusOK, update)
+}
@@ -0,0 +1,140 @@
+package handler
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type ReportHandler struct {
+	service service.ReportService
+	logger  zerolog.Logger
+}
+
+func NewReportHandler(service service.ReportService) *ReportHandler {
+	return &ReportHandler{
+		service: service,
+		logger:  log.With().Str("component", "report_handler").Logger(),
+	}
+}
+
+func (h *ReportHandler) ReportPost(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "ReportPost").Logger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Str("post_id_param", c.Param("id")).Msg("Invalid post ID format")
+		problem.Abort(c, errInvalidPostID)
+		return
+	}
+
+	reporterID, exists := c.Get("userID")
+	if !exists {
+		logger.Warn().Msg("Unauthorized attempt to report post")
+		problem.Abort(c, problem.ErrUnauthorized)
+		return
+	}
+
+	var req struct {
+		Reason  string `json:"reason" binding:"required"`
+		Details string `json:"details"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		badRequest(c, err)
+		return
+	}
+
+	logger = logger.With().Int64("post_id", id).Int64("reporter_id", reporterID.(int64)).Logger()
+	report, err := h.service.CreateReport(c.Request.Context(), &domain.Report{
+		TargetType: domain.ReportTargetPost,
+		TargetID:   id,
+		ReporterID: reporterID.(int64),
+		Reason:     req.Reason,
+		Details:    req.Details,
+	})
+	switch {
+	case errors.Is(err, domain.ErrAlreadyReported):
+		logger.Debug().Msg("Post already reported by user")
+		fail(c, err)
+		return
+	case errors.Is(err, domain.ErrTargetNotFound):
+		problem.Abort(c, errPostNotFound)
+		return
+	case err != nil:
+		logger.Warn().Err(err).Msg("Failed to report post")
+		fail(c, err)
+		return
+	}
+
+	logger.Info().Int64("report_id", report.ID).Msg("Post reported")
+	c.JSON(http.StatusCreated, report)
+}
+
+func (h *ReportHandler) ListReports(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "ListReports").Logger()
+
+	limit, _ := strconv.Atoi(c.Query("limit"))
+	offset, _ := strconv.Atoi(c.Query("offset"))
+	filter := domain.ReportFilter{
+		Status:     c.DefaultQuery("status", domain.ReportStatusOpen),
+		TargetType: c.Query("target_type"),
+		Reason:     c.Query("reason"),
+		Limit:      limit,
+		Offset:     offset,
+	}
+
+	reports, err := h.service.ListReports(c.Request.Context(), filter)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to list reports")
+		fail(c, err)
+		return
+	}
+
+	logger.Debug().Int("report_count", len(reports)).Msg("Retrieved reports")
+	c.JSON(http.StatusOK, reports)
+}
+
+func (h *ReportHandler) ResolveReport(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "ResolveReport").Logger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		logger.Warn().Err(err).Str("report_id_param", c.Param("id")).Msg("Invalid report ID format")
+		problem.Abort(c, errInvalidReportID)
+		return
+	}
+
+	var req struct {
+		Status string `json:"status" binding:"required"`
+	}
+	if err := c.ShouldBindJSON(&req); err != nil {
+		logger.Warn().Err(err).Msg("Invalid request format")
+		badRequest(c, err)
+		return
+	}
+
+	moderatorID := c.GetInt64("userID")
+	logger = logger.With().Int64("report_id", id).Int64("moderator_id", moderatorID).Logger()
+
+	report, err := h.service.ResolveReport(c.Request.Context(), id, req.Status, moderatorID)
+	switch {
+	case errors.Is(err, domain.ErrReportNotFound):
+		fail(c, err)
+		return
+	case err != nil:
+		logger.Warn().Err(err).Msg("Failed to resolve report")
+		fail(c, err)
+		return
+	}
+
+	logger.Info().Str("status", report.Status).Msg("Report resolved")
+	c.JSON(http.StatusOK, report)
+}
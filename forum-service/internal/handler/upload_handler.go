@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// multipartOverhead — запас на заголовки multipart поверх максимального размера файла.
+const multipartOverhead = 64 << 10
+
+type UploadHandler struct {
+	service  service.UploadService
+	maxBytes int64
+	logger   zerolog.Logger
+}
+
+func NewUploadHandler(service service.UploadService, maxBytes int64) *UploadHandler {
+	return &UploadHandler{
+		service:  service,
+		maxBytes: maxBytes,
+		logger:   log.With().Str("component", "upload_handler").Logger(),
+	}
+}
+
+// Upload принимает multipart/form-data с файлом в поле "file".
+func (h *UploadHandler) Upload(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Upload").Logger()
+
+	uploaderID, exists := c.Get("userID")
+	if !exists {
+		logger.Warn().Msg("Unauthorized upload attempt")
+		problem.Abort(c, problem.ErrUnauthorized)
+		return
+	}
+	logger = logger.With().Int64("uploader_id", uploaderID.(int64)).Logger()
+
+	c.Request.Body = http.MaxBytesReader(c.Writer, c.Request.Body, h.maxBytes+multipartOverhead)
+	header, err := c.FormFile("file")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			problem.Abort(c, errUploadTooLarge)
+			return
+		}
+		logger.Warn().Err(err).Msg("Missing file in upload")
+		problem.Abort(c, errFileRequired)
+		return
+	}
+	if header.Size > h.maxBytes {
+		problem.Abort(c, errUploadTooLarge)
+		return
+	}
+
+	file, err := header.Open()
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to open uploaded file")
+		fail(c, err)
+		return
+	}
+	defer file.Close()
+
+	attachment, err := h.service.Upload(c.Request.Context(), uploaderID.(int64), header.Filename, file)
+	switch {
+	case errors.Is(err, domain.ErrUploadTooLarge), errors.Is(err, domain.ErrUnsupportedFileType):
+		fail(c, err)
+		return
+	case err != nil:
+		logger.Error().Err(err).Msg("Failed to store upload")
+		fail(c, err)
+		return
+	}
+
+	logger.Info().Int64("attachment_id", attachment.ID).Msg("File uploaded")
+	c.JSON(http.StatusCreated, attachment)
+}
+
+// Download отдаёт файл по подписанной ссылке, выданной вместе с постом или загрузкой.
+func (h *UploadHandler) Download(c *gin.Context) {
+	logger := h.logger.With().Ctx(c.Request.Context()).Str("method", "Download").Logger()
+
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Abort(c, errInvalidUploadID)
+		return
+	}
+	expires, err := strconv.ParseInt(c.Query("expires"), 10, 64)
+	if err != nil {
+		problem.Abort(c, errInvalidDownloadLink)
+		return
+	}
+	variant := c.DefaultQuery("variant", domain.AttachmentVariantOriginal)
+
+	body, attachment, err := h.service.Open(c.Request.Context(), id, variant, expires, c.Query("sig"))
+	switch {
+	case errors.Is(err, domain.ErrInvalidDownloadLink), errors.Is(err, domain.ErrAttachmentNotFound):
+		fail(c, err)
+		return
+	case err != nil:
+		logger.Error().Err(err).Int64("attachment_id", id).Msg("Failed to open upload")
+		fail(c, err)
+		return
+	}
+	defer body.Close()
+
+	// Картинки показываем в браузере, всё остальное только скачиваем.
+	disposition := "attachment"
+	if attachment.ContentType != "application/pdf" && attachment.ContentType != "text/plain" {
+		disposition = "inline"
+	}
+	c.Header("Content-Type", attachment.ContentType)
+	c.Header("Content-Disposition", mime.FormatMediaType(disposition, map[string]string{"filename": attachment.Filename}))
+	c.Header("X-Content-Type-Options", "nosniff")
+	c.Header("Cache-Control", "private, max-age=300")
+	c.Status(http.StatusOK)
+
+	if _, err := io.Copy(c.Writer, body); err != nil {
+		logger.Warn().Err(err).Int64("attachment_id", id).Msg("Failed to stream upload")
+	}
+}
@@ -3,6 +3,10 @@ package migrations
 import (
 	"database/sql"
 	"fmt"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/ratelimit"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
 )
 
 func MigrateDB(db *sql.DB) error {
@@ -40,10 +44,120 @@ func MigrateDB(db *sql.DB) error {
             created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
             is_deleted BOOLEAN DEFAULT FALSE
         );
+
+        ALTER TABLE posts ADD COLUMN IF NOT EXISTS is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+        ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+        CREATE TABLE IF NOT EXISTS reports (
+            id SERIAL PRIMARY KEY,
+            target_type TEXT NOT NULL,
+            target_id BIGINT NOT NULL,
+            reporter_id BIGINT NOT NULL,
+            reason TEXT NOT NULL,
+            details TEXT NOT NULL DEFAULT '',
+            status TEXT NOT NULL DEFAULT 'open',
+            resolved_by BIGINT,
+            resolved_at TIMESTAMP,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE (target_type, target_id, reporter_id)
+        );
+        CREATE INDEX IF NOT EXISTS idx_reports_status ON reports (status, created_at);
+
+        ALTER TABLE posts ADD COLUMN IF NOT EXISTS pinned BOOLEAN NOT NULL DEFAULT FALSE;
+        ALTER TABLE posts ADD COLUMN IF NOT EXISTS locked BOOLEAN NOT NULL DEFAULT FALSE;
+        ALTER TABLE posts ADD COLUMN IF NOT EXISTS announcement BOOLEAN NOT NULL DEFAULT FALSE;
+
+        CREATE TABLE IF NOT EXISTS comments (
+            id SERIAL PRIMARY KEY,
+            post_id BIGINT NOT NULL REFERENCES posts(id) ON DELETE CASCADE,
+            author_id BIGINT NOT NULL,
+            author TEXT NOT NULL DEFAULT '',
+            content TEXT NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        );
+        CREATE INDEX IF NOT EXISTS idx_comments_post ON comments (post_id, created_at);
+
+        ALTER TABLE posts ADD COLUMN IF NOT EXISTS content_html TEXT NOT NULL DEFAULT '';
+        ALTER TABLE posts ADD COLUMN IF NOT EXISTS render_version INT NOT NULL DEFAULT 0;
+        ALTER TABLE comments ADD COLUMN IF NOT EXISTS content_html TEXT NOT NULL DEFAULT '';
+        ALTER TABLE comments ADD COLUMN IF NOT EXISTS render_version INT NOT NULL DEFAULT 0;
+
+        CREATE TABLE IF NOT EXISTS attachments (
+            id SERIAL PRIMARY KEY,
+            post_id INT REFERENCES posts(id) ON DELETE CASCADE,
+            uploader_id BIGINT NOT NULL,
+            filename VARCHAR(255) NOT NULL,
+            content_type VARCHAR(100) NOT NULL,
+            size_bytes BIGINT NOT NULL,
+            width INT NOT NULL DEFAULT 0,
+            height INT NOT NULL DEFAULT 0,
+            blob_key TEXT NOT NULL,
+            thumbnail_key TEXT NOT NULL DEFAULT '',
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        );
+        CREATE INDEX IF NOT EXISTS idx_attachments_post ON attachments (post_id);
+
+        ALTER TABLE messages ADD COLUMN IF NOT EXISTS is_deleted BOOLEAN DEFAULT FALSE;
+        ALTER TABLE messages ADD COLUMN IF NOT EXISTS edited_at TIMESTAMP;
+        ALTER TABLE messages ADD COLUMN IF NOT EXISTS deleted_by BIGINT;
+
+        CREATE TABLE IF NOT EXISTS reactions (
+            target_type TEXT NOT NULL,
+            target_id BIGINT NOT NULL,
+            user_id BIGINT NOT NULL,
+            emoji TEXT NOT NULL,
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            PRIMARY KEY (target_type, target_id, user_id, emoji)
+        );
+
+        ALTER TABLE messages ADD COLUMN IF NOT EXISTS reply_to BIGINT REFERENCES messages(id) ON DELETE SET NULL;
+
+        CREATE TABLE IF NOT EXISTS mentions (
+            id SERIAL PRIMARY KEY,
+            source_type TEXT NOT NULL,
+            source_id BIGINT NOT NULL,
+            post_id BIGINT,
+            mentioned_user_id BIGINT NOT NULL,
+            author_id BIGINT NOT NULL,
+            author TEXT NOT NULL DEFAULT '',
+            excerpt TEXT NOT NULL DEFAULT '',
+            created_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP,
+            UNIQUE (source_type, source_id, mentioned_user_id)
+        );
+
+        DROP TABLE IF EXISTS pool_event_payloads;
+
+        CREATE TABLE IF NOT EXISTS chat_settings (
+            key TEXT PRIMARY KEY,
+            value TEXT NOT NULL,
+            updated_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+        );
+
+        ALTER TABLE comments ADD COLUMN IF NOT EXISTS is_hidden BOOLEAN NOT NULL DEFAULT FALSE;
+
+        CREATE INDEX IF NOT EXISTS idx_posts_author_created ON posts (author_id, created_at);
+        CREATE INDEX IF NOT EXISTS idx_messages_user_created ON messages (user_id, created_at);
+        CREATE INDEX IF NOT EXISTS idx_comments_author_created ON comments (author_id, created_at);
     `)
 	if err != nil {
 		return fmt.Errorf("failed to create tables: %w", err)
 	}
 
+	if err := audit.Migrate(db); err != nil {
+		return err
+	}
+
+	if err := notifications.Migrate(db); err != nil {
+		return err
+	}
+
+	if err := ratelimit.Migrate(db); err != nil {
+		return err
+	}
+
+	if err := recordVersion(db); err != nil {
+		return err
+	}
+
 	return nil
 }
@@ -0,0 +1,49 @@
+package migrations
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/health"
+)
+
+// SchemaVersion — версия схемы, которую создаёт MigrateDB. Увеличивается при
+// каждом изменении схемы; /readyz сверяет её с версией, записанной в базе.
+const SchemaVersion = 6
+
+func recordVersion(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS schema_migrations (
+			version INTEGER PRIMARY KEY,
+			applied_at TIMESTAMP DEFAULT CURRENT_TIMESTAMP
+		)
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create schema_migrations table: %w", err)
+	}
+
+	_, err = db.Exec(`INSERT INTO schema_migrations (version) VALUES ($1) ON CONFLICT (version) DO NOTHING`, SchemaVersion)
+	if err != nil {
+		return fmt.Errorf("failed to record schema version: %w", err)
+	}
+	return nil
+}
+
+// CheckVersion сверяет версию схемы в базе с SchemaVersion. Более новая схема
+// (её уже обновил следующий релиз) не делает сервис неготовым.
+func CheckVersion(ctx context.Context, db *sql.DB) error {
+	var version sql.NullInt64
+	err := db.QueryRowContext(ctx, `SELECT MAX(version) FROM schema_migrations`).Scan(&version)
+	if err != nil {
+		return fmt.Errorf("failed to read schema version: %w", err)
+	}
+
+	switch {
+	case !version.Valid || version.Int64 < SchemaVersion:
+		return fmt.Errorf("schema version %d, expected %d", version.Int64, SchemaVersion)
+	case version.Int64 > SchemaVersion:
+		return health.Degraded(fmt.Errorf("schema version %d is newer than expected %d", version.Int64, SchemaVersion))
+	}
+	return nil
+}
@@ -0,0 +1,95 @@
+package notifications
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/gin-gonic/gin"
+)
+
+var (
+	errInvalidLimit          = problem.ErrInvalidParam.WithDetail("invalid limit")
+	errInvalidNotificationID = problem.ErrInvalidParam.WithDetail("invalid notification ID")
+	errInvalidCursor         = problem.New(http.StatusBadRequest, "invalid_cursor", ErrInvalidCursor.Error())
+	errNotificationNotFound  = problem.New(http.StatusNotFound, "notification_not_found", ErrNotificationNotFound.Error())
+)
+
+// Handler отдаёт входящие текущего пользователя (userID из AuthMiddleware).
+type Handler struct {
+	service *Service
+}
+
+func NewHandler(service *Service) *Handler {
+	return &Handler{service: service}
+}
+
+// List отдаёт страницу уведомлений; query-параметры: unread (true — только
+// непрочитанные), cursor, limit.
+func (h *Handler) List(c *gin.Context) {
+	filter := Filter{
+		UserID:     c.GetInt64("userID"),
+		UnreadOnly: c.Query("unread") == "true",
+		Cursor:     c.Query("cursor"),
+	}
+	if v := c.Query("limit"); v != "" {
+		var err error
+		if filter.Limit, err = strconv.Atoi(v); err != nil {
+			problem.Abort(c, errInvalidLimit)
+			return
+		}
+	}
+
+	page, err := h.service.List(c.Request.Context(), filter)
+	if err != nil {
+		if errors.Is(err, ErrInvalidCursor) {
+			problem.Abort(c, errInvalidCursor)
+			return
+		}
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, page)
+}
+
+func (h *Handler) UnreadCount(c *gin.Context) {
+	count, err := h.service.UnreadCount(c.Request.Context(), c.GetInt64("userID"))
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"unread_count": count})
+}
+
+func (h *Handler) MarkRead(c *gin.Context) {
+	id, err := strconv.ParseInt(c.Param("id"), 10, 64)
+	if err != nil {
+		problem.Abort(c, errInvalidNotificationID)
+		return
+	}
+
+	err = h.service.MarkRead(c.Request.Context(), c.GetInt64("userID"), id)
+	switch {
+	case errors.Is(err, ErrNotificationNotFound):
+		problem.Abort(c, errNotificationNotFound)
+		return
+	case err != nil:
+		problem.Abort(c, err)
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+func (h *Handler) MarkAllRead(c *gin.Context) {
+	marked, err := h.service.MarkAllRead(c.Request.Context(), c.GetInt64("userID"))
+	if err != nil {
+		problem.Abort(c, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{"marked": marked})
+}
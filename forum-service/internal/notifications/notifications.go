@@ -0,0 +1,64 @@
+// Package notifications ведёт входящие уведомления пользователей форума:
+// ответы на их посты и сообщения, упоминания, действия модераторов.
+// Новые уведомления сразу отдаются в Deliveries для доставки по WebSocket;
+// не доставленные вживую ждут следующего подключения пользователя.
+// Личных сообщений на форуме нет, поэтому и уведомлений о них тоже: вид
+// для них появится вместе с самими личными сообщениями.
+package notifications
+
+import (
+	"context"
+	"errors"
+)
+
+const (
+	KindPostReply  = "post_reply" // комментарий к посту пользователя
+	KindChatReply  = "chat_reply" // ответ на сообщение пользователя в чате
+	KindMention    = "mention"    // @упоминание
+	KindModeration = "moderation" // действие модератора с контентом или аккаунтом пользователя
+)
+
+var (
+	ErrInvalidCursor        = errors.New("invalid cursor")
+	ErrNotificationNotFound = errors.New("notification not found")
+)
+
+type Notification struct {
+	ID         int64  `json:"id"`
+	UserID     int64  `json:"user_id"`
+	Kind       string `json:"kind"`
+	ActorID    int64  `json:"actor_id,omitempty"`
+	Actor      string `json:"actor,omitempty"`
+	TargetType string `json:"target_type,omitempty"`
+	TargetID   int64  `json:"target_id,omitempty"`
+	PostID     int64  `json:"post_id,omitempty"`
+	Text       string `json:"text"`
+	Read       bool   `json:"read"`
+	CreatedAt  string `json:"created_at"`
+}
+
+// Filter задаёт страницу входящих пользователя. Cursor — значение NextCursor
+// с предыдущей страницы.
+type Filter struct {
+	UserID     int64
+	UnreadOnly bool
+	Cursor     string
+	Limit      int
+}
+
+type Page struct {
+	Notifications []*Notification `json:"notifications"`
+	NextCursor    string          `json:"next_cursor,omitempty"`
+	UnreadCount   int             `json:"unread_count"`
+}
+
+// Notifier создаёт уведомление. Уведомления самому себе (UserID == ActorID)
+// молча отбрасываются.
+type Notifier interface {
+	Notify(ctx context.Context, notification *Notification) error
+}
+
+// NopNotifier ничего не создаёт; удобен там, где уведомления не нужны.
+type NopNotifier struct{}
+
+func (NopNotifier) Notify(context.Context, *Notification) error { return nil }
@@ -0,0 +1,73 @@
+package notifications
+
+import (
+	"context"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// deliveryQueueSize — сколько новых уведомлений может ждать живой доставки.
+const deliveryQueueSize = 256
+
+// Service создаёт уведомления и передаёт их на живую доставку.
+type Service struct {
+	store      *Store
+	deliveries chan *Notification
+	logger     zerolog.Logger
+}
+
+func NewService(store *Store) *Service {
+	return &Service{
+		store:      store,
+		deliveries: make(chan *Notification, deliveryQueueSize),
+		logger:     log.With().Str("component", "notification_service").Logger(),
+	}
+}
+
+func (s *Service) Notify(ctx context.Context, n *Notification) error {
+	if n.UserID == 0 || n.UserID == n.ActorID {
+		return nil
+	}
+
+	if err := s.store.Create(ctx, n); err != nil {
+		return err
+	}
+
+	// Если очередь переполнена, уведомление уйдёт при следующем подключении.
+	select {
+	case s.deliveries <- n:
+	default:
+		s.logger.Warn().Ctx(ctx).Int64("notification_id", n.ID).Msg("Delivery queue full, notification left pending")
+	}
+	return nil
+}
+
+// Deliveries отдаёт только что созданные уведомления для доставки по WebSocket.
+func (s *Service) Deliveries() <-chan *Notification {
+	return s.deliveries
+}
+
+func (s *Service) Pending(ctx context.Context, userID int64) ([]*Notification, error) {
+	return s.store.Pending(ctx, userID)
+}
+
+func (s *Service) MarkDelivered(ctx context.Context, ids []int64) error {
+	return s.store.MarkDelivered(ctx, ids)
+}
+
+func (s *Service) List(ctx context.Context, filter Filter) (*Page, error) {
+	return s.store.List(ctx, filter)
+}
+
+func (s *Service) UnreadCount(ctx context.Context, userID int64) (int, error) {
+	return s.store.UnreadCount(ctx, userID)
+}
+
+func (s *Service) MarkRead(ctx context.Context, userID, id int64) error {
+	return s.store.MarkRead(ctx, userID, id)
+}
+
+func (s *Service) MarkAllRead(ctx context.Context, userID int64) (int64, error) {
+	return s.store.MarkAllRead(ctx, userID)
+}
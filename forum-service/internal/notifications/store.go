@@ -0,0 +1,274 @@
+package notifications
+
+import (
+	"context"
+	"database/sql"
+	"encoding/base64"
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	defaultPageSize = 20
+	maxPageSize     = 100
+	// maxPending — сколько недоставленных уведомлений отдаётся при подключении за раз.
+	maxPending = 100
+)
+
+// Migrate создаёт таблицу notifications.
+func Migrate(db *sql.DB) error {
+	_, err := db.Exec(`
+		CREATE TABLE IF NOT EXISTS notifications (
+			id BIGSERIAL PRIMARY KEY,
+			user_id BIGINT NOT NULL,
+			kind TEXT NOT NULL,
+			actor_id BIGINT,
+			actor TEXT NOT NULL DEFAULT '',
+			target_type TEXT NOT NULL DEFAULT '',
+			target_id BIGINT,
+			post_id BIGINT,
+			text TEXT NOT NULL DEFAULT '',
+			read_at TIMESTAMP,
+			delivered_at TIMESTAMP,
+			created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+		);
+		CREATE INDEX IF NOT EXISTS idx_notifications_user ON notifications (user_id, id);
+		CREATE INDEX IF NOT EXISTS idx_notifications_unread ON notifications (user_id) WHERE read_at IS NULL;
+		CREATE INDEX IF NOT EXISTS idx_notifications_pending ON notifications (user_id) WHERE delivered_at IS NULL;
+	`)
+	if err != nil {
+		return fmt.Errorf("failed to create notifications table: %w", err)
+	}
+	return nil
+}
+
+// Store хранит уведомления в PostgreSQL.
+type Store struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewStore(db *sql.DB) *Store {
+	return &Store{
+		db:     db,
+		logger: log.With().Str("component", "notification_store").Logger(),
+	}
+}
+
+// Create сохраняет уведомление и заполняет его ID и время создания.
+func (s *Store) Create(ctx context.Context, n *Notification) error {
+	query := `
+		INSERT INTO notifications (user_id, kind, actor_id, actor, target_type, target_id, post_id, text, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	createdAt := time.Now()
+	err := s.db.QueryRowContext(ctx, query,
+		n.UserID, n.Kind, nullID(n.ActorID), n.Actor, n.TargetType,
+		nullID(n.TargetID), nullID(n.PostID), n.Text, createdAt,
+	).Scan(&n.ID)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", n.UserID).Str("kind", n.Kind).Msg("Failed to create notification")
+		return fmt.Errorf("failed to create notification: %w", err)
+	}
+	n.CreatedAt = createdAt.Format(time.RFC3339)
+
+	s.logger.Debug().Ctx(ctx).
+		Int64("notification_id", n.ID).
+		Int64("user_id", n.UserID).
+		Str("kind", n.Kind).
+		Msg("Notification created")
+	return nil
+}
+
+// List возвращает уведомления пользователя от новых к старым. Курсор — ID
+// последнего уведомления предыдущей страницы.
+func (s *Store) List(ctx context.Context, filter Filter) (*Page, error) {
+	if filter.Limit <= 0 {
+		filter.Limit = defaultPageSize
+	} else if filter.Limit > maxPageSize {
+		filter.Limit = maxPageSize
+	}
+
+	var beforeID int64
+	if filter.Cursor != "" {
+		var err error
+		if beforeID, err = decodeCursor(filter.Cursor); err != nil {
+			return nil, err
+		}
+	}
+
+	// Берём на одну запись больше, чтобы понять, есть ли следующая страница.
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1
+		  AND ($2 = 0 OR id < $2)
+		  AND (NOT $3 OR read_at IS NULL)
+		ORDER BY id DESC
+		LIMIT $4
+	`
+
+	notifications, err := s.query(ctx, query, filter.UserID, beforeID, filter.UnreadOnly, filter.Limit+1)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", filter.UserID).Msg("Failed to list notifications")
+		return nil, err
+	}
+
+	page := &Page{Notifications: notifications}
+	if len(page.Notifications) > filter.Limit {
+		page.Notifications = page.Notifications[:filter.Limit]
+		page.NextCursor = encodeCursor(page.Notifications[filter.Limit-1].ID)
+	}
+
+	if page.UnreadCount, err = s.UnreadCount(ctx, filter.UserID); err != nil {
+		return nil, err
+	}
+	return page, nil
+}
+
+func (s *Store) UnreadCount(ctx context.Context, userID int64) (int, error) {
+	var count int
+	err := s.db.QueryRowContext(ctx,
+		`SELECT COUNT(*) FROM notifications WHERE user_id = $1 AND read_at IS NULL`, userID,
+	).Scan(&count)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", userID).Msg("Failed to count unread notifications")
+		return 0, fmt.Errorf("failed to count unread notifications: %w", err)
+	}
+	return count, nil
+}
+
+// MarkRead отмечает прочитанным уведомление пользователя. Уведомление чужого
+// пользователя считается несуществующим.
+func (s *Store) MarkRead(ctx context.Context, userID, id int64) error {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notifications
+		SET read_at = COALESCE(read_at, $3)
+		WHERE id = $1 AND user_id = $2
+	`, id, userID, time.Now())
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("notification_id", id).Msg("Failed to mark notification read")
+		return fmt.Errorf("failed to mark notification read: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		return ErrNotificationNotFound
+	}
+	return nil
+}
+
+// MarkAllRead отмечает прочитанными все уведомления пользователя и возвращает их число.
+func (s *Store) MarkAllRead(ctx context.Context, userID int64) (int64, error) {
+	result, err := s.db.ExecContext(ctx, `
+		UPDATE notifications
+		SET read_at = $2
+		WHERE user_id = $1 AND read_at IS NULL
+	`, userID, time.Now())
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", userID).Msg("Failed to mark notifications read")
+		return 0, fmt.Errorf("failed to mark notifications read: %w", err)
+	}
+
+	marked, err := result.RowsAffected()
+	if err != nil {
+		return 0, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	return marked, nil
+}
+
+// Pending возвращает недоставленные уведомления пользователя, старые первыми.
+func (s *Store) Pending(ctx context.Context, userID int64) ([]*Notification, error) {
+	query := `
+		SELECT ` + notificationColumns + `
+		FROM notifications
+		WHERE user_id = $1 AND delivered_at IS NULL
+		ORDER BY id ASC
+		LIMIT $2
+	`
+
+	notifications, err := s.query(ctx, query, userID, maxPending)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("user_id", userID).Msg("Failed to get pending notifications")
+		return nil, err
+	}
+	return notifications, nil
+}
+
+func (s *Store) MarkDelivered(ctx context.Context, ids []int64) error {
+	if len(ids) == 0 {
+		return nil
+	}
+
+	if _, err := s.db.ExecContext(ctx, `
+		UPDATE notifications
+		SET delivered_at = $2
+		WHERE id = ANY($1) AND delivered_at IS NULL
+	`, pq.Array(ids), time.Now()); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int("notification_count", len(ids)).Msg("Failed to mark notifications delivered")
+		return fmt.Errorf("failed to mark notifications delivered: %w", err)
+	}
+	return nil
+}
+
+const notificationColumns = `id, user_id, kind, actor_id, actor, target_type, target_id, post_id, text, read_at IS NOT NULL, created_at`
+
+func (s *Store) query(ctx context.Context, query string, args ...interface{}) ([]*Notification, error) {
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query notifications: %w", err)
+	}
+	defer rows.Close()
+
+	notifications := []*Notification{}
+	for rows.Next() {
+		var n Notification
+		var actorID, targetID, postID sql.NullInt64
+		var createdAt time.Time
+
+		if err := rows.Scan(&n.ID, &n.UserID, &n.Kind, &actorID, &n.Actor, &n.TargetType,
+			&targetID, &postID, &n.Text, &n.Read, &createdAt); err != nil {
+			return nil, fmt.Errorf("failed to scan notification: %w", err)
+		}
+		n.ActorID = actorID.Int64
+		n.TargetID = targetID.Int64
+		n.PostID = postID.Int64
+		n.CreatedAt = createdAt.Format(time.RFC3339)
+		notifications = append(notifications, &n)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return notifications, nil
+}
+
+func nullID(id int64) sql.NullInt64 {
+	return sql.NullInt64{Int64: id, Valid: id != 0}
+}
+
+func encodeCursor(id int64) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(strconv.FormatInt(id, 10)))
+}
+
+func decodeCursor(cursor string) (int64, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return 0, ErrInvalidCursor
+	}
+	id, err := strconv.ParseInt(string(raw), 10, 64)
+	if err != nil || id <= 0 {
+		return 0, ErrInvalidCursor
+	}
+	return id, nil
+}
@@ -0,0 +1,214 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type AttachmentRepository interface {
+	Create(ctx context.Context, attachment *domain.Attachment) (int64, error)
+	GetByID(ctx context.Context, id int64) (*domain.Attachment, error)
+	GetByPost(ctx context.Context, postID int64) ([]*domain.Attachment, error)
+	CountUnlinked(ctx context.Context, ids []int64, uploaderID int64) (int, error)
+	AttachToPost(ctx context.Context, ids []int64, postID, uploaderID int64) error
+}
+
+type AttachmentRepositoryImpl struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewAttachmentRepository(db *sql.DB) AttachmentRepository {
+	return &AttachmentRepositoryImpl{
+		db:     db,
+		logger: log.With().Str("component", "attachment_repository").Logger(),
+	}
+}
+
+func (r *AttachmentRepositoryImpl) Create(ctx context.Context, attachment *domain.Attachment) (int64, error) {
+	logger := r.logger.With().
+		Str("method", "Create").
+		Int64("uploader_id", attachment.UploaderID).
+		Str("content_type", attachment.ContentType).
+		Logger()
+
+	query := `
+		INSERT INTO attachments (uploader_id, filename, content_type, size_bytes, width, height, blob_key, thumbnail_key, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
+		RETURNING id
+	`
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, query,
+		attachment.UploaderID,
+		attachment.Filename,
+		attachment.ContentType,
+		attachment.Size,
+		attachment.Width,
+		attachment.Height,
+		attachment.BlobKey,
+		attachment.ThumbnailKey,
+		time.Now(),
+	).Scan(&id)
+
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to create attachment")
+		return 0, fmt.Errorf("failed to create attachment: %w", err)
+	}
+
+	logger.Info().Int64("attachment_id", id).Msg("Attachment created successfully")
+	return id, nil
+}
+
+func (r *AttachmentRepositoryImpl) GetByID(ctx context.Context, id int64) (*domain.Attachment, error) {
+	logger := r.logger.With().
+		Str("method", "GetByID").
+		Int64("attachment_id", id).
+		Logger()
+
+	query := `
+		SELECT id, post_id, uploader_id, filename, content_type, size_bytes, width, height, blob_key, thumbnail_key, created_at
+		FROM attachments
+		WHERE id = $1
+	`
+
+	attachments, err := r.queryAttachments(ctx, query, id)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get attachment")
+		return nil, err
+	}
+	if len(attachments) == 0 {
+		logger.Debug().Msg("Attachment not found")
+		return nil, nil
+	}
+
+	return attachments[0], nil
+}
+
+func (r *AttachmentRepositoryImpl) GetByPost(ctx context.Context, postID int64) ([]*domain.Attachment, error) {
+	logger := r.logger.With().
+		Str("method", "GetByPost").
+		Int64("post_id", postID).
+		Logger()
+
+	query := `
+		SELECT id, post_id, uploader_id, filename, content_type, size_bytes, width, height, blob_key, thumbnail_key, created_at
+		FROM attachments
+		WHERE post_id = $1
+		ORDER BY id ASC
+	`
+
+	attachments, err := r.queryAttachments(ctx, query, postID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get attachments")
+		return nil, err
+	}
+
+	logger.Debug().
+		Int("attachment_count", len(attachments)).
+		Msg("Successfully retrieved attachments")
+	return attachments, nil
+}
+
+// CountUnlinked считает, сколько из ids принадлежат uploaderID и ещё не прикреплены к посту.
+func (r *AttachmentRepositoryImpl) CountUnlinked(ctx context.Context, ids []int64, uploaderID int64) (int, error) {
+	logger := r.logger.With().
+		Str("method", "CountUnlinked").
+		Int64("uploader_id", uploaderID).
+		Logger()
+
+	query := `
+		SELECT COUNT(*)
+		FROM attachments
+		WHERE id = ANY($1) AND uploader_id = $2 AND post_id IS NULL
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, pq.Array(ids), uploaderID).Scan(&count); err != nil {
+		logger.Error().Err(err).Msg("Failed to count attachments")
+		return 0, fmt.Errorf("failed to count attachments: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *AttachmentRepositoryImpl) AttachToPost(ctx context.Context, ids []int64, postID, uploaderID int64) error {
+	logger := r.logger.With().
+		Str("method", "AttachToPost").
+		Int64("post_id", postID).
+		Int64("uploader_id", uploaderID).
+		Logger()
+
+	query := `
+		UPDATE attachments
+		SET post_id = $2
+		WHERE id = ANY($1) AND uploader_id = $3 AND post_id IS NULL
+	`
+
+	result, err := r.db.ExecContext(ctx, query, pq.Array(ids), postID, uploaderID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to attach uploads to post")
+		return fmt.Errorf("failed to attach uploads: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to check rows affected")
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+
+	logger.Info().Int64("attached", rowsAffected).Msg("Uploads attached to post")
+	return nil
+}
+
+func (r *AttachmentRepositoryImpl) queryAttachments(ctx context.Context, query string, args ...interface{}) ([]*domain.Attachment, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query attachments: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	var attachments []*domain.Attachment
+	for rows.Next() {
+		var attachment domain.Attachment
+		var postID sql.NullInt64
+		var createdAt time.Time
+
+		if err := rows.Scan(
+			&attachment.ID,
+			&postID,
+			&attachment.UploaderID,
+			&attachment.Filename,
+			&attachment.ContentType,
+			&attachment.Size,
+			&attachment.Width,
+			&attachment.Height,
+			&attachment.BlobKey,
+			&attachment.ThumbnailKey,
+			&createdAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan attachment: %w", err)
+		}
+
+		attachment.PostID = postID.Int64
+		attachment.CreatedAt = createdAt.Format(time.RFC3339)
+		attachments = append(attachments, &attachment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return attachments, nil
+}
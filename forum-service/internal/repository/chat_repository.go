@@ -13,9 +13,26 @@ import (
 
 type ChatRepository interface {
 	SaveMessage(ctx context.Context, message *domain.Message) error
-	GetRecentMessages(ctx context.Context, limit int) ([]*domain.Message, error)
-	GetMessageHistory(ctx context.Context, before time.Time, limit int) ([]*domain.Message, error)
+	GetRecentMessages(ctx context.Context, limit int, viewerID int64) ([]*domain.Message, error)
+	GetMessageHistory(ctx context.Context, before time.Time, limit int, viewerID int64) ([]*domain.Message, error)
+	GetMessagesAfter(ctx context.Context, afterID int64, limit int, viewerID int64) ([]*domain.Message, error)
+	GetMessageByID(ctx context.Context, id, viewerID int64) (*domain.Message, error)
+	GetAuthorID(ctx context.Context, id int64) (int64, error)
+	SetHidden(ctx context.Context, id int64, hidden bool) error
+	UpdateContent(ctx context.Context, id int64, content string) error
+	MarkDeleted(ctx context.Context, id, deletedBy int64) error
 }
+
+// messageColumns — колонки сообщения (m) и превью сообщения, на которое оно
+// отвечает (q), в порядке, который ожидает queryMessages. Текст удалённых
+// сообщений клиентам не отдаётся.
+//
+// Методы чтения принимают viewerID — кому показывается сообщение: скрытые
+// фильтром сообщения видит только их автор, для остальных (и для рассылки
+// всем, viewerID = 0) их нет.
+const messageColumns = `m.id, CASE WHEN m.is_deleted THEN '' ELSE m.content END, m.username, m.user_id, m.created_at, m.edited_at, COALESCE(m.is_deleted, FALSE), COALESCE(m.is_hidden, FALSE),
+		m.reply_to, q.username, q.user_id, CASE WHEN q.is_deleted OR q.is_hidden THEN '' ELSE q.content END, COALESCE(q.is_deleted OR q.is_hidden, FALSE)`
+
 type ChatRepositoryImpl struct {
 	db     *sql.DB
 	logger zerolog.Logger
@@ -29,15 +46,20 @@ func NewChatRepository(db *sql.DB) ChatRepository {
 }
 
 func (r *ChatRepositoryImpl) SaveMessage(ctx context.Context, message *domain.Message) error {
+	ctx, span := startSpan(ctx, "ChatRepository.SaveMessage")
+	defer span.End()
+
 	logger := r.logger.With().
+		Ctx(ctx).
 		Str("method", "SaveMessage").
 		Str("username", message.Username).
 		Int64("user_id", message.UserID).
 		Logger()
 
 	query := `
-		INSERT INTO messages (content, username, user_id, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO messages (content, username, user_id, created_at, reply_to, is_hidden)
+		VALUES ($1, $2, $3, $4, $5, $6)
+		RETURNING id
 	`
 
 	createdAt := time.Now()
@@ -51,12 +73,14 @@ func (r *ChatRepositoryImpl) SaveMessage(ctx context.Context, message *domain.Me
 		}
 	}
 
-	_, err := r.db.ExecContext(ctx, query,
+	err := r.db.QueryRowContext(ctx, query,
 		message.Content,
 		message.Username,
 		message.UserID,
 		createdAt,
-	)
+		sql.NullInt64{Int64: message.ReplyTo, Valid: message.ReplyTo != 0},
+		message.Hidden,
+	).Scan(&message.ID)
 
 	if err != nil {
 		logger.Error().Err(err).
@@ -66,15 +90,21 @@ func (r *ChatRepositoryImpl) SaveMessage(ctx context.Context, message *domain.Me
 	}
 
 	logger.Debug().
+		Int64("message_id", message.ID).
 		Str("content_prefix", truncateString(message.Content, 20)).
 		Msg("Message saved successfully")
 	return nil
 }
 
-func (r *ChatRepositoryImpl) GetRecentMessages(ctx context.Context, limit int) ([]*domain.Message, error) {
+func (r *ChatRepositoryImpl) GetRecentMessages(ctx context.Context, limit int, viewerID int64) ([]*domain.Message, error) {
+	ctx, span := startSpan(ctx, "ChatRepository.GetRecentMessages")
+	defer span.End()
+
 	logger := r.logger.With().
+		Ctx(ctx).
 		Str("method", "GetRecentMessages").
 		Int("limit", limit).
+		Int64("viewer_id", viewerID).
 		Logger()
 
 	if limit <= 0 {
@@ -86,13 +116,15 @@ func (r *ChatRepositoryImpl) GetRecentMessages(ctx context.Context, limit int) (
 	}
 
 	query := `
-		SELECT id, content, username, user_id, created_at
-		FROM messages
-		ORDER BY created_at DESC
+		SELECT ` + messageColumns + `
+		FROM messages m
+		LEFT JOIN messages q ON q.id = m.reply_to
+		WHERE (m.is_hidden = FALSE OR m.user_id = $2)
+		ORDER BY m.created_at DESC
 		LIMIT $1
 	`
 
-	messages, err := r.queryMessages(ctx, query, limit)
+	messages, err := r.queryMessages(ctx, query, limit, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get recent messages")
 		return nil, err
@@ -104,11 +136,16 @@ func (r *ChatRepositoryImpl) GetRecentMessages(ctx context.Context, limit int) (
 	return messages, nil
 }
 
-func (r *ChatRepositoryImpl) GetMessageHistory(ctx context.Context, before time.Time, limit int) ([]*domain.Message, error) {
+func (r *ChatRepositoryImpl) GetMessageHistory(ctx context.Context, before time.Time, limit int, viewerID int64) ([]*domain.Message, error) {
+	ctx, span := startSpan(ctx, "ChatRepository.GetMessageHistory")
+	defer span.End()
+
 	logger := r.logger.With().
+		Ctx(ctx).
 		Str("method", "GetMessageHistory").
 		Time("before", before).
 		Int("limit", limit).
+		Int64("viewer_id", viewerID).
 		Logger()
 
 	if limit <= 0 {
@@ -117,14 +154,15 @@ func (r *ChatRepositoryImpl) GetMessageHistory(ctx context.Context, before time.
 	}
 
 	query := `
-		SELECT id, content, username, user_id, created_at
-		FROM messages
-		WHERE created_at < $1
-		ORDER BY created_at DESC
+		SELECT ` + messageColumns + `
+		FROM messages m
+		LEFT JOIN messages q ON q.id = m.reply_to
+		WHERE m.created_at < $1 AND (m.is_hidden = FALSE OR m.user_id = $3)
+		ORDER BY m.created_at DESC
 		LIMIT $2
 	`
 
-	messages, err := r.queryMessages(ctx, query, before, limit)
+	messages, err := r.queryMessages(ctx, query, before, limit, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get message history")
 		return nil, err
@@ -136,6 +174,201 @@ func (r *ChatRepositoryImpl) GetMessageHistory(ctx context.Context, before time.
 	return messages, nil
 }
 
+// GetMessagesAfter возвращает до limit самых новых сообщений с ID больше
+// afterID в хронологическом порядке — для досылки пропущенного после
+// переподключения. Если пропущено больше limit, старейшие отбрасываются.
+func (r *ChatRepositoryImpl) GetMessagesAfter(ctx context.Context, afterID int64, limit int, viewerID int64) ([]*domain.Message, error) {
+	ctx, span := startSpan(ctx, "ChatRepository.GetMessagesAfter")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "GetMessagesAfter").
+		Int64("after_id", afterID).
+		Int("limit", limit).
+		Int64("viewer_id", viewerID).
+		Logger()
+
+	if limit <= 0 {
+		limit = 50
+		logger.Debug().Msg("Using default limit value")
+	}
+
+	query := `
+		SELECT * FROM (
+			SELECT ` + messageColumns + `
+			FROM messages m
+			LEFT JOIN messages q ON q.id = m.reply_to
+			WHERE m.id > $1 AND (m.is_hidden = FALSE OR m.user_id = $3)
+			ORDER BY m.id DESC
+			LIMIT $2
+		) missed
+		ORDER BY 1 ASC
+	`
+
+	messages, err := r.queryMessages(ctx, query, afterID, limit, viewerID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get messages after ID")
+		return nil, err
+	}
+
+	logger.Debug().
+		Int("message_count", len(messages)).
+		Msg("Successfully retrieved messages after ID")
+	return messages, nil
+}
+
+func (r *ChatRepositoryImpl) GetMessageByID(ctx context.Context, id, viewerID int64) (*domain.Message, error) {
+	ctx, span := startSpan(ctx, "ChatRepository.GetMessageByID")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "GetMessageByID").
+		Int64("message_id", id).
+		Int64("viewer_id", viewerID).
+		Logger()
+
+	query := `
+		SELECT ` + messageColumns + `
+		FROM messages m
+		LEFT JOIN messages q ON q.id = m.reply_to
+		WHERE m.id = $1 AND (m.is_hidden = FALSE OR m.user_id = $2)
+	`
+
+	messages, err := r.queryMessages(ctx, query, id, viewerID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get message")
+		return nil, err
+	}
+	if len(messages) == 0 {
+		logger.Debug().Msg("Message not found")
+		return nil, nil
+	}
+
+	return messages[0], nil
+}
+
+// GetAuthorID возвращает автора сообщения id, в том числе скрытого, или 0,
+// если такого сообщения нет. Нужен модерации: жалоба может прийти на уже скрытый контент.
+func (r *ChatRepositoryImpl) GetAuthorID(ctx context.Context, id int64) (int64, error) {
+	ctx, span := startSpan(ctx, "ChatRepository.GetAuthorID")
+	defer span.End()
+
+	var authorID int64
+	err := r.db.QueryRowContext(ctx, `SELECT user_id FROM messages WHERE id = $1`, id).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		r.logger.Error().Ctx(ctx).Err(err).Int64("message_id", id).Msg("Failed to get author")
+		return 0, fmt.Errorf("failed to get author: %w", err)
+	}
+	return authorID, nil
+}
+
+func (r *ChatRepositoryImpl) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	ctx, span := startSpan(ctx, "ChatRepository.SetHidden")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "SetHidden").
+		Int64("message_id", id).
+		Bool("hidden", hidden).
+		Logger()
+
+	query := `
+		UPDATE messages
+		SET is_hidden = $2
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, hidden); err != nil {
+		logger.Error().Err(err).Msg("Failed to update message visibility")
+		return fmt.Errorf("failed to update message visibility: %w", err)
+	}
+
+	logger.Info().Msg("Message visibility updated")
+	return nil
+}
+
+// UpdateContent заменяет текст неудалённого сообщения и отмечает время правки.
+func (r *ChatRepositoryImpl) UpdateContent(ctx context.Context, id int64, content string) error {
+	ctx, span := startSpan(ctx, "ChatRepository.UpdateContent")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "UpdateContent").
+		Int64("message_id", id).
+		Logger()
+
+	query := `
+		UPDATE messages
+		SET content = $2, edited_at = $3
+		WHERE id = $1 AND NOT COALESCE(is_deleted, FALSE)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, content, time.Now())
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to update message")
+		return fmt.Errorf("failed to update message: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to check rows affected")
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		logger.Warn().Msg("Message not found or deleted")
+		return domain.ErrMessageNotFound
+	}
+
+	logger.Info().Msg("Message updated")
+	return nil
+}
+
+// MarkDeleted превращает сообщение в «надгробие». Текст остаётся в базе
+// для разбора жалоб, но клиентам больше не отдаётся.
+func (r *ChatRepositoryImpl) MarkDeleted(ctx context.Context, id, deletedBy int64) error {
+	ctx, span := startSpan(ctx, "ChatRepository.MarkDeleted")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "MarkDeleted").
+		Int64("message_id", id).
+		Int64("deleted_by", deletedBy).
+		Logger()
+
+	query := `
+		UPDATE messages
+		SET is_deleted = TRUE, deleted_by = $2
+		WHERE id = $1 AND NOT COALESCE(is_deleted, FALSE)
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, deletedBy)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to delete message")
+		return fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to check rows affected")
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		logger.Warn().Msg("Message not found or already deleted")
+		return domain.ErrMessageNotFound
+	}
+
+	logger.Info().Msg("Message deleted")
+	return nil
+}
+
 func (r *ChatRepositoryImpl) queryMessages(ctx context.Context, query string, args ...interface{}) ([]*domain.Message, error) {
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -151,6 +384,10 @@ func (r *ChatRepositoryImpl) queryMessages(ctx context.Context, query string, ar
 	for rows.Next() {
 		var msg domain.Message
 		var createdAt time.Time
+		var editedAt sql.NullTime
+		var replyTo, quoteUserID sql.NullInt64
+		var quoteUsername, quoteContent sql.NullString
+		var quoteDeleted bool
 
 		if err := rows.Scan(
 			&msg.ID,
@@ -158,11 +395,32 @@ func (r *ChatRepositoryImpl) queryMessages(ctx context.Context, query string, ar
 			&msg.Username,
 			&msg.UserID,
 			&createdAt,
+			&editedAt,
+			&msg.Deleted,
+			&msg.Hidden,
+			&replyTo,
+			&quoteUsername,
+			&quoteUserID,
+			&quoteContent,
+			&quoteDeleted,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan message: %w", err)
 		}
 
 		msg.CreatedAt = createdAt.Format(time.RFC3339)
+		if editedAt.Valid {
+			msg.EditedAt = editedAt.Time.Format(time.RFC3339)
+		}
+		if replyTo.Valid {
+			msg.ReplyTo = replyTo.Int64
+			msg.Quote = domain.NewMessageQuote(&domain.Message{
+				ID:       replyTo.Int64,
+				Username: quoteUsername.String,
+				UserID:   quoteUserID.Int64,
+				Content:  quoteContent.String,
+				Deleted:  quoteDeleted,
+			})
+		}
 		messages = append(messages, &msg)
 	}
 
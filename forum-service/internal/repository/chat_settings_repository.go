@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// settingSlowMode — ключ интервала медленного режима в chat_settings.
+const settingSlowMode = "slow_mode"
+
+// ChatSettingsRepository хранит настройки чата, общие для всех экземпляров
+// и переживающие перезапуск.
+type ChatSettingsRepository interface {
+	// LoadSlowMode возвращает сохранённый интервал медленного режима;
+	// ok == false, если модераторы его ещё не задавали.
+	LoadSlowMode(ctx context.Context) (interval time.Duration, ok bool, err error)
+	SaveSlowMode(ctx context.Context, interval time.Duration) error
+}
+
+type ChatSettingsRepositoryImpl struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewChatSettingsRepository(db *sql.DB) ChatSettingsRepository {
+	return &ChatSettingsRepositoryImpl{
+		db:     db,
+		logger: log.With().Str("component", "chat_settings_repository").Logger(),
+	}
+}
+
+func (r *ChatSettingsRepositoryImpl) LoadSlowMode(ctx context.Context) (time.Duration, bool, error) {
+	ctx, span := startSpan(ctx, "ChatSettingsRepository.LoadSlowMode")
+	defer span.End()
+
+	var value string
+	err := r.db.QueryRowContext(ctx, `SELECT value FROM chat_settings WHERE key = $1`, settingSlowMode).Scan(&value)
+	if errors.Is(err, sql.ErrNoRows) {
+		return 0, false, nil
+	}
+	if err != nil {
+		r.logger.Error().Ctx(ctx).Err(err).Msg("Failed to load slow mode")
+		return 0, false, fmt.Errorf("failed to load slow mode: %w", err)
+	}
+
+	interval, err := time.ParseDuration(value)
+	if err != nil {
+		return 0, false, fmt.Errorf("invalid stored slow mode %q: %w", value, err)
+	}
+	return interval, true, nil
+}
+
+func (r *ChatSettingsRepositoryImpl) SaveSlowMode(ctx context.Context, interval time.Duration) error {
+	ctx, span := startSpan(ctx, "ChatSettingsRepository.SaveSlowMode")
+	defer span.End()
+
+	_, err := r.db.ExecContext(ctx, `
+		INSERT INTO chat_settings (key, value, updated_at) VALUES ($1, $2, CURRENT_TIMESTAMP)
+		ON CONFLICT (key) DO UPDATE SET value = EXCLUDED.value, updated_at = EXCLUDED.updated_at
+	`, settingSlowMode, interval.String())
+	if err != nil {
+		r.logger.Error().Ctx(ctx).Err(err).Dur("interval", interval).Msg("Failed to save slow mode")
+		return fmt.Errorf("failed to save slow mode: %w", err)
+	}
+	return nil
+}
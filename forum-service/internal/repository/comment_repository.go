@@ -0,0 +1,209 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type CommentRepository interface {
+	Create(ctx context.Context, comment *domain.Comment) (int64, error)
+	// GetByID отдаёт комментарий и тогда, когда он скрыт.
+	GetByID(ctx context.Context, id int64) (*domain.Comment, error)
+	// GetByPost отдаёт комментарии поста; скрытые фильтром видит только их
+	// автор viewerID.
+	GetByPost(ctx context.Context, postID, viewerID int64) ([]*domain.Comment, error)
+	UpdateContentHTML(ctx context.Context, id int64, html string, version int) error
+	SetHidden(ctx context.Context, id int64, hidden bool) error
+}
+
+type CommentRepositoryImpl struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewCommentRepository(db *sql.DB) CommentRepository {
+	return &CommentRepositoryImpl{
+		db:     db,
+		logger: log.With().Str("component", "comment_repository").Logger(),
+	}
+}
+
+func (r *CommentRepositoryImpl) Create(ctx context.Context, comment *domain.Comment) (int64, error) {
+	logger := r.logger.With().
+		Str("method", "Create").
+		Int64("post_id", comment.PostID).
+		Int64("author_id", comment.AuthorID).
+		Logger()
+
+	query := `
+		INSERT INTO comments (post_id, author_id, author, content, content_html, render_version, created_at, is_hidden)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		RETURNING id
+	`
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, query,
+		comment.PostID,
+		comment.AuthorID,
+		comment.Author,
+		comment.Content,
+		comment.ContentHTML,
+		comment.RenderVer,
+		time.Now(),
+		comment.Hidden,
+	).Scan(&id)
+
+	if err != nil {
+		logger.Error().Err(err).
+			Str("content_prefix", truncateString(comment.Content, 20)).
+			Msg("Failed to create comment")
+		return 0, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	logger.Info().
+		Int64("comment_id", id).
+		Msg("Comment created successfully")
+	return id, nil
+}
+
+func (r *CommentRepositoryImpl) GetByID(ctx context.Context, id int64) (*domain.Comment, error) {
+	logger := r.logger.With().
+		Str("method", "GetByID").
+		Int64("comment_id", id).
+		Logger()
+
+	query := `
+		SELECT id, post_id, author_id, author, content, content_html, render_version, created_at
+		FROM comments
+		WHERE id = $1
+	`
+
+	comments, err := r.queryComments(ctx, query, id)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get comment")
+		return nil, err
+	}
+	if len(comments) == 0 {
+		logger.Debug().Msg("Comment not found")
+		return nil, nil
+	}
+
+	return comments[0], nil
+}
+
+func (r *CommentRepositoryImpl) GetByPost(ctx context.Context, postID, viewerID int64) ([]*domain.Comment, error) {
+	logger := r.logger.With().
+		Str("method", "GetByPost").
+		Int64("post_id", postID).
+		Int64("viewer_id", viewerID).
+		Logger()
+
+	query := `
+		SELECT id, post_id, author_id, author, content, content_html, render_version, created_at
+		FROM comments
+		WHERE post_id = $1 AND (is_hidden = FALSE OR author_id = $2)
+		ORDER BY created_at ASC
+	`
+
+	comments, err := r.queryComments(ctx, query, postID, viewerID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get comments")
+		return nil, err
+	}
+
+	logger.Debug().
+		Int("comment_count", len(comments)).
+		Msg("Successfully retrieved comments")
+	return comments, nil
+}
+
+// UpdateContentHTML сохраняет перерендеренный HTML комментария вместе с версией рендерера.
+func (r *CommentRepositoryImpl) UpdateContentHTML(ctx context.Context, id int64, html string, version int) error {
+	logger := r.logger.With().
+		Str("method", "UpdateContentHTML").
+		Int64("comment_id", id).
+		Int("render_version", version).
+		Logger()
+
+	query := `
+		UPDATE comments
+		SET content_html = $2, render_version = $3
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, html, version); err != nil {
+		logger.Error().Err(err).Msg("Failed to update comment HTML")
+		return fmt.Errorf("failed to update comment HTML: %w", err)
+	}
+
+	logger.Debug().Msg("Comment HTML updated")
+	return nil
+}
+
+func (r *CommentRepositoryImpl) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	logger := r.logger.With().
+		Str("method", "SetHidden").
+		Int64("comment_id", id).
+		Bool("hidden", hidden).
+		Logger()
+
+	query := `
+		UPDATE comments
+		SET is_hidden = $2
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, hidden); err != nil {
+		logger.Error().Err(err).Msg("Failed to update comment visibility")
+		return fmt.Errorf("failed to update comment visibility: %w", err)
+	}
+
+	logger.Info().Msg("Comment visibility updated")
+	return nil
+}
+
+func (r *CommentRepositoryImpl) queryComments(ctx context.Context, query string, args ...interface{}) ([]*domain.Comment, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query comments: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	var comments []*domain.Comment
+	for rows.Next() {
+		var comment domain.Comment
+		var createdAt time.Time
+
+		if err := rows.Scan(
+			&comment.ID,
+			&comment.PostID,
+			&comment.AuthorID,
+			&comment.Author,
+			&comment.Content,
+			&comment.ContentHTML,
+			&comment.RenderVer,
+			&createdAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan comment: %w", err)
+		}
+
+		comment.CreatedAt = createdAt.Format(time.RFC3339)
+		comments = append(comments, &comment)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return comments, nil
+}
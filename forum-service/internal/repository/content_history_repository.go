@@ -0,0 +1,105 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// ContentHistoryRepository отдаёт прошлые посты, комментарии и сообщения автора для
+// фильтров контента (contentfilter.History), включая скрытые.
+type ContentHistoryRepository interface {
+	FirstSeen(ctx context.Context, authorID int64) (time.Time, error)
+	Recent(ctx context.Context, authorID int64, since time.Time, limit int) ([]string, error)
+}
+
+type ContentHistoryRepositoryImpl struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewContentHistoryRepository(db *sql.DB) ContentHistoryRepository {
+	return &ContentHistoryRepositoryImpl{
+		db:     db,
+		logger: log.With().Str("component", "content_history_repository").Logger(),
+	}
+}
+
+func (r *ContentHistoryRepositoryImpl) FirstSeen(ctx context.Context, authorID int64) (time.Time, error) {
+	ctx, span := startSpan(ctx, "ContentHistoryRepository.FirstSeen")
+	defer span.End()
+
+	query := `
+		SELECT MIN(created_at) FROM (
+			SELECT MIN(created_at) AS created_at FROM posts WHERE author_id = $1
+			UNION ALL
+			SELECT MIN(created_at) FROM messages WHERE user_id = $1
+			UNION ALL
+			SELECT MIN(created_at) FROM comments WHERE author_id = $1
+		) first_seen
+	`
+
+	var firstSeen sql.NullTime
+	if err := r.db.QueryRowContext(ctx, query, authorID).Scan(&firstSeen); err != nil {
+		r.logger.Error().Ctx(ctx).Err(err).Int64("author_id", authorID).Msg("Failed to get first activity")
+		return time.Time{}, fmt.Errorf("failed to get first activity: %w", err)
+	}
+	return firstSeen.Time, nil
+}
+
+// Recent не отдаёт удалённый автором контент: сообщения помечаются
+// is_deleted и отфильтровываются, а посты и комментарии удаляются из базы
+// (колонки is_deleted у posts в старых базах нет, поэтому на неё запрос не
+// опирается). Скрытое модераторами и фильтром остаётся — повтор удалённого
+// за спам текста тоже повтор.
+func (r *ContentHistoryRepositoryImpl) Recent(ctx context.Context, authorID int64, since time.Time, limit int) ([]string, error) {
+	ctx, span := startSpan(ctx, "ContentHistoryRepository.Recent")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "Recent").
+		Int64("author_id", authorID).
+		Logger()
+
+	query := `
+		SELECT content FROM (
+			SELECT title || ' ' || content AS content, created_at FROM posts
+			WHERE author_id = $1 AND created_at >= $2
+			UNION ALL
+			SELECT content, created_at FROM messages
+			WHERE user_id = $1 AND created_at >= $2 AND NOT COALESCE(is_deleted, FALSE)
+			UNION ALL
+			SELECT content, created_at FROM comments
+			WHERE author_id = $1 AND created_at >= $2
+		) recent
+		ORDER BY created_at DESC
+		LIMIT $3
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, authorID, since, limit)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to query recent content")
+		return nil, fmt.Errorf("failed to query recent content: %w", err)
+	}
+	defer rows.Close()
+
+	var texts []string
+	for rows.Next() {
+		var text string
+		if err := rows.Scan(&text); err != nil {
+			logger.Error().Err(err).Msg("Failed to scan recent content")
+			return nil, fmt.Errorf("failed to scan recent content: %w", err)
+		}
+		texts = append(texts, text)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error().Err(err).Msg("Failed to iterate recent content")
+		return nil, fmt.Errorf("failed to iterate recent content: %w", err)
+	}
+	return texts, nil
+}
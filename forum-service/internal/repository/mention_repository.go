@@ -0,0 +1,72 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type MentionRepository interface {
+	Create(ctx context.Context, mention *domain.Mention) (bool, error)
+}
+
+type MentionRepositoryImpl struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewMentionRepository(db *sql.DB) MentionRepository {
+	return &MentionRepositoryImpl{
+		db:     db,
+		logger: log.With().Str("component", "mention_repository").Logger(),
+	}
+}
+
+// Create сохраняет упоминание и заполняет его ID и время создания. Повторное
+// упоминание того же пользователя в том же источнике не сохраняется: тогда
+// возвращается false.
+func (r *MentionRepositoryImpl) Create(ctx context.Context, mention *domain.Mention) (bool, error) {
+	logger := r.logger.With().
+		Str("method", "Create").
+		Str("source_type", mention.SourceType).
+		Int64("source_id", mention.SourceID).
+		Int64("mentioned_user_id", mention.MentionedUserID).
+		Logger()
+
+	query := `
+		INSERT INTO mentions (source_type, source_id, post_id, mentioned_user_id, author_id, author, excerpt, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8)
+		ON CONFLICT (source_type, source_id, mentioned_user_id) DO NOTHING
+		RETURNING id
+	`
+
+	createdAt := time.Now()
+	err := r.db.QueryRowContext(ctx, query,
+		mention.SourceType,
+		mention.SourceID,
+		sql.NullInt64{Int64: mention.PostID, Valid: mention.PostID != 0},
+		mention.MentionedUserID,
+		mention.AuthorID,
+		mention.Author,
+		mention.Excerpt,
+		createdAt,
+	).Scan(&mention.ID)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Debug().Msg("Duplicate mention ignored")
+			return false, nil
+		}
+		logger.Error().Err(err).Msg("Failed to create mention")
+		return false, fmt.Errorf("failed to create mention: %w", err)
+	}
+
+	mention.CreatedAt = createdAt.Format(time.RFC3339)
+	logger.Debug().Int64("mention_id", mention.ID).Msg("Mention created successfully")
+	return true, nil
+}
@@ -17,11 +17,19 @@ type PostRepositoryImpl struct {
 }
 type PostRepository interface {
 	Create(ctx context.Context, post *domain.Post) (int64, error)
-	GetByID(ctx context.Context, id int64) (*domain.Post, error)
-	GetAll(ctx context.Context) ([]*domain.Post, error)
+	// Методы чтения принимают viewerID — кому показывается пост: скрытые
+	// фильтром посты видит только их автор, для остальных (viewerID = 0)
+	// их нет.
+	GetByID(ctx context.Context, id, viewerID int64) (*domain.Post, error)
+	GetAuthorID(ctx context.Context, id int64) (int64, error)
+	GetAll(ctx context.Context, viewerID int64) ([]*domain.Post, error)
 	Delete(ctx context.Context, id, authorID int64) error
-	GetPostsWithAuthors(ctx context.Context) ([]*domain.Post, error)
-	GetPostsPaginated(ctx context.Context, offset, limit int) ([]*domain.Post, error)
+	GetPostsWithAuthors(ctx context.Context, viewerID int64) ([]*domain.Post, error)
+	GetPostsPaginated(ctx context.Context, offset, limit int, viewerID int64) ([]*domain.Post, error)
+	SetHidden(ctx context.Context, id int64, hidden bool) error
+	UpdateFlags(ctx context.Context, id int64, update domain.PostFlagsUpdate) error
+	GetAnnouncements(ctx context.Context) ([]*domain.Post, error)
+	UpdateContentHTML(ctx context.Context, id int64, html string, version int) error
 }
 
 func NewPostRepository(db *sql.DB) PostRepository {
@@ -32,15 +40,19 @@ func NewPostRepository(db *sql.DB) PostRepository {
 }
 
 func (r *PostRepositoryImpl) Create(ctx context.Context, post *domain.Post) (int64, error) {
+	ctx, span := startSpan(ctx, "PostRepository.Create")
+	defer span.End()
+
 	logger := r.logger.With().
+		Ctx(ctx).
 		Str("method", "Create").
 		Str("title", post.Title).
 		Int64("author_id", post.AuthorID).
 		Logger()
 
 	query := `
-		INSERT INTO posts (title, content, author_id, created_at)
-		VALUES ($1, $2, $3, $4)
+		INSERT INTO posts (title, content, content_html, render_version, author_id, created_at, is_hidden)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
 		RETURNING id
 	`
 
@@ -48,8 +60,11 @@ func (r *PostRepositoryImpl) Create(ctx context.Context, post *domain.Post) (int
 	err := r.db.QueryRowContext(ctx, query,
 		post.Title,
 		post.Content,
+		post.ContentHTML,
+		post.RenderVer,
 		post.AuthorID,
 		time.Now(),
+		post.Hidden,
 	).Scan(&id)
 
 	if err != nil {
@@ -65,27 +80,36 @@ func (r *PostRepositoryImpl) Create(ctx context.Context, post *domain.Post) (int
 	return id, nil
 }
 
-func (r *PostRepositoryImpl) GetByID(ctx context.Context, id int64) (*domain.Post, error) {
+func (r *PostRepositoryImpl) GetByID(ctx context.Context, id, viewerID int64) (*domain.Post, error) {
+	ctx, span := startSpan(ctx, "PostRepository.GetByID")
+	defer span.End()
+
 	logger := r.logger.With().
+		Ctx(ctx).
 		Str("method", "GetByID").
 		Int64("post_id", id).
 		Logger()
 
 	query := `
-		SELECT id, title, content, author_id, created_at
+		SELECT id, title, content, content_html, render_version, author_id, created_at, pinned, locked, announcement
 		FROM posts
-		WHERE id = $1
+		WHERE id = $1 AND (is_hidden = FALSE OR author_id = $2)
 	`
 
 	var post domain.Post
 	var createdAt time.Time
 
-	err := r.db.QueryRowContext(ctx, query, id).Scan(
+	err := r.db.QueryRowContext(ctx, query, id, viewerID).Scan(
 		&post.ID,
 		&post.Title,
 		&post.Content,
+		&post.ContentHTML,
+		&post.RenderVer,
 		&post.AuthorID,
 		&createdAt,
+		&post.Pinned,
+		&post.Locked,
+		&post.Announcement,
 	)
 
 	if err != nil {
@@ -102,18 +126,41 @@ func (r *PostRepositoryImpl) GetByID(ctx context.Context, id int64) (*domain.Pos
 	return &post, nil
 }
 
-func (r *PostRepositoryImpl) GetAll(ctx context.Context) ([]*domain.Post, error) {
+// GetAuthorID возвращает автора поста id, в том числе скрытого, или 0,
+// если такого поста нет. Нужен модерации: жалоба может прийти на уже скрытый контент.
+func (r *PostRepositoryImpl) GetAuthorID(ctx context.Context, id int64) (int64, error) {
+	ctx, span := startSpan(ctx, "PostRepository.GetAuthorID")
+	defer span.End()
+
+	var authorID int64
+	err := r.db.QueryRowContext(ctx, `SELECT author_id FROM posts WHERE id = $1`, id).Scan(&authorID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		r.logger.Error().Ctx(ctx).Err(err).Int64("post_id", id).Msg("Failed to get author")
+		return 0, fmt.Errorf("failed to get author: %w", err)
+	}
+	return authorID, nil
+}
+
+func (r *PostRepositoryImpl) GetAll(ctx context.Context, viewerID int64) ([]*domain.Post, error) {
+	ctx, span := startSpan(ctx, "PostRepository.GetAll")
+	defer span.End()
+
 	logger := r.logger.With().
+		Ctx(ctx).
 		Str("method", "GetAll").
 		Logger()
 
 	query := `
-		SELECT id, title, content, author_id, created_at
+		SELECT id, title, content, content_html, render_version, author_id, created_at, pinned, locked, announcement
 		FROM posts
-		ORDER BY created_at DESC
+		WHERE is_hidden = FALSE OR author_id = $1
+		ORDER BY pinned DESC, created_at DESC
 	`
 
-	posts, err := r.queryPosts(ctx, query)
+	posts, err := r.queryPosts(ctx, query, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get all posts")
 		return nil, err
@@ -125,19 +172,24 @@ func (r *PostRepositoryImpl) GetAll(ctx context.Context) ([]*domain.Post, error)
 	return posts, nil
 }
 
-func (r *PostRepositoryImpl) GetPostsWithAuthors(ctx context.Context) ([]*domain.Post, error) {
+func (r *PostRepositoryImpl) GetPostsWithAuthors(ctx context.Context, viewerID int64) ([]*domain.Post, error) {
+	ctx, span := startSpan(ctx, "PostRepository.GetPostsWithAuthors")
+	defer span.End()
+
 	logger := r.logger.With().
+		Ctx(ctx).
 		Str("method", "GetPostsWithAuthors").
 		Logger()
 
 	query := `
-		SELECT p.id, p.title, p.content, p.author_id, p.created_at, u.username as author
+		SELECT p.id, p.title, p.content, p.content_html, p.render_version, p.author_id, p.created_at, p.pinned, p.locked, p.announcement, u.username as author
 		FROM posts p
 		JOIN users u ON p.author_id = u.id
-		ORDER BY p.created_at DESC
+		WHERE p.is_hidden = FALSE OR p.author_id = $1
+		ORDER BY p.pinned DESC, p.created_at DESC
 	`
 
-	rows, err := r.db.QueryContext(ctx, query)
+	rows, err := r.db.QueryContext(ctx, query, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to query posts with authors")
 		return nil, fmt.Errorf("failed to query posts: %w", err)
@@ -157,8 +209,13 @@ func (r *PostRepositoryImpl) GetPostsWithAuthors(ctx context.Context) ([]*domain
 			&post.ID,
 			&post.Title,
 			&post.Content,
+			&post.ContentHTML,
+			&post.RenderVer,
 			&post.AuthorID,
 			&createdAt,
+			&post.Pinned,
+			&post.Locked,
+			&post.Announcement,
 			&post.Author,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan post: %w", err)
@@ -178,8 +235,12 @@ func (r *PostRepositoryImpl) GetPostsWithAuthors(ctx context.Context) ([]*domain
 	return posts, nil
 }
 
-func (r *PostRepositoryImpl) GetPostsPaginated(ctx context.Context, offset, limit int) ([]*domain.Post, error) {
+func (r *PostRepositoryImpl) GetPostsPaginated(ctx context.Context, offset, limit int, viewerID int64) ([]*domain.Post, error) {
+	ctx, span := startSpan(ctx, "PostRepository.GetPostsPaginated")
+	defer span.End()
+
 	logger := r.logger.With().
+		Ctx(ctx).
 		Str("method", "GetPostsPaginated").
 		Int("offset", offset).
 		Int("limit", limit).
@@ -195,13 +256,14 @@ func (r *PostRepositoryImpl) GetPostsPaginated(ctx context.Context, offset, limi
 	}
 
 	query := `
-		SELECT id, title, content, author_id, created_at
+		SELECT id, title, content, content_html, render_version, author_id, created_at, pinned, locked, announcement
 		FROM posts
-		ORDER BY created_at DESC
+		WHERE is_hidden = FALSE OR author_id = $3
+		ORDER BY pinned DESC, created_at DESC
 		LIMIT $1 OFFSET $2
 	`
 
-	posts, err := r.queryPosts(ctx, query, limit, offset)
+	posts, err := r.queryPosts(ctx, query, limit, offset, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get paginated posts")
 		return nil, err
@@ -214,7 +276,11 @@ func (r *PostRepositoryImpl) GetPostsPaginated(ctx context.Context, offset, limi
 }
 
 func (r *PostRepositoryImpl) Delete(ctx context.Context, id, authorID int64) error {
+	ctx, span := startSpan(ctx, "PostRepository.Delete")
+	defer span.End()
+
 	logger := r.logger.With().
+		Ctx(ctx).
 		Str("method", "Delete").
 		Int64("post_id", id).
 		Int64("author_id", authorID).
@@ -246,6 +312,126 @@ func (r *PostRepositoryImpl) Delete(ctx context.Context, id, authorID int64) err
 	return nil
 }
 
+func (r *PostRepositoryImpl) SetHidden(ctx context.Context, id int64, hidden bool) error {
+	ctx, span := startSpan(ctx, "PostRepository.SetHidden")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "SetHidden").
+		Int64("post_id", id).
+		Bool("hidden", hidden).
+		Logger()
+
+	query := `
+		UPDATE posts
+		SET is_hidden = $2
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, hidden); err != nil {
+		logger.Error().Err(err).Msg("Failed to update post visibility")
+		return fmt.Errorf("failed to update post visibility: %w", err)
+	}
+
+	logger.Info().Msg("Post visibility updated")
+	return nil
+}
+
+// UpdateFlags меняет только переданные в update флаги; остальные сохраняют значение.
+func (r *PostRepositoryImpl) UpdateFlags(ctx context.Context, id int64, update domain.PostFlagsUpdate) error {
+	ctx, span := startSpan(ctx, "PostRepository.UpdateFlags")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "UpdateFlags").
+		Int64("post_id", id).
+		Logger()
+
+	query := `
+		UPDATE posts
+		SET pinned = COALESCE($2, pinned),
+		    locked = COALESCE($3, locked),
+		    announcement = COALESCE($4, announcement)
+		WHERE id = $1
+	`
+
+	result, err := r.db.ExecContext(ctx, query, id, update.Pinned, update.Locked, update.Announcement)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to update post flags")
+		return fmt.Errorf("failed to update post flags: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to check rows affected")
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		logger.Warn().Msg("Post not found")
+		return domain.ErrPostNotFound
+	}
+
+	logger.Info().Msg("Post flags updated")
+	return nil
+}
+
+func (r *PostRepositoryImpl) GetAnnouncements(ctx context.Context) ([]*domain.Post, error) {
+	ctx, span := startSpan(ctx, "PostRepository.GetAnnouncements")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "GetAnnouncements").
+		Logger()
+
+	query := `
+		SELECT id, title, content, content_html, render_version, author_id, created_at, pinned, locked, announcement
+		FROM posts
+		WHERE announcement = TRUE AND is_hidden = FALSE
+		ORDER BY pinned DESC, created_at DESC
+	`
+
+	posts, err := r.queryPosts(ctx, query)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get announcements")
+		return nil, err
+	}
+
+	logger.Debug().
+		Int("post_count", len(posts)).
+		Msg("Successfully retrieved announcements")
+	return posts, nil
+}
+
+// UpdateContentHTML сохраняет перерендеренный HTML поста вместе с версией рендерера.
+func (r *PostRepositoryImpl) UpdateContentHTML(ctx context.Context, id int64, html string, version int) error {
+	ctx, span := startSpan(ctx, "PostRepository.UpdateContentHTML")
+	defer span.End()
+
+	logger := r.logger.With().
+		Ctx(ctx).
+		Str("method", "UpdateContentHTML").
+		Int64("post_id", id).
+		Int("render_version", version).
+		Logger()
+
+	query := `
+		UPDATE posts
+		SET content_html = $2, render_version = $3
+		WHERE id = $1
+	`
+
+	if _, err := r.db.ExecContext(ctx, query, id, html, version); err != nil {
+		logger.Error().Err(err).Msg("Failed to update post HTML")
+		return fmt.Errorf("failed to update post HTML: %w", err)
+	}
+
+	logger.Debug().Msg("Post HTML updated")
+	return nil
+}
+
 func (r *PostRepositoryImpl) queryPosts(ctx context.Context, query string, args ...interface{}) ([]*domain.Post, error) {
 	rows, err := r.db.QueryContext(ctx, query, args...)
 	if err != nil {
@@ -266,8 +452,13 @@ func (r *PostRepositoryImpl) queryPosts(ctx context.Context, query string, args
 			&post.ID,
 			&post.Title,
 			&post.Content,
+			&post.ContentHTML,
+			&post.RenderVer,
 			&post.AuthorID,
 			&createdAt,
+			&post.Pinned,
+			&post.Locked,
+			&post.Announcement,
 		); err != nil {
 			return nil, fmt.Errorf("failed to scan post: %w", err)
 		}
@@ -0,0 +1,124 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type ReactionRepository interface {
+	Toggle(ctx context.Context, targetType string, targetID, userID int64, emoji string) (bool, error)
+	Counts(ctx context.Context, targetType string, targetIDs []int64) (map[int64][]domain.ReactionCount, error)
+}
+
+type ReactionRepositoryImpl struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewReactionRepository(db *sql.DB) ReactionRepository {
+	return &ReactionRepositoryImpl{
+		db:     db,
+		logger: log.With().Str("component", "reaction_repository").Logger(),
+	}
+}
+
+// Toggle снимает реакцию пользователя, если она уже стоит, и ставит её иначе.
+// Возвращает true, если реакция поставлена.
+func (r *ReactionRepositoryImpl) Toggle(ctx context.Context, targetType string, targetID, userID int64, emoji string) (bool, error) {
+	logger := r.logger.With().
+		Str("method", "Toggle").
+		Str("target_type", targetType).
+		Int64("target_id", targetID).
+		Int64("user_id", userID).
+		Logger()
+
+	result, err := r.db.ExecContext(ctx, `
+		DELETE FROM reactions
+		WHERE target_type = $1 AND target_id = $2 AND user_id = $3 AND emoji = $4
+	`, targetType, targetID, userID, emoji)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to remove reaction")
+		return false, fmt.Errorf("failed to remove reaction: %w", err)
+	}
+
+	removed, err := result.RowsAffected()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to check rows affected")
+		return false, fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if removed > 0 {
+		logger.Debug().Msg("Reaction removed")
+		return false, nil
+	}
+
+	// Двойной клик может прийти одновременно из двух вкладок: вторая вставка
+	// просто ничего не делает.
+	if _, err := r.db.ExecContext(ctx, `
+		INSERT INTO reactions (target_type, target_id, user_id, emoji)
+		VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING
+	`, targetType, targetID, userID, emoji); err != nil {
+		logger.Error().Err(err).Msg("Failed to add reaction")
+		return false, fmt.Errorf("failed to add reaction: %w", err)
+	}
+
+	logger.Debug().Msg("Reaction added")
+	return true, nil
+}
+
+// Counts возвращает итоги реакций для каждой цели из targetIDs; цели без
+// реакций в результат не попадают. Эмодзи упорядочены по убыванию количества.
+func (r *ReactionRepositoryImpl) Counts(ctx context.Context, targetType string, targetIDs []int64) (map[int64][]domain.ReactionCount, error) {
+	logger := r.logger.With().
+		Str("method", "Counts").
+		Str("target_type", targetType).
+		Int("target_count", len(targetIDs)).
+		Logger()
+
+	counts := make(map[int64][]domain.ReactionCount)
+	if len(targetIDs) == 0 {
+		return counts, nil
+	}
+
+	query := `
+		SELECT target_id, emoji, COUNT(*) AS cnt, MIN(created_at) AS first_at
+		FROM reactions
+		WHERE target_type = $1 AND target_id = ANY($2)
+		GROUP BY target_id, emoji
+		ORDER BY target_id, cnt DESC, first_at ASC
+	`
+
+	rows, err := r.db.QueryContext(ctx, query, targetType, pq.Array(targetIDs))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to query reactions")
+		return nil, fmt.Errorf("failed to query reactions: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			logger.Warn().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	for rows.Next() {
+		var targetID int64
+		var count domain.ReactionCount
+		var firstAt sql.NullTime
+		if err := rows.Scan(&targetID, &count.Emoji, &count.Count, &firstAt); err != nil {
+			logger.Error().Err(err).Msg("Failed to scan reaction count")
+			return nil, fmt.Errorf("failed to scan reaction count: %w", err)
+		}
+		counts[targetID] = append(counts[targetID], count)
+	}
+	if err := rows.Err(); err != nil {
+		logger.Error().Err(err).Msg("Error during rows iteration")
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return counts, nil
+}
@@ -0,0 +1,256 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type ReportRepository interface {
+	Create(ctx context.Context, report *domain.Report) (int64, error)
+	GetByID(ctx context.Context, id int64) (*domain.Report, error)
+	List(ctx context.Context, filter domain.ReportFilter) ([]*domain.Report, error)
+	UpdateStatus(ctx context.Context, id int64, status string, moderatorID int64) error
+	CountForTarget(ctx context.Context, targetType string, targetID int64, status string) (int, error)
+}
+
+type ReportRepositoryImpl struct {
+	db     *sql.DB
+	logger zerolog.Logger
+}
+
+func NewReportRepository(db *sql.DB) ReportRepository {
+	return &ReportRepositoryImpl{
+		db:     db,
+		logger: log.With().Str("component", "report_repository").Logger(),
+	}
+}
+
+// Create сохраняет жалобу. Повторная жалоба того же пользователя на тот же
+// контент не создаёт новую запись и возвращает domain.ErrAlreadyReported.
+func (r *ReportRepositoryImpl) Create(ctx context.Context, report *domain.Report) (int64, error) {
+	logger := r.logger.With().
+		Str("method", "Create").
+		Str("target_type", report.TargetType).
+		Int64("target_id", report.TargetID).
+		Int64("reporter_id", report.ReporterID).
+		Logger()
+
+	query := `
+		INSERT INTO reports (target_type, target_id, reporter_id, reason, details, status, created_at)
+		VALUES ($1, $2, $3, $4, $5, $6, $7)
+		ON CONFLICT (target_type, target_id, reporter_id) DO NOTHING
+		RETURNING id
+	`
+
+	var id int64
+	err := r.db.QueryRowContext(ctx, query,
+		report.TargetType,
+		report.TargetID,
+		report.ReporterID,
+		report.Reason,
+		report.Details,
+		domain.ReportStatusOpen,
+		time.Now(),
+	).Scan(&id)
+
+	if err != nil {
+		if err == sql.ErrNoRows {
+			logger.Debug().Msg("Duplicate report ignored")
+			return 0, domain.ErrAlreadyReported
+		}
+		logger.Error().Err(err).Msg("Failed to create report")
+		return 0, fmt.Errorf("failed to create report: %w", err)
+	}
+
+	logger.Info().Int64("report_id", id).Msg("Report created successfully")
+	return id, nil
+}
+
+func (r *ReportRepositoryImpl) GetByID(ctx context.Context, id int64) (*domain.Report, error) {
+	logger := r.logger.With().
+		Str("method", "GetByID").
+		Int64("report_id", id).
+		Logger()
+
+	query := `
+		SELECT id, target_type, target_id, reporter_id, reason, details, status, resolved_by, resolved_at, created_at
+		FROM reports
+		WHERE id = $1
+	`
+
+	reports, err := r.queryReports(ctx, query, id)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get report")
+		return nil, err
+	}
+	if len(reports) == 0 {
+		logger.Debug().Msg("Report not found")
+		return nil, nil
+	}
+
+	return reports[0], nil
+}
+
+func (r *ReportRepositoryImpl) List(ctx context.Context, filter domain.ReportFilter) ([]*domain.Report, error) {
+	logger := r.logger.With().
+		Str("method", "List").
+		Str("status", filter.Status).
+		Str("target_type", filter.TargetType).
+		Str("reason", filter.Reason).
+		Int("limit", filter.Limit).
+		Int("offset", filter.Offset).
+		Logger()
+
+	var conditions []string
+	var args []interface{}
+	addCondition := func(column, value string) {
+		if value == "" {
+			return
+		}
+		args = append(args, value)
+		conditions = append(conditions, fmt.Sprintf("%s = $%d", column, len(args)))
+	}
+	addCondition("status", filter.Status)
+	addCondition("target_type", filter.TargetType)
+	addCondition("reason", filter.Reason)
+
+	query := `
+		SELECT id, target_type, target_id, reporter_id, reason, details, status, resolved_by, resolved_at, created_at
+		FROM reports
+	`
+	if len(conditions) > 0 {
+		query += " WHERE " + strings.Join(conditions, " AND ")
+	}
+	args = append(args, filter.Limit, filter.Offset)
+	query += fmt.Sprintf(" ORDER BY created_at ASC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	reports, err := r.queryReports(ctx, query, args...)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list reports")
+		return nil, err
+	}
+
+	logger.Debug().
+		Int("report_count", len(reports)).
+		Msg("Successfully retrieved reports")
+	return reports, nil
+}
+
+func (r *ReportRepositoryImpl) UpdateStatus(ctx context.Context, id int64, status string, moderatorID int64) error {
+	logger := r.logger.With().
+		Str("method", "UpdateStatus").
+		Int64("report_id", id).
+		Str("status", status).
+		Int64("moderator_id", moderatorID).
+		Logger()
+
+	query := `
+		UPDATE reports
+		SET status = $2, resolved_by = $3, resolved_at = $4
+		WHERE id = $1
+	`
+
+	var resolvedBy sql.NullInt64
+	var resolvedAt sql.NullTime
+	if status != domain.ReportStatusOpen {
+		resolvedBy = sql.NullInt64{Int64: moderatorID, Valid: true}
+		resolvedAt = sql.NullTime{Time: time.Now(), Valid: true}
+	}
+
+	result, err := r.db.ExecContext(ctx, query, id, status, resolvedBy, resolvedAt)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to update report status")
+		return fmt.Errorf("failed to update report status: %w", err)
+	}
+
+	rowsAffected, err := result.RowsAffected()
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to check rows affected")
+		return fmt.Errorf("failed to check rows affected: %w", err)
+	}
+	if rowsAffected == 0 {
+		logger.Warn().Msg("Report not found")
+		return domain.ErrReportNotFound
+	}
+
+	logger.Info().Msg("Report status updated")
+	return nil
+}
+
+func (r *ReportRepositoryImpl) CountForTarget(ctx context.Context, targetType string, targetID int64, status string) (int, error) {
+	logger := r.logger.With().
+		Str("method", "CountForTarget").
+		Str("target_type", targetType).
+		Int64("target_id", targetID).
+		Str("status", status).
+		Logger()
+
+	query := `
+		SELECT COUNT(*)
+		FROM reports
+		WHERE target_type = $1 AND target_id = $2 AND status = $3
+	`
+
+	var count int
+	if err := r.db.QueryRowContext(ctx, query, targetType, targetID, status).Scan(&count); err != nil {
+		logger.Error().Err(err).Msg("Failed to count reports")
+		return 0, fmt.Errorf("failed to count reports: %w", err)
+	}
+
+	return count, nil
+}
+
+func (r *ReportRepositoryImpl) queryReports(ctx context.Context, query string, args ...interface{}) ([]*domain.Report, error) {
+	rows, err := r.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query reports: %w", err)
+	}
+	defer func() {
+		if err := rows.Close(); err != nil {
+			r.logger.Warn().Err(err).Msg("Failed to close rows")
+		}
+	}()
+
+	var reports []*domain.Report
+	for rows.Next() {
+		var report domain.Report
+		var resolvedBy sql.NullInt64
+		var resolvedAt sql.NullTime
+		var createdAt time.Time
+
+		if err := rows.Scan(
+			&report.ID,
+			&report.TargetType,
+			&report.TargetID,
+			&report.ReporterID,
+			&report.Reason,
+			&report.Details,
+			&report.Status,
+			&resolvedBy,
+			&resolvedAt,
+			&createdAt,
+		); err != nil {
+			return nil, fmt.Errorf("failed to scan report: %w", err)
+		}
+
+		report.ResolvedBy = resolvedBy.Int64
+		if resolvedAt.Valid {
+			report.ResolvedAt = resolvedAt.Time.Format(time.RFC3339)
+		}
+		report.CreatedAt = createdAt.Format(time.RFC3339)
+		reports = append(reports, &report)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows error: %w", err)
+	}
+
+	return reports, nil
+}
@@ -4,34 +4,59 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 	"time"
 
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/contentfilter"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/repository"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
+const maxMessageLength = 500
+
 type ChatServiceImpl struct {
-	repo   repository.ChatRepository
-	logger zerolog.Logger
+	repo       repository.ChatRepository
+	reactions  repository.ReactionRepository
+	mentions   MentionService
+	notifier   notifications.Notifier
+	auditor    audit.Recorder
+	screen     *ContentScreen
+	editWindow time.Duration
+	logger     zerolog.Logger
 }
 type ChatService interface {
 	ProcessMessage(ctx context.Context, message *domain.Message) error
-	GetRecentMessages(ctx context.Context, limit int) ([]*domain.Message, error)
-	GetMessageHistory(ctx context.Context, before time.Time, limit int) ([]*domain.Message, error)
+	GetRecentMessages(ctx context.Context, limit int, viewerID int64) ([]*domain.Message, error)
+	GetMessageHistory(ctx context.Context, before time.Time, limit int, viewerID int64) ([]*domain.Message, error)
+	GetMessagesAfter(ctx context.Context, afterID int64, limit int, viewerID int64) ([]*domain.Message, error)
+	GetMessage(ctx context.Context, id int64) (*domain.Message, error)
+	EditMessage(ctx context.Context, id, userID int64, content string) (*domain.Message, error)
+	DeleteMessage(ctx context.Context, id, userID int64, isModerator bool) (*domain.Message, error)
 }
 
-func NewChatService(repo repository.ChatRepository) ChatService {
+// NewChatService создаёт сервис чата. Автор может править своё сообщение
+// в течение editWindow после отправки.
+func NewChatService(repo repository.ChatRepository, reactions repository.ReactionRepository, mentions MentionService, notifier notifications.Notifier, auditor audit.Recorder, screen *ContentScreen, editWindow time.Duration) ChatService {
 	return &ChatServiceImpl{
-		repo:   repo,
-		logger: log.With().Str("component", "chat_service").Logger(),
+		repo:       repo,
+		reactions:  reactions,
+		mentions:   mentions,
+		notifier:   notifier,
+		auditor:    auditor,
+		screen:     screen,
+		editWindow: editWindow,
+		logger:     log.With().Str("component", "chat_service").Logger(),
 	}
 }
 
 func (s *ChatServiceImpl) ProcessMessage(ctx context.Context, message *domain.Message) error {
 	logger := s.logger.With().
+		Ctx(ctx).
 		Str("method", "ProcessMessage").
 		Str("username", message.Username).
 		Int64("user_id", message.UserID).
@@ -53,24 +78,51 @@ func (s *ChatServiceImpl) ProcessMessage(ctx context.Context, message *domain.Me
 	// Валидация содержания
 	message.Content = strings.TrimSpace(message.Content)
 	if message.Content == "" {
-		err := errors.New("message content cannot be empty")
+		err := domain.Invalid("message content cannot be empty")
 		logger.Warn().Err(err).Msg("Validation failed")
 		return err
 	}
-	if len(message.Content) > 500 {
-		err := errors.New("message too long (max 500 chars)")
+	if len(message.Content) > maxMessageLength {
+		err := domain.Invalid("message too long (max %d chars)", maxMessageLength)
 		logger.Warn().Err(err).
 			Int("content_length", len(message.Content)).
 			Msg("Validation failed")
 		return err
 	}
 
+	// Комната у чата одна, поэтому любое живое сообщение годится как цель ответа.
+	if message.ReplyTo != 0 {
+		target, err := s.repo.GetMessageByID(ctx, message.ReplyTo, 0)
+		if err != nil {
+			logger.Error().Err(err).Int64("reply_to", message.ReplyTo).Msg("Failed to get reply target")
+			return fmt.Errorf("failed to get message: %w", err)
+		}
+		if target == nil || target.Deleted {
+			logger.Warn().Int64("reply_to", message.ReplyTo).Msg("Reply target not found")
+			return domain.ErrReplyTargetNotFound
+		}
+		message.Quote = domain.NewMessageQuote(target)
+	}
+
+	content := &contentfilter.Content{
+		Kind:     domain.ReportTargetMessage,
+		AuthorID: message.UserID,
+		Text:     message.Content,
+	}
+	decision, err := s.screen.check(ctx, content)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Message rejected by content filter")
+		return err
+	}
+	message.Hidden = decision.Action >= contentfilter.ActionHold
+	message.Held = decision.Action == contentfilter.ActionHold
+
 	if message.CreatedAt == "" {
 		message.CreatedAt = time.Now().Format(time.RFC3339)
 		logger.Debug().Msg("Set default timestamp for message")
 	}
 
-	err := s.repo.SaveMessage(ctx, message)
+	err = s.repo.SaveMessage(ctx, message)
 	if err != nil {
 		logger.Error().Err(err).
 			Str("content_prefix", truncateString(message.Content, 20)).
@@ -78,16 +130,51 @@ func (s *ChatServiceImpl) ProcessMessage(ctx context.Context, message *domain.Me
 		return fmt.Errorf("failed to save message: %w", err)
 	}
 
+	s.screen.apply(ctx, content, decision, message.ID)
+	if message.Hidden {
+		// Скрытое сообщение видит только автор, поэтому ни ответ, ни
+		// упоминания в нём никого не уведомляют.
+		logger.Info().
+			Int64("message_id", message.ID).
+			Bool("held", message.Held).
+			Msg("Message saved hidden by content filter")
+		return nil
+	}
+
+	if message.Quote != nil {
+		if err := s.notifier.Notify(ctx, &notifications.Notification{
+			UserID:     message.Quote.UserID,
+			Kind:       notifications.KindChatReply,
+			ActorID:    message.UserID,
+			Actor:      message.Username,
+			TargetType: domain.MentionSourceMessage,
+			TargetID:   message.ID,
+			Text:       domain.Excerpt(message.Content),
+		}); err != nil {
+			logger.Error().Err(err).Msg("Failed to notify replied-to author")
+		}
+	}
+
+	s.mentions.Notify(domain.MentionSource{
+		Type:     domain.MentionSourceMessage,
+		ID:       message.ID,
+		AuthorID: message.UserID,
+		Author:   message.Username,
+		Text:     message.Content,
+	})
+
 	logger.Info().
 		Str("content_prefix", truncateString(message.Content, 20)).
 		Msg("Message processed successfully")
 	return nil
 }
 
-func (s *ChatServiceImpl) GetRecentMessages(ctx context.Context, limit int) ([]*domain.Message, error) {
+func (s *ChatServiceImpl) GetRecentMessages(ctx context.Context, limit int, viewerID int64) ([]*domain.Message, error) {
 	logger := s.logger.With().
+		Ctx(ctx).
 		Str("method", "GetRecentMessages").
 		Int("limit", limit).
+		Int64("viewer_id", viewerID).
 		Logger()
 
 	if limit <= 0 {
@@ -98,7 +185,7 @@ func (s *ChatServiceImpl) GetRecentMessages(ctx context.Context, limit int) ([]*
 		logger.Debug().Msg("Limiting maximum messages to 1000")
 	}
 
-	messages, err := s.repo.GetRecentMessages(ctx, limit)
+	messages, err := s.repo.GetRecentMessages(ctx, limit, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get recent messages from repository")
 		return nil, fmt.Errorf("failed to get messages: %w", err)
@@ -111,6 +198,7 @@ func (s *ChatServiceImpl) GetRecentMessages(ctx context.Context, limit int) ([]*
 				Msg("Set default timestamp for message")
 		}
 	}
+	s.loadReactions(ctx, messages)
 
 	logger.Debug().
 		Int("message_count", len(messages)).
@@ -118,11 +206,13 @@ func (s *ChatServiceImpl) GetRecentMessages(ctx context.Context, limit int) ([]*
 	return messages, nil
 }
 
-func (s *ChatServiceImpl) GetMessageHistory(ctx context.Context, before time.Time, limit int) ([]*domain.Message, error) {
+func (s *ChatServiceImpl) GetMessageHistory(ctx context.Context, before time.Time, limit int, viewerID int64) ([]*domain.Message, error) {
 	logger := s.logger.With().
+		Ctx(ctx).
 		Str("method", "GetMessageHistory").
 		Time("before", before).
 		Int("limit", limit).
+		Int64("viewer_id", viewerID).
 		Logger()
 
 	if limit <= 0 {
@@ -130,11 +220,12 @@ func (s *ChatServiceImpl) GetMessageHistory(ctx context.Context, before time.Tim
 		logger.Debug().Msg("Using default limit value")
 	}
 
-	messages, err := s.repo.GetMessageHistory(ctx, before, limit)
+	messages, err := s.repo.GetMessageHistory(ctx, before, limit, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get message history from repository")
 		return nil, fmt.Errorf("failed to get message history: %w", err)
 	}
+	s.loadReactions(ctx, messages)
 
 	logger.Debug().
 		Int("message_count", len(messages)).
@@ -142,6 +233,225 @@ func (s *ChatServiceImpl) GetMessageHistory(ctx context.Context, before time.Tim
 	return messages, nil
 }
 
+// GetMessagesAfter возвращает до limit самых новых сообщений после afterID по возрастанию ID.
+// Скрытые фильтром сообщения видит только их автор viewerID.
+func (s *ChatServiceImpl) GetMessagesAfter(ctx context.Context, afterID int64, limit int, viewerID int64) ([]*domain.Message, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "GetMessagesAfter").
+		Int64("after_id", afterID).
+		Int("limit", limit).
+		Int64("viewer_id", viewerID).
+		Logger()
+
+	if limit <= 0 {
+		limit = 50
+		logger.Debug().Msg("Using default limit value")
+	} else if limit > 1000 {
+		limit = 1000
+		logger.Debug().Msg("Limiting maximum messages to 1000")
+	}
+
+	messages, err := s.repo.GetMessagesAfter(ctx, afterID, limit, viewerID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get messages after ID from repository")
+		return nil, fmt.Errorf("failed to get messages: %w", err)
+	}
+	s.loadReactions(ctx, messages)
+
+	logger.Debug().
+		Int("message_count", len(messages)).
+		Msg("Retrieved messages after ID successfully")
+	return messages, nil
+}
+
+// GetMessage возвращает опубликованное сообщение с итогами реакций.
+func (s *ChatServiceImpl) GetMessage(ctx context.Context, id int64) (*domain.Message, error) {
+	message, err := s.getLiveMessage(ctx, id, 0)
+	if err != nil {
+		return nil, err
+	}
+	s.loadReactions(ctx, []*domain.Message{message})
+	return message, nil
+}
+
+func (s *ChatServiceImpl) EditMessage(ctx context.Context, id, userID int64, content string) (*domain.Message, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "EditMessage").
+		Int64("message_id", id).
+		Int64("user_id", userID).
+		Logger()
+
+	content = strings.TrimSpace(content)
+	if content == "" {
+		err := domain.Invalid("message content cannot be empty")
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+	if len(content) > maxMessageLength {
+		err := domain.Invalid("message too long (max %d chars)", maxMessageLength)
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+
+	message, err := s.getLiveMessage(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	if message.UserID != userID {
+		logger.Warn().Msg("Attempt to edit someone else's message")
+		return nil, domain.ErrNotMessageAuthor
+	}
+	if createdAt, err := time.Parse(time.RFC3339, message.CreatedAt); err == nil && time.Since(createdAt) > s.editWindow {
+		logger.Debug().Msg("Edit window expired")
+		return nil, domain.ErrEditWindowExpired
+	}
+
+	screened := &contentfilter.Content{
+		Kind:     domain.ReportTargetMessage,
+		AuthorID: userID,
+		Text:     content,
+	}
+	decision, err := s.screen.check(ctx, screened)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Edit rejected by content filter")
+		return nil, err
+	}
+	hide := decision.Action >= contentfilter.ActionHold
+
+	if err := s.repo.UpdateContent(ctx, id, content); err != nil {
+		if errors.Is(err, domain.ErrMessageNotFound) {
+			return nil, err
+		}
+		logger.Error().Err(err).Msg("Failed to update message in repository")
+		return nil, fmt.Errorf("failed to edit message: %w", err)
+	}
+	// Правка, которую фильтр скрыл бы в новом сообщении, снимает с публикации
+	// и уже разосланное. Обратно скрытое сообщение открывает только модератор.
+	if hide && !message.Hidden {
+		if err := s.repo.SetHidden(ctx, id, true); err != nil {
+			logger.Error().Err(err).Msg("Failed to hide edited message")
+			return nil, fmt.Errorf("failed to edit message: %w", err)
+		}
+	}
+
+	updated, err := s.getLiveMessage(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	updated.Held = decision.Action == contentfilter.ActionHold
+
+	s.screen.apply(ctx, screened, decision, id)
+	if updated.Hidden {
+		logger.Info().
+			Bool("held", updated.Held).
+			Msg("Edited message hidden by content filter")
+		return updated, nil
+	}
+
+	// Повторные упоминания отсекаются в репозитории, так что уведомлены будут
+	// только пользователи, добавленные правкой.
+	s.mentions.Notify(domain.MentionSource{
+		Type:     domain.MentionSourceMessage,
+		ID:       updated.ID,
+		AuthorID: updated.UserID,
+		Author:   updated.Username,
+		Text:     updated.Content,
+	})
+
+	logger.Info().Msg("Message edited successfully")
+	return updated, nil
+}
+
+// DeleteMessage удаляет сообщение. Автор может удалить своё сообщение в любое
+// время, модератор — любое; удаление чужого сообщения попадает в журнал аудита.
+func (s *ChatServiceImpl) DeleteMessage(ctx context.Context, id, userID int64, isModerator bool) (*domain.Message, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "DeleteMessage").
+		Int64("message_id", id).
+		Int64("user_id", userID).
+		Logger()
+
+	message, err := s.getLiveMessage(ctx, id, userID)
+	if err != nil {
+		return nil, err
+	}
+	ownMessage := message.UserID == userID
+	if !ownMessage && !isModerator {
+		logger.Warn().Msg("Attempt to delete someone else's message")
+		return nil, domain.ErrNotMessageAuthor
+	}
+
+	if err := s.repo.MarkDeleted(ctx, id, userID); err != nil {
+		if errors.Is(err, domain.ErrMessageNotFound) {
+			return nil, err
+		}
+		logger.Error().Err(err).Msg("Failed to delete message in repository")
+		return nil, fmt.Errorf("failed to delete message: %w", err)
+	}
+
+	if !ownMessage {
+		if err := s.auditor.Record(ctx, &audit.Event{
+			ActorID:    userID,
+			Action:     audit.ActionMessageDelete,
+			TargetType: domain.ReportTargetMessage,
+			TargetID:   strconv.FormatInt(id, 10),
+			Metadata:   map[string]interface{}{"author_id": message.UserID},
+		}); err != nil {
+			logger.Error().Err(err).Msg("Failed to record audit event")
+		}
+		if err := s.notifier.Notify(ctx, &notifications.Notification{
+			UserID:     message.UserID,
+			Kind:       notifications.KindModeration,
+			ActorID:    userID,
+			TargetType: domain.MentionSourceMessage,
+			TargetID:   id,
+			Text:       "A moderator deleted your chat message: " + domain.Excerpt(message.Content),
+		}); err != nil {
+			logger.Error().Err(err).Msg("Failed to notify message author")
+		}
+	}
+
+	message.Content = ""
+	message.Deleted = true
+
+	logger.Info().Bool("by_moderator", !ownMessage).Msg("Message deleted successfully")
+	return message, nil
+}
+
+// getLiveMessage возвращает неудалённое сообщение; скрытое фильтром находит
+// только его автор viewerID.
+func (s *ChatServiceImpl) getLiveMessage(ctx context.Context, id, viewerID int64) (*domain.Message, error) {
+	message, err := s.repo.GetMessageByID(ctx, id, viewerID)
+	if err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("message_id", id).Msg("Failed to get message from repository")
+		return nil, fmt.Errorf("failed to get message: %w", err)
+	}
+	if message == nil || message.Deleted {
+		return nil, domain.ErrMessageNotFound
+	}
+	return message, nil
+}
+
+// loadReactions заполняет итоги реакций; без них история всё равно отдаётся.
+func (s *ChatServiceImpl) loadReactions(ctx context.Context, messages []*domain.Message) {
+	ids := make([]int64, 0, len(messages))
+	for _, message := range messages {
+		ids = append(ids, message.ID)
+	}
+
+	counts, err := s.reactions.Counts(ctx, domain.ReactionTargetMessage, ids)
+	if err != nil {
+		s.logger.Warn().Ctx(ctx).Err(err).Str("method", "loadReactions").Msg("Failed to load message reactions")
+		return
+	}
+	for _, message := range messages {
+		message.Reactions = counts[message.ID]
+	}
+}
+
 func truncateString(s string, maxLen int) string {
 	if len(s) <= maxLen {
 		return s
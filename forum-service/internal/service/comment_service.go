@@ -0,0 +1,222 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/avatar"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/contentfilter"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/repository"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/markdown"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const maxCommentLength = 5000
+
+type CommentServiceImpl struct {
+	repo     repository.CommentRepository
+	postRepo repository.PostRepository
+	mentions MentionService
+	notifier notifications.Notifier
+	screen   *ContentScreen
+	renderer *markdown.Renderer
+	// avatarBaseURL — публичный адрес auth-service, который раздаёт аватары.
+	avatarBaseURL string
+	logger        zerolog.Logger
+}
+
+type CommentService interface {
+	CreateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error)
+	// GetComments отдаёт комментарии поста, если viewerID (0 — гость) его видит.
+	GetComments(ctx context.Context, postID, viewerID int64) ([]*domain.Comment, error)
+}
+
+func NewCommentService(repo repository.CommentRepository, postRepo repository.PostRepository, mentions MentionService, notifier notifications.Notifier, screen *ContentScreen, renderer *markdown.Renderer, avatarBaseURL string) CommentService {
+	return &CommentServiceImpl{
+		repo:          repo,
+		postRepo:      postRepo,
+		mentions:      mentions,
+		notifier:      notifier,
+		screen:        screen,
+		renderer:      renderer,
+		avatarBaseURL: avatarBaseURL,
+		logger:        log.With().Str("component", "comment_service").Logger(),
+	}
+}
+
+func (s *CommentServiceImpl) CreateComment(ctx context.Context, comment *domain.Comment) (*domain.Comment, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "CreateComment").
+		Int64("post_id", comment.PostID).
+		Int64("author_id", comment.AuthorID).
+		Logger()
+
+	comment.Content = strings.TrimSpace(comment.Content)
+	if comment.Content == "" {
+		err := domain.Invalid("comment content cannot be empty")
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+	if len(comment.Content) > maxCommentLength {
+		err := domain.Invalid("comment too long (max %d chars)", maxCommentLength)
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+	if comment.AuthorID == 0 {
+		err := errors.New("author ID is required")
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+
+	post, err := s.postRepo.GetByID(ctx, comment.PostID, comment.AuthorID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get post from repository")
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if post == nil {
+		return nil, domain.ErrPostNotFound
+	}
+	if post.Locked {
+		logger.Warn().Msg("Attempt to comment on locked post")
+		return nil, domain.ErrPostLocked
+	}
+
+	content := &contentfilter.Content{
+		Kind:     domain.ReportTargetComment,
+		AuthorID: comment.AuthorID,
+		Text:     comment.Content,
+	}
+	decision, err := s.screen.check(ctx, content)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Comment rejected by content filter")
+		return nil, err
+	}
+	comment.Hidden = decision.Action >= contentfilter.ActionHold
+	comment.Held = decision.Action == contentfilter.ActionHold
+
+	html, err := s.renderer.Render(comment.Content)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to render comment content")
+		return nil, fmt.Errorf("failed to render comment: %w", err)
+	}
+	comment.ContentHTML = html
+	comment.RenderVer = markdown.Version
+
+	id, err := s.repo.Create(ctx, comment)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to create comment in repository")
+		return nil, fmt.Errorf("failed to create comment: %w", err)
+	}
+
+	created, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.Error().Err(err).Int64("comment_id", id).Msg("Failed to fetch created comment")
+		return nil, fmt.Errorf("failed to fetch created comment: %w", err)
+	}
+
+	if created != nil {
+		created.Held = comment.Held
+		s.prepare(ctx, []*domain.Comment{created})
+	}
+
+	s.screen.apply(ctx, content, decision, id)
+	if comment.Hidden {
+		// Скрытый комментарий видит только автор, поэтому ни автор поста,
+		// ни упомянутые не уведомляются.
+		logger.Info().
+			Int64("comment_id", id).
+			Bool("held", comment.Held).
+			Msg("Comment saved hidden by content filter")
+		return created, nil
+	}
+
+	if err := s.notifier.Notify(ctx, &notifications.Notification{
+		UserID:     post.AuthorID,
+		Kind:       notifications.KindPostReply,
+		ActorID:    comment.AuthorID,
+		Actor:      comment.Author,
+		TargetType: domain.MentionSourceComment,
+		TargetID:   id,
+		PostID:     comment.PostID,
+		Text:       domain.Excerpt(comment.Content),
+	}); err != nil {
+		logger.Error().Err(err).Int64("comment_id", id).Msg("Failed to notify post author")
+	}
+
+	s.mentions.Notify(domain.MentionSource{
+		Type:     domain.MentionSourceComment,
+		ID:       id,
+		PostID:   comment.PostID,
+		AuthorID: comment.AuthorID,
+		Author:   comment.Author,
+		Text:     comment.Content,
+	})
+
+	logger.Info().Int64("comment_id", id).Msg("Comment created successfully")
+	return created, nil
+}
+
+func (s *CommentServiceImpl) GetComments(ctx context.Context, postID, viewerID int64) ([]*domain.Comment, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "GetComments").
+		Int64("post_id", postID).
+		Logger()
+
+	post, err := s.postRepo.GetByID(ctx, postID, viewerID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get post from repository")
+		return nil, fmt.Errorf("failed to get post: %w", err)
+	}
+	if post == nil {
+		return nil, domain.ErrPostNotFound
+	}
+
+	comments, err := s.repo.GetByPost(ctx, postID, viewerID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get comments from repository")
+		return nil, fmt.Errorf("failed to get comments: %w", err)
+	}
+	s.prepare(ctx, comments)
+
+	logger.Debug().
+		Int("comment_count", len(comments)).
+		Msg("Retrieved comments successfully")
+	return comments, nil
+}
+
+// prepare проставляет адрес аватара автора и перерисовывает HTML комментариев,
+// сохранённый устаревшей версией рендерера.
+func (s *CommentServiceImpl) prepare(ctx context.Context, comments []*domain.Comment) {
+	for _, comment := range comments {
+		comment.AvatarURL = avatar.URL(s.avatarBaseURL, comment.AuthorID)
+		if comment.RenderVer == markdown.Version {
+			continue
+		}
+
+		logger := s.logger.With().
+			Ctx(ctx).
+			Str("method", "prepare").
+			Int64("comment_id", comment.ID).
+			Int("render_version", comment.RenderVer).
+			Logger()
+
+		html, err := s.renderer.Render(comment.Content)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to re-render comment content")
+			continue
+		}
+		comment.ContentHTML = html
+		comment.RenderVer = markdown.Version
+
+		if err := s.repo.UpdateContentHTML(ctx, comment.ID, html, markdown.Version); err != nil {
+			logger.Warn().Err(err).Msg("Failed to store re-rendered comment HTML")
+		}
+	}
+}
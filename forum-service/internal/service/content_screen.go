@@ -0,0 +1,136 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/contentfilter"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/repository"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// ContentScreen прогоняет новые посты и сообщения через цепочку фильтров
+// контента и применяет её решение: отказ, скрытие без ведома автора или
+// скрытие с жалобой в очереди модерации. Каждое срабатывание попадает
+// в журнал аудита с кодами причин. Nil-экран пропускает всё.
+type ContentScreen struct {
+	chain   *contentfilter.Chain
+	reports repository.ReportRepository
+	auditor audit.Recorder
+	logger  zerolog.Logger
+}
+
+func NewContentScreen(chain *contentfilter.Chain, reports repository.ReportRepository, auditor audit.Recorder) *ContentScreen {
+	return &ContentScreen{
+		chain:   chain,
+		reports: reports,
+		auditor: auditor,
+		logger:  log.With().Str("component", "content_screen").Logger(),
+	}
+}
+
+// check проверяет контент до сохранения. Отклонённый контент сразу
+// записывается в журнал, а вызывающий получает *domain.ContentRejectedError.
+func (s *ContentScreen) check(ctx context.Context, content *contentfilter.Content) (contentfilter.Decision, error) {
+	if s == nil {
+		return contentfilter.Decision{}, nil
+	}
+
+	decision := s.chain.Check(ctx, content)
+	if decision.Action == contentfilter.ActionReject {
+		s.record(ctx, content, decision, 0)
+		return decision, &domain.ContentRejectedError{Reasons: decision.Reasons()}
+	}
+	return decision, nil
+}
+
+// apply вызывается после сохранения контента с ID targetID: записывает
+// сработавшие фильтры в журнал, а задержанный контент ставит в очередь
+// модерации жалобой от FilterReporterID. Отклонив жалобу, модератор
+// публикует контент.
+func (s *ContentScreen) apply(ctx context.Context, content *contentfilter.Content, decision contentfilter.Decision, targetID int64) {
+	if s == nil || len(decision.Verdicts) == 0 {
+		return
+	}
+	s.record(ctx, content, decision, targetID)
+
+	if decision.Action != contentfilter.ActionHold {
+		return
+	}
+	_, err := s.reports.Create(ctx, &domain.Report{
+		TargetType: content.Kind,
+		TargetID:   targetID,
+		ReporterID: domain.FilterReporterID,
+		Reason:     reportReason(decision),
+		Details:    describeDecision(decision),
+	})
+	if err != nil {
+		s.logger.Error().
+			Ctx(ctx).
+			Err(err).
+			Str("target_type", content.Kind).
+			Int64("target_id", targetID).
+			Msg("Failed to queue held content for moderation")
+	}
+}
+
+func (s *ContentScreen) record(ctx context.Context, content *contentfilter.Content, decision contentfilter.Decision, targetID int64) {
+	verdicts := make([]map[string]interface{}, 0, len(decision.Verdicts))
+	for _, v := range decision.Verdicts {
+		verdicts = append(verdicts, map[string]interface{}{
+			"filter": v.Filter,
+			"reason": v.Reason,
+			"action": v.Action.String(),
+			"detail": v.Detail,
+		})
+	}
+
+	event := &audit.Event{
+		ActorID:    content.AuthorID,
+		Action:     audit.ActionContentFiltered,
+		TargetType: content.Kind,
+		Metadata: map[string]interface{}{
+			"action":   decision.Action.String(),
+			"reasons":  decision.Reasons(),
+			"verdicts": verdicts,
+		},
+	}
+	if targetID != 0 {
+		event.TargetID = strconv.FormatInt(targetID, 10)
+	}
+
+	s.logger.Info().
+		Ctx(ctx).
+		Int64("author_id", content.AuthorID).
+		Str("target_type", content.Kind).
+		Str("action", decision.Action.String()).
+		Strs("reasons", decision.Reasons()).
+		Msg("Content filter triggered")
+	if err := s.auditor.Record(ctx, event); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Msg("Failed to record audit event")
+	}
+}
+
+// reportReason выбирает причину жалобы: спам, если сработало что-то кроме
+// списков слов.
+func reportReason(decision contentfilter.Decision) string {
+	for _, v := range decision.Verdicts {
+		if v.Reason != contentfilter.ReasonBlockedWord {
+			return domain.ReportReasonSpam
+		}
+	}
+	return domain.ReportReasonOther
+}
+
+func describeDecision(decision contentfilter.Decision) string {
+	parts := make([]string, 0, len(decision.Verdicts))
+	for _, v := range decision.Verdicts {
+		parts = append(parts, fmt.Sprintf("%s (%s)", v.Reason, v.Detail))
+	}
+	return truncateString("content filter: "+strings.Join(parts, "; "), maxReportDetailsLength)
+}
@@ -0,0 +1,119 @@
+package service
+
+import (
+	"context"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/repository"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// mentionTimeout ограничивает фоновый разбор упоминаний вместе с запросом в auth-service.
+const mentionTimeout = 10 * time.Second
+
+// UserResolver сопоставляет имена пользователей с ID (см. authclient.Client)
+// от имени пользователя actorID.
+type UserResolver interface {
+	ResolveUsernames(ctx context.Context, actorID int64, actor string, usernames []string) (map[string]int64, error)
+}
+
+type MentionServiceImpl struct {
+	repo     repository.MentionRepository
+	resolver UserResolver
+	notifier notifications.Notifier
+	logger   zerolog.Logger
+}
+
+type MentionService interface {
+	Notify(source domain.MentionSource)
+}
+
+func NewMentionService(repo repository.MentionRepository, resolver UserResolver, notifier notifications.Notifier) MentionService {
+	return &MentionServiceImpl{
+		repo:     repo,
+		resolver: resolver,
+		notifier: notifier,
+		logger:   log.With().Str("component", "mention_service").Logger(),
+	}
+}
+
+// Notify в фоне находит в тексте упоминания существующих пользователей,
+// сохраняет их и отправляет упомянутым уведомления. Упоминания несуществующих
+// имён остаются обычным текстом; себя упомянуть нельзя.
+func (s *MentionServiceImpl) Notify(source domain.MentionSource) {
+	usernames := domain.ExtractMentions(source.Text)
+	if len(usernames) == 0 {
+		return
+	}
+
+	go func() {
+		ctx, cancel := context.WithTimeout(context.Background(), mentionTimeout)
+		defer cancel()
+		s.process(ctx, source, usernames)
+	}()
+}
+
+func (s *MentionServiceImpl) process(ctx context.Context, source domain.MentionSource, usernames []string) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "Notify").
+		Str("source_type", source.Type).
+		Int64("source_id", source.ID).
+		Int64("author_id", source.AuthorID).
+		Logger()
+
+	ids, err := s.resolver.ResolveUsernames(ctx, source.AuthorID, source.Author, usernames)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to resolve mentioned usernames")
+		return
+	}
+
+	excerpt := domain.Excerpt(source.Text)
+	created := 0
+	for _, username := range usernames {
+		userID, ok := ids[username]
+		if !ok || userID == source.AuthorID {
+			continue
+		}
+
+		mention := &domain.Mention{
+			SourceType:      source.Type,
+			SourceID:        source.ID,
+			PostID:          source.PostID,
+			MentionedUserID: userID,
+			AuthorID:        source.AuthorID,
+			Author:          source.Author,
+			Excerpt:         excerpt,
+		}
+		ok, err := s.repo.Create(ctx, mention)
+		if err != nil {
+			logger.Error().Err(err).Int64("mentioned_user_id", userID).Msg("Failed to save mention")
+			continue
+		}
+		if !ok {
+			continue
+		}
+		created++
+
+		if err := s.notifier.Notify(ctx, &notifications.Notification{
+			UserID:     userID,
+			Kind:       notifications.KindMention,
+			ActorID:    source.AuthorID,
+			Actor:      source.Author,
+			TargetType: source.Type,
+			TargetID:   source.ID,
+			PostID:     source.PostID,
+			Text:       excerpt,
+		}); err != nil {
+			logger.Error().Err(err).Int64("mention_id", mention.ID).Msg("Failed to notify mentioned user")
+		}
+	}
+
+	logger.Info().
+		Int("mentioned", len(usernames)).
+		Int("created", created).
+		Msg("Mentions processed")
+}
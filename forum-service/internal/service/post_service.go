@@ -4,37 +4,65 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"strconv"
 	"strings"
 
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/avatar"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/contentfilter"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/repository"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/markdown"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 )
 
 type PostServiceImpl struct {
-	repo   repository.PostRepository
-	logger zerolog.Logger
+	repo        repository.PostRepository
+	attachments repository.AttachmentRepository
+	reactions   repository.ReactionRepository
+	mentions    MentionService
+	notifier    notifications.Notifier
+	auditor     audit.Recorder
+	screen      *ContentScreen
+	renderer    *markdown.Renderer
+	// avatarBaseURL — публичный адрес auth-service, который раздаёт аватары.
+	avatarBaseURL string
+	logger        zerolog.Logger
 }
 
 type PostService interface {
 	CreatePost(ctx context.Context, post *domain.Post) (*domain.Post, error)
-	GetPost(ctx context.Context, id int64) (*domain.Post, error)
-	GetAllPosts(ctx context.Context) ([]*domain.Post, error)
-	GetPostsPaginated(ctx context.Context, offset, limit int) ([]*domain.Post, error)
+	// viewerID — кто читает (0 — гость): скрытые фильтром посты видит
+	// только их автор.
+	GetPost(ctx context.Context, id, viewerID int64) (*domain.Post, error)
+	GetAllPosts(ctx context.Context, viewerID int64) ([]*domain.Post, error)
+	GetPostsPaginated(ctx context.Context, offset, limit int, viewerID int64) ([]*domain.Post, error)
 	DeletePost(ctx context.Context, id, authorID int64) error
-	GetPostsWithAuthors(ctx context.Context) ([]*domain.Post, error)
+	GetPostsWithAuthors(ctx context.Context, viewerID int64) ([]*domain.Post, error)
+	GetAnnouncements(ctx context.Context) ([]*domain.Post, error)
+	UpdateFlags(ctx context.Context, id int64, update domain.PostFlagsUpdate, moderatorID int64) (*domain.Post, error)
 }
 
-func NewPostService(repo repository.PostRepository) PostService {
+func NewPostService(repo repository.PostRepository, attachments repository.AttachmentRepository, reactions repository.ReactionRepository, mentions MentionService, notifier notifications.Notifier, auditor audit.Recorder, screen *ContentScreen, renderer *markdown.Renderer, avatarBaseURL string) PostService {
 	return &PostServiceImpl{
-		repo:   repo,
-		logger: log.With().Str("component", "post_service").Logger(),
+		repo:          repo,
+		attachments:   attachments,
+		reactions:     reactions,
+		mentions:      mentions,
+		notifier:      notifier,
+		auditor:       auditor,
+		screen:        screen,
+		renderer:      renderer,
+		avatarBaseURL: avatarBaseURL,
+		logger:        log.With().Str("component", "post_service").Logger(),
 	}
 }
 
 func (s *PostServiceImpl) CreatePost(ctx context.Context, post *domain.Post) (*domain.Post, error) {
 	logger := s.logger.With().
+		Ctx(ctx).
 		Str("method", "CreatePost").
 		Str("title", post.Title).
 		Int64("author_id", post.AuthorID).
@@ -45,12 +73,12 @@ func (s *PostServiceImpl) CreatePost(ctx context.Context, post *domain.Post) (*d
 	post.Content = strings.TrimSpace(post.Content)
 
 	if len(post.Title) < 3 || len(post.Title) > 100 {
-		err := errors.New("title must be between 3-100 characters")
+		err := domain.Invalid("title must be between 3-100 characters")
 		logger.Warn().Err(err).Msg("Validation failed")
 		return nil, err
 	}
 	if len(post.Content) < 10 {
-		err := errors.New("content must be at least 10 characters")
+		err := domain.Invalid("content must be at least 10 characters")
 		logger.Warn().Err(err).Msg("Validation failed")
 		return nil, err
 	}
@@ -60,62 +88,144 @@ func (s *PostServiceImpl) CreatePost(ctx context.Context, post *domain.Post) (*d
 		return nil, err
 	}
 
+	content := &contentfilter.Content{
+		Kind:     domain.ReportTargetPost,
+		AuthorID: post.AuthorID,
+		Title:    post.Title,
+		Text:     post.Content,
+	}
+	decision, err := s.screen.check(ctx, content)
+	if err != nil {
+		logger.Warn().Err(err).Msg("Post rejected by content filter")
+		return nil, err
+	}
+	post.Hidden = decision.Action >= contentfilter.ActionHold
+	post.Held = decision.Action == contentfilter.ActionHold
+
+	if len(post.AttachmentIDs) > 0 {
+		linkable, err := s.attachments.CountUnlinked(ctx, post.AttachmentIDs, post.AuthorID)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to check attachments")
+			return nil, fmt.Errorf("failed to check attachments: %w", err)
+		}
+		if linkable != len(post.AttachmentIDs) {
+			logger.Warn().Ints64("attachment_ids", post.AttachmentIDs).Msg("Attachments not owned or already attached")
+			return nil, domain.ErrAttachmentNotFound
+		}
+	}
+
+	html, err := s.renderer.Render(post.Content)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to render post content")
+		return nil, fmt.Errorf("failed to render post: %w", err)
+	}
+	post.ContentHTML = html
+	post.RenderVer = markdown.Version
+
 	id, err := s.repo.Create(ctx, post)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to create post in repository")
 		return nil, fmt.Errorf("failed to create post: %w", err)
 	}
 
-	createdPost, err := s.repo.GetByID(ctx, id)
+	if len(post.AttachmentIDs) > 0 {
+		if err := s.attachments.AttachToPost(ctx, post.AttachmentIDs, id, post.AuthorID); err != nil {
+			logger.Error().Err(err).Int64("post_id", id).Msg("Failed to attach uploads")
+			// Пост без вложений удаляется, иначе повтор запроса создал бы
+			// дубликат. Контекст запроса к этому моменту может быть отменён.
+			if err := s.repo.Delete(context.WithoutCancel(ctx), id, post.AuthorID); err != nil {
+				logger.Error().Err(err).Int64("post_id", id).Msg("Failed to remove post after attach failure")
+			}
+			return nil, fmt.Errorf("failed to attach uploads: %w", err)
+		}
+	}
+
+	// Пост сохранён целиком: дальше фильтр записывает решение и ставит
+	// задержанный пост в очередь модерации, даже если перечитать его не удастся.
+	s.screen.apply(ctx, content, decision, id)
+
+	// Скрытый пост автор видит, поэтому при теневом скрытии он получает
+	// его как обычный опубликованный.
+	createdPost, err := s.repo.GetByID(ctx, id, post.AuthorID)
 	if err != nil {
 		logger.Error().Err(err).
 			Int64("post_id", id).
 			Msg("Failed to fetch created post")
 		return nil, fmt.Errorf("failed to fetch created post: %w", err)
 	}
+	if createdPost != nil {
+		createdPost.Held = post.Held
+		s.prepare(ctx, createdPost)
+		if createdPost.Attachments, err = s.attachments.GetByPost(ctx, id); err != nil {
+			logger.Error().Err(err).Int64("post_id", id).Msg("Failed to fetch post attachments")
+			return nil, fmt.Errorf("failed to fetch attachments: %w", err)
+		}
+	}
+
+	// Упоминания из скрытого поста не рассылаются: их адресаты его не увидят.
+	if !post.Hidden {
+		s.mentions.Notify(domain.MentionSource{
+			Type:     domain.MentionSourcePost,
+			ID:       id,
+			PostID:   id,
+			AuthorID: post.AuthorID,
+			Author:   post.Author,
+			Text:     post.Content,
+		})
+	}
 
 	logger.Info().
 		Int64("post_id", id).
+		Bool("hidden", post.Hidden).
 		Msg("Post created successfully")
 	return createdPost, nil
 }
 
-func (s *PostServiceImpl) GetPost(ctx context.Context, id int64) (*domain.Post, error) {
+func (s *PostServiceImpl) GetPost(ctx context.Context, id, viewerID int64) (*domain.Post, error) {
 	logger := s.logger.With().
+		Ctx(ctx).
 		Str("method", "GetPost").
 		Int64("post_id", id).
 		Logger()
 
 	if id <= 0 {
-		err := errors.New("invalid post ID")
+		err := domain.Invalid("invalid post ID")
 		logger.Warn().Err(err).Msg("Validation failed")
 		return nil, err
 	}
 
-	post, err := s.repo.GetByID(ctx, id)
+	post, err := s.repo.GetByID(ctx, id, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get post from repository")
 		return nil, fmt.Errorf("failed to get post: %w", err)
 	}
 	if post == nil {
 		logger.Debug().Msg("Post not found")
-		return nil, errors.New("post not found")
+		return nil, domain.ErrPostNotFound
+	}
+	s.prepare(ctx, post)
+
+	if post.Attachments, err = s.attachments.GetByPost(ctx, id); err != nil {
+		logger.Error().Err(err).Msg("Failed to get post attachments")
+		return nil, fmt.Errorf("failed to get attachments: %w", err)
 	}
 
 	logger.Debug().Msg("Post retrieved successfully")
 	return post, nil
 }
 
-func (s *PostServiceImpl) GetAllPosts(ctx context.Context) ([]*domain.Post, error) {
+func (s *PostServiceImpl) GetAllPosts(ctx context.Context, viewerID int64) ([]*domain.Post, error) {
 	logger := s.logger.With().
+		Ctx(ctx).
 		Str("method", "GetAllPosts").
 		Logger()
 
-	posts, err := s.repo.GetAll(ctx)
+	posts, err := s.repo.GetAll(ctx, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get posts from repository")
 		return nil, fmt.Errorf("failed to get posts: %w", err)
 	}
+	s.prepare(ctx, posts...)
 
 	logger.Debug().
 		Int("post_count", len(posts)).
@@ -123,8 +233,9 @@ func (s *PostServiceImpl) GetAllPosts(ctx context.Context) ([]*domain.Post, erro
 	return posts, nil
 }
 
-func (s *PostServiceImpl) GetPostsPaginated(ctx context.Context, offset, limit int) ([]*domain.Post, error) {
+func (s *PostServiceImpl) GetPostsPaginated(ctx context.Context, offset, limit int, viewerID int64) ([]*domain.Post, error) {
 	logger := s.logger.With().
+		Ctx(ctx).
 		Str("method", "GetPostsPaginated").
 		Int("offset", offset).
 		Int("limit", limit).
@@ -139,11 +250,12 @@ func (s *PostServiceImpl) GetPostsPaginated(ctx context.Context, offset, limit i
 		logger.Debug().Msg("Using default offset value")
 	}
 
-	posts, err := s.repo.GetPostsPaginated(ctx, offset, limit)
+	posts, err := s.repo.GetPostsPaginated(ctx, offset, limit, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get paginated posts from repository")
 		return nil, fmt.Errorf("failed to get paginated posts: %w", err)
 	}
+	s.prepare(ctx, posts...)
 
 	logger.Debug().
 		Int("post_count", len(posts)).
@@ -153,40 +265,216 @@ func (s *PostServiceImpl) GetPostsPaginated(ctx context.Context, offset, limit i
 
 func (s *PostServiceImpl) DeletePost(ctx context.Context, id, authorID int64) error {
 	logger := s.logger.With().
+		Ctx(ctx).
 		Str("method", "DeletePost").
 		Int64("post_id", id).
 		Int64("author_id", authorID).
 		Logger()
 
 	if id <= 0 || authorID <= 0 {
-		err := errors.New("invalid ID")
+		err := domain.Invalid("invalid ID")
 		logger.Warn().Err(err).Msg("Validation failed")
 		return err
 	}
 
-	err := s.repo.Delete(ctx, id, authorID)
+	post, err := s.repo.GetByID(ctx, id, authorID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get post from repository")
+		return fmt.Errorf("failed to get post: %w", err)
+	}
+	if post != nil && post.Locked {
+		logger.Warn().Msg("Attempt to delete locked post")
+		return domain.ErrPostLocked
+	}
+
+	err = s.repo.Delete(ctx, id, authorID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to delete post in repository")
 		return fmt.Errorf("failed to delete post: %w", err)
 	}
 
+	if err := s.auditor.Record(ctx, &audit.Event{
+		ActorID:    authorID,
+		Action:     audit.ActionPostDelete,
+		TargetType: domain.ReportTargetPost,
+		TargetID:   strconv.FormatInt(id, 10),
+	}); err != nil {
+		logger.Error().Err(err).Msg("Failed to record audit event")
+	}
+
 	logger.Info().Msg("Post deleted successfully")
 	return nil
 }
 
-func (s *PostServiceImpl) GetPostsWithAuthors(ctx context.Context) ([]*domain.Post, error) {
+func (s *PostServiceImpl) GetPostsWithAuthors(ctx context.Context, viewerID int64) ([]*domain.Post, error) {
 	logger := s.logger.With().
+		Ctx(ctx).
 		Str("method", "GetPostsWithAuthors").
 		Logger()
 
-	posts, err := s.repo.GetPostsWithAuthors(ctx)
+	posts, err := s.repo.GetPostsWithAuthors(ctx, viewerID)
 	if err != nil {
 		logger.Error().Err(err).Msg("Failed to get posts with authors from repository")
 		return nil, fmt.Errorf("failed to get posts with authors: %w", err)
 	}
+	s.prepare(ctx, posts...)
 
 	logger.Debug().
 		Int("post_count", len(posts)).
 		Msg("Retrieved posts with authors successfully")
 	return posts, nil
 }
+
+func (s *PostServiceImpl) GetAnnouncements(ctx context.Context) ([]*domain.Post, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "GetAnnouncements").
+		Logger()
+
+	posts, err := s.repo.GetAnnouncements(ctx)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get announcements from repository")
+		return nil, fmt.Errorf("failed to get announcements: %w", err)
+	}
+	s.prepare(ctx, posts...)
+
+	logger.Debug().
+		Int("post_count", len(posts)).
+		Msg("Retrieved announcements successfully")
+	return posts, nil
+}
+
+func (s *PostServiceImpl) UpdateFlags(ctx context.Context, id int64, update domain.PostFlagsUpdate, moderatorID int64) (*domain.Post, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "UpdateFlags").
+		Int64("post_id", id).
+		Int64("moderator_id", moderatorID).
+		Logger()
+
+	if update.IsEmpty() {
+		err := domain.Invalid("at least one of pinned, locked, announcement is required")
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+
+	if err := s.repo.UpdateFlags(ctx, id, update); err != nil {
+		if errors.Is(err, domain.ErrPostNotFound) {
+			return nil, err
+		}
+		logger.Error().Err(err).Msg("Failed to update post flags in repository")
+		return nil, fmt.Errorf("failed to update post flags: %w", err)
+	}
+
+	post, err := s.repo.GetByID(ctx, id, moderatorID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch updated post")
+		return nil, fmt.Errorf("failed to fetch updated post: %w", err)
+	}
+	if post == nil {
+		return nil, domain.ErrPostNotFound
+	}
+	s.prepare(ctx, post)
+
+	if err := s.auditor.Record(ctx, &audit.Event{
+		ActorID:    moderatorID,
+		Action:     audit.ActionPostFlagsUpdate,
+		TargetType: domain.ReportTargetPost,
+		TargetID:   strconv.FormatInt(id, 10),
+		Metadata: map[string]interface{}{
+			"pinned":       post.Pinned,
+			"locked":       post.Locked,
+			"announcement": post.Announcement,
+		},
+	}); err != nil {
+		logger.Error().Err(err).Msg("Failed to record audit event")
+	}
+
+	if err := s.notifier.Notify(ctx, &notifications.Notification{
+		UserID:     post.AuthorID,
+		Kind:       notifications.KindModeration,
+		ActorID:    moderatorID,
+		TargetType: domain.ReportTargetPost,
+		TargetID:   id,
+		PostID:     id,
+		Text:       fmt.Sprintf("A moderator updated your post %q: %s", post.Title, describeFlags(update)),
+	}); err != nil {
+		logger.Error().Err(err).Msg("Failed to notify post author")
+	}
+
+	logger.Info().
+		Bool("pinned", post.Pinned).
+		Bool("locked", post.Locked).
+		Bool("announcement", post.Announcement).
+		Msg("Post flags updated successfully")
+	return post, nil
+}
+
+// prepare заполняет вычисляемые поля постов перед отдачей клиенту: адрес аватара
+// автора, итоги реакций и HTML. HTML, сохранённый устаревшей версией рендерера, перерисовывается
+// и записывается обратно, чтобы следующие чтения брали его из базы.
+// Ошибки не прерывают чтение: пост отдаётся с тем HTML, что удалось получить.
+func (s *PostServiceImpl) prepare(ctx context.Context, posts ...*domain.Post) {
+	s.loadReactions(ctx, posts)
+
+	for _, post := range posts {
+		post.AvatarURL = avatar.URL(s.avatarBaseURL, post.AuthorID)
+		if post.RenderVer == markdown.Version {
+			continue
+		}
+
+		logger := s.logger.With().
+			Ctx(ctx).
+			Str("method", "prepare").
+			Int64("post_id", post.ID).
+			Int("render_version", post.RenderVer).
+			Logger()
+
+		html, err := s.renderer.Render(post.Content)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to re-render post content")
+			continue
+		}
+		post.ContentHTML = html
+		post.RenderVer = markdown.Version
+
+		if err := s.repo.UpdateContentHTML(ctx, post.ID, html, markdown.Version); err != nil {
+			logger.Warn().Err(err).Msg("Failed to store re-rendered post HTML")
+		}
+	}
+}
+
+func (s *PostServiceImpl) loadReactions(ctx context.Context, posts []*domain.Post) {
+	ids := make([]int64, 0, len(posts))
+	for _, post := range posts {
+		ids = append(ids, post.ID)
+	}
+
+	counts, err := s.reactions.Counts(ctx, domain.ReactionTargetPost, ids)
+	if err != nil {
+		s.logger.Warn().Ctx(ctx).Err(err).Str("method", "loadReactions").Msg("Failed to load post reactions")
+		return
+	}
+	for _, post := range posts {
+		post.Reactions = counts[post.ID]
+	}
+}
+
+// describeFlags перечисляет изменённые флаги поста словами, например "pinned, unlocked".
+func describeFlags(update domain.PostFlagsUpdate) string {
+	var changes []string
+	describe := func(flag *bool, on, off string) {
+		if flag == nil {
+			return
+		}
+		if *flag {
+			changes = append(changes, on)
+		} else {
+			changes = append(changes, off)
+		}
+	}
+	describe(update.Pinned, "pinned", "unpinned")
+	describe(update.Locked, "locked", "unlocked")
+	describe(update.Announcement, "marked as announcement", "no longer an announcement")
+	return strings.Join(changes, ", ")
+}
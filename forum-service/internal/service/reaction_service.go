@@ -0,0 +1,112 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/repository"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+type ReactionServiceImpl struct {
+	repo     repository.ReactionRepository
+	postRepo repository.PostRepository
+	chatRepo repository.ChatRepository
+	logger   zerolog.Logger
+}
+
+type ReactionService interface {
+	ToggleReaction(ctx context.Context, targetType string, targetID, userID int64, emoji string) (*domain.ReactionUpdate, error)
+}
+
+func NewReactionService(repo repository.ReactionRepository, postRepo repository.PostRepository, chatRepo repository.ChatRepository) ReactionService {
+	return &ReactionServiceImpl{
+		repo:     repo,
+		postRepo: postRepo,
+		chatRepo: chatRepo,
+		logger:   log.With().Str("component", "reaction_service").Logger(),
+	}
+}
+
+// ToggleReaction ставит или снимает реакцию пользователя на пост или сообщение
+// и возвращает новые итоги по цели, готовые к рассылке клиентам.
+func (s *ReactionServiceImpl) ToggleReaction(ctx context.Context, targetType string, targetID, userID int64, emoji string) (*domain.ReactionUpdate, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "ToggleReaction").
+		Str("target_type", targetType).
+		Int64("target_id", targetID).
+		Int64("user_id", userID).
+		Logger()
+
+	if userID <= 0 {
+		err := errors.New("user ID is required")
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+	if !domain.IsValidReactionTarget(targetType) {
+		err := fmt.Errorf("invalid target type %q", targetType)
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+	if !domain.IsValidReaction(emoji) {
+		logger.Warn().Str("emoji", emoji).Msg("Unsupported reaction")
+		return nil, domain.ErrInvalidReaction
+	}
+
+	if err := s.checkTarget(ctx, targetType, targetID, userID); err != nil {
+		return nil, err
+	}
+
+	added, err := s.repo.Toggle(ctx, targetType, targetID, userID, emoji)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to toggle reaction in repository")
+		return nil, fmt.Errorf("failed to toggle reaction: %w", err)
+	}
+
+	counts, err := s.repo.Counts(ctx, targetType, []int64{targetID})
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get reaction counts")
+		return nil, fmt.Errorf("failed to get reactions: %w", err)
+	}
+
+	update := &domain.ReactionUpdate{
+		TargetType: targetType,
+		TargetID:   targetID,
+		UserID:     userID,
+		Emoji:      emoji,
+		Added:      added,
+		Reactions:  counts[targetID],
+	}
+	if update.Reactions == nil {
+		update.Reactions = []domain.ReactionCount{}
+	}
+
+	logger.Info().Bool("added", added).Msg("Reaction toggled successfully")
+	return update, nil
+}
+
+func (s *ReactionServiceImpl) checkTarget(ctx context.Context, targetType string, targetID, userID int64) error {
+	switch targetType {
+	case domain.ReactionTargetPost:
+		post, err := s.postRepo.GetByID(ctx, targetID, userID)
+		if err != nil {
+			return fmt.Errorf("failed to get post: %w", err)
+		}
+		if post == nil {
+			return domain.ErrPostNotFound
+		}
+	case domain.ReactionTargetMessage:
+		message, err := s.chatRepo.GetMessageByID(ctx, targetID, 0)
+		if err != nil {
+			return fmt.Errorf("failed to get message: %w", err)
+		}
+		if message == nil || message.Deleted {
+			return domain.ErrMessageNotFound
+		}
+	}
+	return nil
+}
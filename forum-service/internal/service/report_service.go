@@ -0,0 +1,327 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/audit"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/repository"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const maxReportDetailsLength = 1000
+
+type ReportServiceImpl struct {
+	repo          repository.ReportRepository
+	postRepo      repository.PostRepository
+	chatRepo      repository.ChatRepository
+	commentRepo   repository.CommentRepository
+	auditor       audit.Recorder
+	notifier      notifications.Notifier
+	hideThreshold int
+	logger        zerolog.Logger
+}
+
+type ReportService interface {
+	CreateReport(ctx context.Context, report *domain.Report) (*domain.Report, error)
+	ListReports(ctx context.Context, filter domain.ReportFilter) ([]*domain.Report, error)
+	ResolveReport(ctx context.Context, id int64, status string, moderatorID int64) (*domain.Report, error)
+}
+
+// NewReportService создаёт сервис жалоб. Контент скрывается автоматически, как только
+// число открытых жалоб на него достигает hideThreshold (0 отключает автоскрытие).
+func NewReportService(repo repository.ReportRepository, postRepo repository.PostRepository, chatRepo repository.ChatRepository, commentRepo repository.CommentRepository, auditor audit.Recorder, notifier notifications.Notifier, hideThreshold int) ReportService {
+	return &ReportServiceImpl{
+		repo:          repo,
+		postRepo:      postRepo,
+		chatRepo:      chatRepo,
+		commentRepo:   commentRepo,
+		auditor:       auditor,
+		notifier:      notifier,
+		hideThreshold: hideThreshold,
+		logger:        log.With().Str("component", "report_service").Logger(),
+	}
+}
+
+func (s *ReportServiceImpl) CreateReport(ctx context.Context, report *domain.Report) (*domain.Report, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "CreateReport").
+		Str("target_type", report.TargetType).
+		Int64("target_id", report.TargetID).
+		Int64("reporter_id", report.ReporterID).
+		Logger()
+
+	report.Details = strings.TrimSpace(report.Details)
+	if report.ReporterID <= 0 {
+		err := errors.New("reporter ID is required")
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+	if !domain.IsValidReportReason(report.Reason) {
+		err := domain.Invalid("invalid report reason: %q", report.Reason)
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+	if len(report.Details) > maxReportDetailsLength {
+		err := domain.Invalid("details too long (max %d chars)", maxReportDetailsLength)
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+
+	exists, err := s.targetExists(ctx, report.TargetType, report.TargetID)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to check report target")
+		return nil, fmt.Errorf("failed to check report target: %w", err)
+	}
+	if !exists {
+		logger.Warn().Msg("Report target not found")
+		return nil, domain.ErrTargetNotFound
+	}
+
+	id, err := s.repo.Create(ctx, report)
+	if err != nil {
+		if errors.Is(err, domain.ErrAlreadyReported) {
+			logger.Debug().Msg("Duplicate report")
+			return nil, err
+		}
+		logger.Error().Err(err).Msg("Failed to create report in repository")
+		return nil, fmt.Errorf("failed to create report: %w", err)
+	}
+
+	s.recordAudit(ctx, &audit.Event{
+		ActorID:    report.ReporterID,
+		Action:     audit.ActionReportCreate,
+		TargetType: report.TargetType,
+		TargetID:   strconv.FormatInt(report.TargetID, 10),
+		Metadata:   map[string]interface{}{"report_id": id, "reason": report.Reason},
+	})
+
+	if err := s.syncVisibility(ctx, report.TargetType, report.TargetID); err != nil {
+		logger.Error().Err(err).Msg("Failed to apply report threshold")
+	}
+
+	created, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.Error().Err(err).Int64("report_id", id).Msg("Failed to fetch created report")
+		return nil, fmt.Errorf("failed to fetch created report: %w", err)
+	}
+
+	logger.Info().Int64("report_id", id).Msg("Report created successfully")
+	return created, nil
+}
+
+func (s *ReportServiceImpl) ListReports(ctx context.Context, filter domain.ReportFilter) ([]*domain.Report, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "ListReports").
+		Str("status", filter.Status).
+		Logger()
+
+	if filter.Status != "" && !domain.IsValidReportStatus(filter.Status) {
+		err := domain.Invalid("invalid report status: %q", filter.Status)
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+	if filter.Limit <= 0 || filter.Limit > 100 {
+		filter.Limit = 50
+		logger.Debug().Msg("Using default limit value")
+	}
+	if filter.Offset < 0 {
+		filter.Offset = 0
+	}
+
+	reports, err := s.repo.List(ctx, filter)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to list reports from repository")
+		return nil, fmt.Errorf("failed to list reports: %w", err)
+	}
+
+	logger.Debug().
+		Int("report_count", len(reports)).
+		Msg("Retrieved reports successfully")
+	return reports, nil
+}
+
+func (s *ReportServiceImpl) ResolveReport(ctx context.Context, id int64, status string, moderatorID int64) (*domain.Report, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "ResolveReport").
+		Int64("report_id", id).
+		Str("status", status).
+		Int64("moderator_id", moderatorID).
+		Logger()
+
+	if !domain.IsValidReportStatus(status) {
+		err := domain.Invalid("invalid report status: %q", status)
+		logger.Warn().Err(err).Msg("Validation failed")
+		return nil, err
+	}
+
+	if err := s.repo.UpdateStatus(ctx, id, status, moderatorID); err != nil {
+		if errors.Is(err, domain.ErrReportNotFound) {
+			return nil, err
+		}
+		logger.Error().Err(err).Msg("Failed to update report in repository")
+		return nil, fmt.Errorf("failed to resolve report: %w", err)
+	}
+
+	report, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to fetch resolved report")
+		return nil, fmt.Errorf("failed to fetch report: %w", err)
+	}
+	if report == nil {
+		return nil, domain.ErrReportNotFound
+	}
+
+	if status == domain.ReportStatusActioned {
+		// Автора ищем до скрытия: скрытый контент репозитории уже не отдают.
+		authorID, authorErr := s.contentAuthor(ctx, report.TargetType, report.TargetID)
+		if authorErr != nil {
+			logger.Warn().Err(authorErr).Msg("Failed to find content author")
+		}
+		err = s.setHidden(ctx, report.TargetType, report.TargetID, true)
+		if err == nil {
+			s.notifyRemoved(ctx, report, authorID, moderatorID)
+		}
+	} else if report.ReporterID == domain.FilterReporterID && status == domain.ReportStatusDismissed {
+		// Модератор одобрил контент, задержанный фильтром: публикуем его,
+		// если жалоб пользователей не набралось на скрытие.
+		err = s.setHidden(ctx, report.TargetType, report.TargetID, false)
+		if err == nil {
+			err = s.syncVisibility(ctx, report.TargetType, report.TargetID)
+		}
+	} else {
+		err = s.syncVisibility(ctx, report.TargetType, report.TargetID)
+	}
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to update content visibility")
+	}
+
+	s.recordAudit(ctx, &audit.Event{
+		ActorID:    moderatorID,
+		Action:     audit.ActionReportResolve,
+		TargetType: report.TargetType,
+		TargetID:   strconv.FormatInt(report.TargetID, 10),
+		Metadata:   map[string]interface{}{"report_id": id, "status": status},
+	})
+
+	logger.Info().Msg("Report resolved successfully")
+	return report, nil
+}
+
+// contentAuthor возвращает автора контента, включая скрытый фильтром или
+// по жалобам; 0 — контента нет.
+func (s *ReportServiceImpl) contentAuthor(ctx context.Context, targetType string, targetID int64) (int64, error) {
+	switch targetType {
+	case domain.ReportTargetPost:
+		return s.postRepo.GetAuthorID(ctx, targetID)
+	case domain.ReportTargetMessage:
+		return s.chatRepo.GetAuthorID(ctx, targetID)
+	case domain.ReportTargetComment:
+		comment, err := s.commentRepo.GetByID(ctx, targetID)
+		if err != nil || comment == nil {
+			return 0, err
+		}
+		return comment.AuthorID, nil
+	default:
+		return 0, fmt.Errorf("unknown report target type: %q", targetType)
+	}
+}
+
+// notifyRemoved сообщает автору, что его контент скрыт по жалобе.
+func (s *ReportServiceImpl) notifyRemoved(ctx context.Context, report *domain.Report, authorID, moderatorID int64) {
+	if authorID == 0 {
+		return
+	}
+
+	n := &notifications.Notification{
+		UserID:     authorID,
+		Kind:       notifications.KindModeration,
+		ActorID:    moderatorID,
+		TargetType: report.TargetType,
+		TargetID:   report.TargetID,
+		Text:       fmt.Sprintf("Your %s was removed by a moderator (reason: %s)", report.TargetType, report.Reason),
+	}
+	switch report.TargetType {
+	case domain.ReportTargetPost:
+		n.PostID = report.TargetID
+	case domain.ReportTargetComment:
+		if comment, err := s.commentRepo.GetByID(ctx, report.TargetID); err == nil && comment != nil {
+			n.PostID = comment.PostID
+		}
+	}
+	if err := s.notifier.Notify(ctx, n); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Int64("report_id", report.ID).Msg("Failed to notify content author")
+	}
+}
+
+// targetExists проверяет, что контент есть, в том числе скрытый: на него
+// тоже можно пожаловаться.
+func (s *ReportServiceImpl) targetExists(ctx context.Context, targetType string, targetID int64) (bool, error) {
+	if targetID <= 0 {
+		return false, nil
+	}
+	authorID, err := s.contentAuthor(ctx, targetType, targetID)
+	return authorID != 0, err
+}
+
+// syncVisibility скрывает контент при достижении порога открытых жалоб
+// и возвращает его, когда после отклонения жалоб их стало меньше порога.
+// Контент, по которому модератор уже принял меры, остаётся скрытым.
+func (s *ReportServiceImpl) syncVisibility(ctx context.Context, targetType string, targetID int64) error {
+	if s.hideThreshold <= 0 {
+		return nil
+	}
+
+	actioned, err := s.repo.CountForTarget(ctx, targetType, targetID, domain.ReportStatusActioned)
+	if err != nil {
+		return err
+	}
+	if actioned > 0 {
+		return s.setHidden(ctx, targetType, targetID, true)
+	}
+
+	open, err := s.repo.CountForTarget(ctx, targetType, targetID, domain.ReportStatusOpen)
+	if err != nil {
+		return err
+	}
+
+	hidden := open >= s.hideThreshold
+	if open == s.hideThreshold {
+		s.recordAudit(ctx, &audit.Event{
+			Action:     audit.ActionContentAutoHidden,
+			TargetType: targetType,
+			TargetID:   strconv.FormatInt(targetID, 10),
+			Metadata:   map[string]interface{}{"open_reports": open, "threshold": s.hideThreshold},
+		})
+	}
+
+	return s.setHidden(ctx, targetType, targetID, hidden)
+}
+
+func (s *ReportServiceImpl) recordAudit(ctx context.Context, event *audit.Event) {
+	if err := s.auditor.Record(ctx, event); err != nil {
+		s.logger.Error().Ctx(ctx).Err(err).Str("action", event.Action).Msg("Failed to record audit event")
+	}
+}
+
+func (s *ReportServiceImpl) setHidden(ctx context.Context, targetType string, targetID int64, hidden bool) error {
+	switch targetType {
+	case domain.ReportTargetPost:
+		return s.postRepo.SetHidden(ctx, targetID, hidden)
+	case domain.ReportTargetMessage:
+		return s.chatRepo.SetHidden(ctx, targetID, hidden)
+	case domain.ReportTargetComment:
+		return s.commentRepo.SetHidden(ctx, targetID, hidden)
+	default:
+		return fmt.Errorf("unknown report target type: %q", targetType)
+	}
+}
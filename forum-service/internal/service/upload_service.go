@@ -0,0 +1,294 @@
+package service
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"mime"
+	"net/http"
+	"net/url"
+	"path"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/blobstore"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/imaging"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/repository"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const thumbnailSize = 320
+
+// allowedUploadTypes — типы, определяемые по содержимому файла (а не по заголовку
+// Content-Type клиента), которые разрешено загружать.
+var allowedUploadTypes = map[string]bool{
+	"image/png":       true,
+	"image/jpeg":      true,
+	"image/gif":       true,
+	"image/webp":      true,
+	"application/pdf": true,
+	"text/plain":      true,
+}
+
+type UploadServiceImpl struct {
+	repo      repository.AttachmentRepository
+	store     blobstore.BlobStore
+	maxBytes  int64
+	urlSecret []byte
+	urlTTL    time.Duration
+	logger    zerolog.Logger
+}
+
+type UploadService interface {
+	Upload(ctx context.Context, uploaderID int64, filename string, r io.Reader) (*domain.Attachment, error)
+	Open(ctx context.Context, id int64, variant string, expires int64, signature string) (io.ReadCloser, *domain.Attachment, error)
+	SignURLs(attachments ...*domain.Attachment)
+}
+
+// NewUploadService создаёт сервис загрузок. Ссылки на скачивание подписываются
+// urlSecret и действуют urlTTL.
+func NewUploadService(repo repository.AttachmentRepository, store blobstore.BlobStore, maxBytes int64, urlSecret string, urlTTL time.Duration) UploadService {
+	return &UploadServiceImpl{
+		repo:      repo,
+		store:     store,
+		maxBytes:  maxBytes,
+		urlSecret: []byte(urlSecret),
+		urlTTL:    urlTTL,
+		logger:    log.With().Str("component", "upload_service").Logger(),
+	}
+}
+
+func (s *UploadServiceImpl) Upload(ctx context.Context, uploaderID int64, filename string, r io.Reader) (*domain.Attachment, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "Upload").
+		Int64("uploader_id", uploaderID).
+		Logger()
+
+	data, err := io.ReadAll(io.LimitReader(r, s.maxBytes+1))
+	if err != nil {
+		logger.Warn().Err(err).Msg("Failed to read upload")
+		return nil, fmt.Errorf("failed to read upload: %w", err)
+	}
+	if int64(len(data)) > s.maxBytes {
+		logger.Warn().Int64("max_bytes", s.maxBytes).Msg("Upload too large")
+		return nil, domain.ErrUploadTooLarge
+	}
+	if len(data) == 0 {
+		return nil, domain.Invalid("file is empty")
+	}
+
+	contentType := sniffContentType(data)
+	if !allowedUploadTypes[contentType] {
+		logger.Warn().Str("content_type", contentType).Msg("Unsupported file type")
+		return nil, domain.ErrUnsupportedFileType
+	}
+
+	attachment := &domain.Attachment{
+		UploaderID:  uploaderID,
+		Filename:    sanitizeFilename(filename),
+		ContentType: contentType,
+		Size:        int64(len(data)),
+	}
+	logger = logger.With().Str("content_type", contentType).Int64("size", attachment.Size).Logger()
+
+	key, err := newBlobKey("attachments", extensionFor(contentType))
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to generate blob key")
+		return nil, err
+	}
+	attachment.BlobKey = key
+
+	var thumbnail []byte
+	if strings.HasPrefix(contentType, "image/") {
+		img, _, err := imaging.Decode(bytes.NewReader(data))
+		if err != nil {
+			logger.Warn().Err(err).Msg("Failed to decode image")
+			return nil, domain.ErrUnsupportedFileType
+		}
+		attachment.Width = img.Bounds().Dx()
+		attachment.Height = img.Bounds().Dy()
+
+		thumbnail, err = imaging.EncodePNG(imaging.Fit(img, thumbnailSize))
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to generate thumbnail")
+			return nil, err
+		}
+		attachment.ThumbnailKey = strings.TrimSuffix(key, path.Ext(key)) + "_thumb.png"
+	}
+
+	if err := s.store.Put(ctx, attachment.BlobKey, bytes.NewReader(data), attachment.Size, contentType); err != nil {
+		logger.Error().Err(err).Msg("Failed to store upload")
+		return nil, fmt.Errorf("failed to store upload: %w", err)
+	}
+	if thumbnail != nil {
+		if err := s.store.Put(ctx, attachment.ThumbnailKey, bytes.NewReader(thumbnail), int64(len(thumbnail)), "image/png"); err != nil {
+			logger.Error().Err(err).Msg("Failed to store thumbnail")
+			s.cleanup(ctx, attachment.BlobKey)
+			return nil, fmt.Errorf("failed to store thumbnail: %w", err)
+		}
+	}
+
+	id, err := s.repo.Create(ctx, attachment)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to create attachment in repository")
+		s.cleanup(ctx, attachment.BlobKey, attachment.ThumbnailKey)
+		return nil, fmt.Errorf("failed to save upload: %w", err)
+	}
+
+	created, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.Error().Err(err).Int64("attachment_id", id).Msg("Failed to fetch created attachment")
+		return nil, fmt.Errorf("failed to fetch upload: %w", err)
+	}
+	s.SignURLs(created)
+
+	logger.Info().Int64("attachment_id", id).Msg("Upload stored successfully")
+	return created, nil
+}
+
+// Open проверяет подпись ссылки и открывает запрошенный вариант файла.
+func (s *UploadServiceImpl) Open(ctx context.Context, id int64, variant string, expires int64, signature string) (io.ReadCloser, *domain.Attachment, error) {
+	logger := s.logger.With().
+		Ctx(ctx).
+		Str("method", "Open").
+		Int64("attachment_id", id).
+		Str("variant", variant).
+		Logger()
+
+	if time.Now().Unix() > expires || !hmac.Equal([]byte(signature), []byte(s.signature(id, variant, expires))) {
+		logger.Debug().Msg("Invalid or expired download link")
+		return nil, nil, domain.ErrInvalidDownloadLink
+	}
+
+	attachment, err := s.repo.GetByID(ctx, id)
+	if err != nil {
+		logger.Error().Err(err).Msg("Failed to get attachment from repository")
+		return nil, nil, fmt.Errorf("failed to get attachment: %w", err)
+	}
+	if attachment == nil {
+		return nil, nil, domain.ErrAttachmentNotFound
+	}
+
+	key := attachment.BlobKey
+	if variant == domain.AttachmentVariantThumbnail {
+		if attachment.ThumbnailKey == "" {
+			return nil, nil, domain.ErrAttachmentNotFound
+		}
+		key = attachment.ThumbnailKey
+		attachment.ContentType = "image/png"
+	}
+
+	body, err := s.store.Get(ctx, key)
+	if err != nil {
+		if errors.Is(err, blobstore.ErrNotFound) {
+			logger.Warn().Str("key", key).Msg("Blob missing for attachment")
+			return nil, nil, domain.ErrAttachmentNotFound
+		}
+		logger.Error().Err(err).Msg("Failed to open blob")
+		return nil, nil, fmt.Errorf("failed to open attachment: %w", err)
+	}
+
+	return body, attachment, nil
+}
+
+// SignURLs заполняет URL и ThumbnailURL ссылками, действующими urlTTL.
+func (s *UploadServiceImpl) SignURLs(attachments ...*domain.Attachment) {
+	expires := time.Now().Add(s.urlTTL).Unix()
+	for _, attachment := range attachments {
+		attachment.URL = s.signedURL(attachment.ID, domain.AttachmentVariantOriginal, expires)
+		if attachment.ThumbnailKey != "" {
+			attachment.ThumbnailURL = s.signedURL(attachment.ID, domain.AttachmentVariantThumbnail, expires)
+		}
+	}
+}
+
+func (s *UploadServiceImpl) signedURL(id int64, variant string, expires int64) string {
+	query := url.Values{}
+	query.Set("variant", variant)
+	query.Set("expires", strconv.FormatInt(expires, 10))
+	query.Set("sig", s.signature(id, variant, expires))
+	return fmt.Sprintf("/api/uploads/%d?%s", id, query.Encode())
+}
+
+func (s *UploadServiceImpl) signature(id int64, variant string, expires int64) string {
+	mac := hmac.New(sha256.New, s.urlSecret)
+	fmt.Fprintf(mac, "%d:%s:%d", id, variant, expires)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (s *UploadServiceImpl) cleanup(ctx context.Context, keys ...string) {
+	for _, key := range keys {
+		if key == "" {
+			continue
+		}
+		if err := s.store.Delete(ctx, key); err != nil {
+			s.logger.Warn().Ctx(ctx).Err(err).Str("key", key).Msg("Failed to delete orphaned blob")
+		}
+	}
+}
+
+func sniffContentType(data []byte) string {
+	mediaType, _, err := mime.ParseMediaType(http.DetectContentType(data))
+	if err != nil {
+		return "application/octet-stream"
+	}
+	return mediaType
+}
+
+func extensionFor(contentType string) string {
+	switch contentType {
+	case "image/png":
+		return ".png"
+	case "image/jpeg":
+		return ".jpg"
+	case "image/gif":
+		return ".gif"
+	case "image/webp":
+		return ".webp"
+	case "application/pdf":
+		return ".pdf"
+	case "text/plain":
+		return ".txt"
+	default:
+		return ""
+	}
+}
+
+// sanitizeFilename оставляет только базовое имя файла без управляющих символов;
+// оно используется лишь для отображения и Content-Disposition.
+func sanitizeFilename(name string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.Map(func(r rune) rune {
+		if r < 0x20 || r == 0x7f || r == '"' {
+			return -1
+		}
+		return r
+	}, name)
+	if name == "" || name == "." || name == "/" {
+		name = "file"
+	}
+	if len(name) > 255 {
+		name = name[:255]
+	}
+	return strings.ToValidUTF8(name, "")
+}
+
+// newBlobKey возвращает случайный ключ вида "prefix/2006/01/<hex><ext>".
+func newBlobKey(prefix, ext string) (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", fmt.Errorf("failed to generate blob key: %w", err)
+	}
+	return fmt.Sprintf("%s/%s/%s%s", prefix, time.Now().UTC().Format("2006/01"), hex.EncodeToString(buf), ext), nil
+}
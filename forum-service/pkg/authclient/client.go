@@ -0,0 +1,163 @@
+package authclient
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/helper"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/requestid"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/tracing"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+// actorTokenTTL — срок токена, которым forum-service подписывает запросы
+// от имени пользователя.
+const actorTokenTTL = 60
+
+// Client — HTTP-клиент auth-service.
+type Client struct {
+	baseURL string
+	// jwtSecret — общий с auth-service ключ; им подписываются запросы от
+	// имени пользователя, когда его собственного токена под рукой нет.
+	jwtSecret string
+	http      *http.Client
+	logger    zerolog.Logger
+}
+
+func New(baseURL, jwtSecret string) *Client {
+	return &Client{
+		baseURL:   baseURL,
+		jwtSecret: jwtSecret,
+		http: &http.Client{
+			Timeout:   3 * time.Second,
+			Transport: requestid.Transport(tracing.Transport(http.DefaultTransport)),
+		},
+		logger: log.With().Str("component", "auth_client").Logger(),
+	}
+}
+
+// Ping проверяет, что auth-service отвечает на /healthz.
+func (c *Client) Ping(ctx context.Context) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/healthz", nil)
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return fmt.Errorf("auth service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("auth service returned status %d", resp.StatusCode)
+	}
+	return nil
+}
+
+// ActiveSanctions возвращает действующие санкции пользователя, которому принадлежит token.
+func (c *Client) ActiveSanctions(ctx context.Context, token string) ([]domain.Sanction, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/me/sanctions", nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("auth service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth service returned status %d", resp.StatusCode)
+	}
+
+	var sanctions []domain.Sanction
+	if err := json.NewDecoder(resp.Body).Decode(&sanctions); err != nil {
+		return nil, fmt.Errorf("failed to decode sanctions: %w", err)
+	}
+
+	c.logger.Debug().Ctx(ctx).Int("sanction_count", len(sanctions)).Msg("Fetched active sanctions")
+	return sanctions, nil
+}
+
+// CurrentRole возвращает роль, которая сейчас записана в auth-service
+// у владельца token (она может отличаться от роли в самом токене).
+func (c *Client) CurrentRole(ctx context.Context, token string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/me", nil)
+	if err != nil {
+		return "", fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return "", fmt.Errorf("auth service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("auth service returned status %d", resp.StatusCode)
+	}
+
+	var profile struct {
+		Role string `json:"role"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&profile); err != nil {
+		return "", fmt.Errorf("failed to decode profile: %w", err)
+	}
+	return profile.Role, nil
+}
+
+// ResolveUsernames сопоставляет имена пользователей с их ID. Имена, которых нет
+// в auth-service, в результат не попадают. Запрос идёт от имени actorID (автора
+// текста с упоминаниями), и лимит поиска в auth-service считается на него.
+func (c *Client) ResolveUsernames(ctx context.Context, actorID int64, actor string, usernames []string) (map[string]int64, error) {
+	ids := make(map[string]int64, len(usernames))
+	if len(usernames) == 0 {
+		return ids, nil
+	}
+
+	token, err := helper.GenerateJWTWithClaims(actorID, actor, domain.RoleUser, c.jwtSecret, actorTokenTTL)
+	if err != nil {
+		return nil, fmt.Errorf("failed to sign request: %w", err)
+	}
+
+	query := url.Values{"username": usernames}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, c.baseURL+"/api/v1/users?"+query.Encode(), nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to build request: %w", err)
+	}
+	req.Header.Set("Authorization", "Bearer "+token)
+
+	resp, err := c.http.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("auth service unavailable: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("auth service returned status %d", resp.StatusCode)
+	}
+
+	var users []struct {
+		ID       int64  `json:"id"`
+		Username string `json:"username"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&users); err != nil {
+		return nil, fmt.Errorf("failed to decode users: %w", err)
+	}
+	for _, user := range users {
+		ids[user.Username] = user.ID
+	}
+
+	c.logger.Debug().Ctx(ctx).Int("requested", len(usernames)).Int("resolved", len(ids)).Msg("Resolved usernames")
+	return ids, nil
+}
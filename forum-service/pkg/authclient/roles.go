@@ -0,0 +1,80 @@
+package authclient
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// RoleCache хранит текущие роли пользователей ttl после запроса. Роль в JWT
+// остаётся прежней до истечения токена, поэтому маршруты модераторов и
+// администраторов сверяют её с auth-service. Во время сбоя auth-service, как
+// и SanctionCache, отдаётся последняя известная роль не старше ttl*staleFactor.
+type RoleCache struct {
+	client *Client
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	entries   map[int64]roleEntry
+	lastEvict time.Time
+}
+
+type roleEntry struct {
+	role      string
+	fetchedAt time.Time
+}
+
+func NewRoleCache(client *Client, ttl time.Duration) *RoleCache {
+	return &RoleCache{
+		client:  client,
+		ttl:     ttl,
+		entries: make(map[int64]roleEntry),
+	}
+}
+
+// Current возвращает текущую роль пользователя userID, которому принадлежит
+// token. Ошибка означает, что auth-service недоступен и известной роли нет.
+func (c *RoleCache) Current(ctx context.Context, userID int64, token string) (string, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, cached := c.entries[userID]
+	c.mu.Unlock()
+	if cached && now.Sub(entry.fetchedAt) < c.ttl {
+		return entry.role, nil
+	}
+
+	role, err := c.client.CurrentRole(ctx, token)
+	if err != nil {
+		if cached && now.Sub(entry.fetchedAt) < c.ttl*staleFactor {
+			c.client.logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Int64("user_id", userID).
+				Dur("age", now.Sub(entry.fetchedAt)).
+				Msg("Auth service unavailable, using cached role")
+			return entry.role, nil
+		}
+		return "", err
+	}
+
+	c.mu.Lock()
+	c.entries[userID] = roleEntry{role: role, fetchedAt: now}
+	c.evictLocked(now)
+	c.mu.Unlock()
+	return role, nil
+}
+
+// evictLocked раз в ttl удаляет записи, которые уже не пригодятся даже
+// во время сбоя.
+func (c *RoleCache) evictLocked(now time.Time) {
+	if now.Sub(c.lastEvict) < c.ttl {
+		return
+	}
+	c.lastEvict = now
+	for userID, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= c.ttl*staleFactor {
+			delete(c.entries, userID)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package authclient
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+)
+
+// staleFactor — во сколько раз дольше ttl последний известный список санкций
+// ещё используется, пока auth-service недоступен.
+const staleFactor = 10
+
+// SanctionCache хранит действующие санкции пользователей ttl после запроса,
+// чтобы проверять их на каждом запросе без похода в auth-service. Если
+// auth-service не отвечает, отдаётся последний известный список (не старше
+// ttl*staleFactor): уже выданный бан продолжает действовать и во время сбоя.
+type SanctionCache struct {
+	client *Client
+	ttl    time.Duration
+
+	mu        sync.Mutex
+	entries   map[int64]sanctionEntry
+	lastEvict time.Time
+}
+
+type sanctionEntry struct {
+	sanctions []domain.Sanction
+	fetchedAt time.Time
+}
+
+func NewSanctionCache(client *Client, ttl time.Duration) *SanctionCache {
+	return &SanctionCache{
+		client:  client,
+		ttl:     ttl,
+		entries: make(map[int64]sanctionEntry),
+	}
+}
+
+// Active возвращает действующие санкции пользователя userID, которому
+// принадлежит token. Ошибка означает, что auth-service недоступен и свежего
+// списка нет — решать, пропускать ли запрос, вызывающему.
+func (c *SanctionCache) Active(ctx context.Context, userID int64, token string) ([]domain.Sanction, error) {
+	now := time.Now()
+
+	c.mu.Lock()
+	entry, cached := c.entries[userID]
+	c.mu.Unlock()
+	if cached && now.Sub(entry.fetchedAt) < c.ttl {
+		return activeAt(entry.sanctions, now), nil
+	}
+
+	sanctions, err := c.client.ActiveSanctions(ctx, token)
+	if err != nil {
+		if cached && now.Sub(entry.fetchedAt) < c.ttl*staleFactor {
+			c.client.logger.Warn().
+				Ctx(ctx).
+				Err(err).
+				Int64("user_id", userID).
+				Dur("age", now.Sub(entry.fetchedAt)).
+				Msg("Auth service unavailable, using cached sanctions")
+			return activeAt(entry.sanctions, now), nil
+		}
+		return nil, err
+	}
+
+	c.mu.Lock()
+	c.entries[userID] = sanctionEntry{sanctions: sanctions, fetchedAt: now}
+	c.evictLocked(now)
+	c.mu.Unlock()
+	return sanctions, nil
+}
+
+// Invalidate забывает санкции пользователя: следующая проверка спросит
+// auth-service. Вызывается, когда auth-service сообщает о выданной или
+// отозванной санкции; другие экземпляры увидят её не позже чем через ttl.
+func (c *SanctionCache) Invalidate(userID int64) {
+	c.mu.Lock()
+	delete(c.entries, userID)
+	c.mu.Unlock()
+}
+
+// evictLocked раз в ttl удаляет записи, которые уже не пригодятся даже
+// во время сбоя.
+func (c *SanctionCache) evictLocked(now time.Time) {
+	if now.Sub(c.lastEvict) < c.ttl {
+		return
+	}
+	c.lastEvict = now
+	for userID, entry := range c.entries {
+		if now.Sub(entry.fetchedAt) >= c.ttl*staleFactor {
+			delete(c.entries, userID)
+		}
+	}
+}
+
+// activeAt отбрасывает санкции, истёкшие с момента запроса.
+func activeAt(sanctions []domain.Sanction, now time.Time) []domain.Sanction {
+	active := make([]domain.Sanction, 0, len(sanctions))
+	for _, sanction := range sanctions {
+		if sanction.IsActive(now) {
+			active = append(active, sanction)
+		}
+	}
+	return active
+}
@@ -0,0 +1,83 @@
+// Package markdown превращает CommonMark-исходник постов и комментариев
+// в безопасный HTML.
+package markdown
+
+import (
+	"bytes"
+	"fmt"
+	"regexp"
+
+	"github.com/microcosm-cc/bluemonday"
+	"github.com/yuin/goldmark"
+	"github.com/yuin/goldmark/ast"
+	"github.com/yuin/goldmark/parser"
+	"github.com/yuin/goldmark/text"
+	"github.com/yuin/goldmark/util"
+)
+
+// Version — версия правил рендеринга. Её нужно увеличивать при изменении
+// парсера или политики санитайзера, чтобы сохранённый HTML перерисовался.
+const Version = 1
+
+const linkRel = "nofollow ugc"
+
+// Renderer безопасен для одновременного использования из разных горутин.
+type Renderer struct {
+	md     goldmark.Markdown
+	policy *bluemonday.Policy
+}
+
+func NewRenderer() *Renderer {
+	return &Renderer{
+		md: goldmark.New(
+			// Сырой HTML из исходника goldmark по умолчанию не пропускает.
+			goldmark.WithParserOptions(
+				parser.WithASTTransformers(util.Prioritized(linkRelTransformer{}, 100)),
+			),
+		),
+		policy: newPolicy(),
+	}
+}
+
+// Render возвращает санитизированный HTML для markdown-исходника.
+func (r *Renderer) Render(source string) (string, error) {
+	var buf bytes.Buffer
+	if err := r.md.Convert([]byte(source), &buf); err != nil {
+		return "", fmt.Errorf("failed to render markdown: %w", err)
+	}
+	return r.policy.Sanitize(buf.String()), nil
+}
+
+func newPolicy() *bluemonday.Policy {
+	p := bluemonday.NewPolicy()
+	p.AllowStandardURLs()
+	p.AllowElements(
+		"p", "br", "hr", "blockquote", "pre",
+		"h1", "h2", "h3", "h4", "h5", "h6",
+		"ul", "ol", "li",
+		"em", "strong", "code", "del",
+	)
+	p.AllowAttrs("start").Matching(bluemonday.Integer).OnElements("ol")
+	p.AllowAttrs("class").Matching(regexp.MustCompile(`^language-[\w+-]+$`)).OnElements("code")
+	p.AllowAttrs("href", "title").OnElements("a")
+	p.AllowAttrs("rel").Matching(regexp.MustCompile(`^` + linkRel + `$`)).OnElements("a")
+	p.AllowAttrs("src", "alt", "title").OnElements("img")
+	p.RequireNoFollowOnLinks(true)
+	return p
+}
+
+// linkRelTransformer проставляет rel="nofollow ugc" всем ссылкам из пользовательского текста.
+type linkRelTransformer struct{}
+
+func (linkRelTransformer) Transform(doc *ast.Document, _ text.Reader, _ parser.Context) {
+	_ = ast.Walk(doc, func(n ast.Node, entering bool) (ast.WalkStatus, error) {
+		if !entering {
+			return ast.WalkContinue, nil
+		}
+		switch n.Kind() {
+		case ast.KindLink, ast.KindAutoLink:
+			n.SetAttributeString("rel", []byte(linkRel))
+		}
+		return ast.WalkContinue, nil
+	})
+}
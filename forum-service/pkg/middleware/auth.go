@@ -1,18 +1,20 @@
 package middleware
 
 import (
-	"fmt"
 	"github.com/Frozz164/forum-app_v2/auth-service/pkg/helper"
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
 	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
 	_ "github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
 	"strings"
 )
 
+var errInvalidAuthHeader = problem.ErrUnauthorized.WithDetail("invalid authorization header format")
+
 func AuthMiddleware(secretKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := log.With().
+			Ctx(c.Request.Context()).
 			Str("middleware", "AuthMiddleware").
 			Str("path", c.Request.URL.Path).
 			Logger()
@@ -20,7 +22,7 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 		authHeader := c.GetHeader("Authorization")
 		if authHeader == "" {
 			logger.Warn().Msg("Authorization header is missing")
-			c.AbortWithStatusJSON(401, gin.H{"error": "Authorization header required"})
+			problem.Abort(c, problem.ErrUnauthorized)
 			return
 		}
 
@@ -29,14 +31,14 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 			logger.Warn().
 				Str("header", authHeader).
 				Msg("Invalid authorization header format")
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid authorization header format"})
+			problem.Abort(c, errInvalidAuthHeader)
 			return
 		}
 
 		tokenString := parts[1]
 		if tokenString == "" {
 			logger.Warn().Msg("Empty token string")
-			c.AbortWithStatusJSON(401, gin.H{"error": "Token required"})
+			problem.Abort(c, problem.ErrUnauthorized)
 			return
 		}
 
@@ -44,31 +46,50 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 			Str("token_prefix", tokenString[:min(10, len(tokenString))]).
 			Logger()
 
-		token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-			if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-				logger.Warn().
-					Str("alg", fmt.Sprintf("%v", token.Header["alg"])).
-					Msg("Unexpected signing method")
-				return nil, fmt.Errorf("unexpected signing method: %v", token.Header["alg"])
-			}
-			return []byte(secretKey), nil
-		})
-
+		claims, err := helper.ValidateTokenWithClaims(tokenString, secretKey)
 		if err != nil {
 			logger.Warn().
 				Err(err).
 				Msg("Token validation failed")
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token"})
+			problem.Abort(c, problem.ErrInvalidToken)
 			return
 		}
 
-		if !token.Valid {
-			logger.Warn().Msg("Invalid token provided")
-			c.AbortWithStatusJSON(401, gin.H{"error": "Invalid token"})
+		c.Set("userID", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
+
+		logger.Debug().Msg("Token validated successfully")
+		c.Next()
+	}
+}
+
+// OptionalAuthMiddleware — для публичных маршрутов: если запрос пришёл
+// с действительным токеном, кладёт в контекст данные пользователя, как
+// AuthMiddleware; без токена или с недействительным запрос идёт как гостевой.
+func OptionalAuthMiddleware(secretKey string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		tokenString, ok := strings.CutPrefix(c.GetHeader("Authorization"), "Bearer ")
+		if !ok || tokenString == "" {
+			c.Next()
 			return
 		}
 
-		logger.Debug().Msg("Token validated successfully")
+		claims, err := helper.ValidateTokenWithClaims(tokenString, secretKey)
+		if err != nil {
+			log.Debug().
+				Ctx(c.Request.Context()).
+				Err(err).
+				Str("middleware", "OptionalAuthMiddleware").
+				Str("path", c.Request.URL.Path).
+				Msg("Token validation failed, continuing as guest")
+			c.Next()
+			return
+		}
+
+		c.Set("userID", claims.UserID)
+		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
 		c.Next()
 	}
 }
@@ -76,6 +97,7 @@ func AuthMiddleware(secretKey string) gin.HandlerFunc {
 func AuthWebSocketMiddleware(secretKey string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		logger := log.With().
+			Ctx(c.Request.Context()).
 			Str("middleware", "AuthWebSocketMiddleware").
 			Str("path", c.Request.URL.Path).
 			Logger()
@@ -87,7 +109,7 @@ func AuthWebSocketMiddleware(secretKey string) gin.HandlerFunc {
 			authHeader := c.GetHeader("Authorization")
 			if authHeader == "" {
 				logger.Debug().Msg("Token is missing in both query and header")
-				c.AbortWithStatusJSON(401, gin.H{"error": "token required"})
+				problem.Abort(c, problem.ErrUnauthorized)
 				return
 			}
 
@@ -96,7 +118,7 @@ func AuthWebSocketMiddleware(secretKey string) gin.HandlerFunc {
 				logger.Warn().
 					Str("header", authHeader).
 					Msg("Invalid authorization header format")
-				c.AbortWithStatusJSON(401, gin.H{"error": "invalid auth format"})
+				problem.Abort(c, errInvalidAuthHeader)
 				return
 			}
 			token = parts[1]
@@ -109,13 +131,14 @@ func AuthWebSocketMiddleware(secretKey string) gin.HandlerFunc {
 				Err(err).
 				Str("token_prefix", token[:min(10, len(token))]).
 				Msg("WebSocket token validation failed")
-			c.AbortWithStatusJSON(401, gin.H{"error": "invalid token"})
+			problem.Abort(c, problem.ErrInvalidToken)
 			return
 		}
 
 		// 4. Сохраняем данные в контекст
 		c.Set("userID", claims.UserID)
 		c.Set("username", claims.Username)
+		c.Set("role", claims.Role)
 
 		logger.Info().
 			Str("username", claims.Username).
@@ -125,3 +148,29 @@ func AuthWebSocketMiddleware(secretKey string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// RequireRole пропускает запрос только если роль из токена входит в список разрешённых.
+// Должен стоять после AuthMiddleware.
+func RequireRole(roles ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := log.With().
+			Ctx(c.Request.Context()).
+			Str("middleware", "RequireRole").
+			Str("path", c.Request.URL.Path).
+			Logger()
+
+		role := c.GetString("role")
+		for _, allowed := range roles {
+			if role == allowed {
+				c.Next()
+				return
+			}
+		}
+
+		logger.Warn().
+			Int64("user_id", c.GetInt64("userID")).
+			Str("role", role).
+			Msg("Insufficient role")
+		problem.Abort(c, problem.ErrForbidden)
+	}
+}
@@ -23,6 +23,7 @@ func GinLogger() gin.HandlerFunc {
 		errorMessage := c.Errors.ByType(gin.ErrorTypePrivate).String()
 
 		logger := log.With().
+			Ctx(c.Request.Context()).
 			Int("status", status).
 			Str("method", method).
 			Str("path", path).
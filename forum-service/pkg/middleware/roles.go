@@ -0,0 +1,41 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/authclient"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+var errRoleUnavailable = problem.New(http.StatusServiceUnavailable, "role_unavailable", "cannot verify permissions right now")
+
+// LoadCurrentRole заменяет роль из JWT текущей ролью из auth-service (через
+// кэш), чтобы пониженный модератор терял права, не дожидаясь истечения
+// токена. Ставится после AuthMiddleware и перед RequireRole.
+//
+// В отличие от LoadSanctions, без ответа auth-service запрос отклоняется:
+// пропустить его значило бы выдать права, которых у пользователя может уже
+// не быть.
+func LoadCurrentRole(cache *authclient.RoleCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		role, err := cache.Current(c.Request.Context(), c.GetInt64("userID"), token)
+		if err != nil {
+			log.Error().
+				Ctx(c.Request.Context()).
+				Err(err).
+				Str("middleware", "LoadCurrentRole").
+				Str("path", c.Request.URL.Path).
+				Int64("user_id", c.GetInt64("userID")).
+				Msg("Failed to load current role")
+			problem.Abort(c, errRoleUnavailable)
+			return
+		}
+
+		c.Set("role", role)
+		c.Next()
+	}
+}
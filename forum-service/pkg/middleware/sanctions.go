@@ -0,0 +1,85 @@
+package middleware
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/problem"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/pkg/authclient"
+	"github.com/gin-gonic/gin"
+	"github.com/rs/zerolog/log"
+)
+
+var errSanctioned = problem.New(http.StatusForbidden, "sanctioned", "action not allowed")
+
+// sanctionsKey — ключ gin.Context, под которым LoadSanctions сохраняет
+// санкции пользователя.
+const sanctionsKey = "sanctions"
+
+// LoadSanctions получает действующие санкции пользователя (через кэш) и
+// отклоняет запросы забаненных — так бан действует на всю группу маршрутов,
+// даже если JWT выдан до него. Санкции сохраняются в контексте для
+// RejectSanctioned. Должен стоять после AuthMiddleware.
+//
+// Если auth-service недоступен и в кэше ничего нет, запрос пропускается:
+// токен проверен локально, а блокировать весь форум из-за auth-service
+// нельзя. Такие запросы пишутся в лог с уровнем error.
+func LoadSanctions(cache *authclient.SanctionCache) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		logger := log.With().
+			Ctx(c.Request.Context()).
+			Str("middleware", "LoadSanctions").
+			Str("path", c.Request.URL.Path).
+			Int64("user_id", c.GetInt64("userID")).
+			Logger()
+
+		token := strings.TrimPrefix(c.GetHeader("Authorization"), "Bearer ")
+		sanctions, err := cache.Active(c.Request.Context(), c.GetInt64("userID"), token)
+		if err != nil {
+			logger.Error().Err(err).Msg("Failed to check sanctions, allowing request unchecked")
+			c.Next()
+			return
+		}
+		c.Set(sanctionsKey, sanctions)
+
+		if abortSanctioned(c, sanctions, domain.SanctionBan) {
+			return
+		}
+		c.Next()
+	}
+}
+
+// RejectSanctioned отклоняет запрос, если у пользователя есть действующая санкция
+// одного из перечисленных типов. Должен стоять после LoadSanctions.
+func RejectSanctioned(types ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		sanctions, _ := c.Get(sanctionsKey)
+		list, _ := sanctions.([]domain.Sanction)
+		if abortSanctioned(c, list, types...) {
+			return
+		}
+		c.Next()
+	}
+}
+
+func abortSanctioned(c *gin.Context, sanctions []domain.Sanction, types ...string) bool {
+	sanction := domain.FindSanction(sanctions, types...)
+	if sanction == nil {
+		return false
+	}
+
+	log.Warn().
+		Ctx(c.Request.Context()).
+		Str("path", c.Request.URL.Path).
+		Int64("user_id", c.GetInt64("userID")).
+		Int64("sanction_id", sanction.ID).
+		Str("type", sanction.Type).
+		Msg("Request rejected due to sanction")
+	problem.Abort(c, errSanctioned.
+		WithDetail("action not allowed: "+sanction.Type).
+		With("sanction_type", sanction.Type).
+		With("reason", sanction.Reason).
+		With("expires_at", sanction.ExpiresAt))
+	return true
+}
@@ -23,7 +23,7 @@ func (a *chatServiceAdapter) GetRecentMessages(ctx context.Context, limit int) (
 		Int("limit", limit).
 		Logger()
 
-	domainMessages, err := a.service.GetRecentMessages(ctx, limit)
+	domainMessages, err := a.service.GetRecentMessages(ctx, limit, 0)
 	if err != nil {
 		logger.Error().
 			Err(err).
@@ -42,13 +42,22 @@ func (a *chatServiceAdapter) GetRecentMessages(ctx context.Context, limit int) (
 			continue
 		}
 
-		wsMessages = append(wsMessages, Message{
+		wsMsg := Message{
+			ID:        dm.ID,
 			Type:      MsgTypeChat,
 			Content:   dm.Content,
 			Sender:    dm.Username,
 			Timestamp: createdAt.Unix(),
 			UserID:    dm.UserID,
-		})
+			Deleted:   dm.Deleted,
+			Reactions: dm.Reactions,
+			ReplyTo:   dm.ReplyTo,
+			Quote:     dm.Quote,
+		}
+		if dm.EditedAt != "" {
+			wsMsg.EditedAt = parseTime(dm.EditedAt).Unix()
+		}
+		wsMessages = append(wsMessages, wsMsg)
 	}
 
 	logger.Debug().
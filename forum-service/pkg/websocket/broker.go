@@ -0,0 +1,118 @@
+package websocket
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
+)
+
+// Виды событий, которые пул разносит между экземплярами через Broker.
+const (
+	// EventBroadcast — кадр для всех клиентов (сообщение, правка, реакция, присутствие).
+	EventBroadcast = "broadcast"
+	// EventTyping — индикатор набора; не доставляется подключениям самого автора.
+	EventTyping = "typing"
+	// EventPresence — число подключений пользователей на экземпляре-источнике.
+	EventPresence = "presence"
+	// EventSanction — выданная или снятая санкция для живых подключений.
+	EventSanction = "sanction"
+	// EventNotification — уведомление для подключений адресата.
+	EventNotification = "notification"
+	// EventSlowMode — модератор изменил интервал медленного режима.
+	EventSlowMode = "slow_mode"
+	// EventResync — брокер восстановил подписку после разрыва и мог
+	// пропустить события; пул досылает сообщения, опубликованные за это время.
+	EventResync = "resync"
+)
+
+// Event — событие пула, которое должно дойти до клиентов на всех экземплярах.
+type Event struct {
+	Kind string `json:"kind"`
+	// Origin — ID экземпляра, опубликовавшего событие.
+	Origin  string   `json:"origin"`
+	Message *Message `json:"message,omitempty"`
+	// MessageRef — ID сообщения чата, которое не поместилось в шину:
+	// получатели перечитывают его из базы (вместо Message).
+	MessageRef   int64                       `json:"message_ref,omitempty"`
+	Sanction     *domain.Sanction            `json:"sanction,omitempty"`
+	Notification *notifications.Notification `json:"notification,omitempty"`
+	// Presence — счётчики подключений на экземпляре Origin (EventPresence).
+	Presence []PresenceCount `json:"presence,omitempty"`
+	// SlowMode — новый интервал медленного режима (EventSlowMode); 0 выключает.
+	SlowMode time.Duration `json:"slow_mode,omitempty"`
+	// Trace — W3C trace context span'а, породившего событие; рассылка
+	// ссылается на него.
+	Trace map[string]string `json:"trace,omitempty"`
+}
+
+// withMessageRef возвращает копию события, в которой сообщение чата заменено
+// ссылкой MessageRef, или nil, если перечитать событие по ID нельзя.
+func (e *Event) withMessageRef() *Event {
+	if e.Kind != EventBroadcast || e.Message == nil || e.Message.Type != MsgTypeChat || e.Message.ID == 0 {
+		return nil
+	}
+	ref := *e
+	ref.Message = nil
+	ref.MessageRef = e.Message.ID
+	return &ref
+}
+
+// PresenceCount — сколько подключений пользователя открыто на экземпляре
+// (0 — ни одного).
+type PresenceCount struct {
+	UserID      int64  `json:"user_id"`
+	Username    string `json:"username"`
+	Connections int    `json:"connections"`
+}
+
+// Broker доставляет события пула всем экземплярам сервиса, включая
+// опубликовавший: пул рассылает клиентам только то, что пришло из Events.
+type Broker interface {
+	Publish(ctx context.Context, event *Event) error
+	Events() <-chan *Event
+	Close() error
+}
+
+// MemoryBroker — брокер для единственного экземпляра: события возвращаются
+// в тот же процесс без внешней шины.
+type MemoryBroker struct {
+	events chan *Event
+}
+
+func NewMemoryBroker() *MemoryBroker {
+	return &MemoryBroker{events: make(chan *Event, MaxMessageQueue)}
+}
+
+func (b *MemoryBroker) Publish(ctx context.Context, event *Event) error {
+	select {
+	case b.events <- event:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("publish event: %w", ctx.Err())
+	}
+}
+
+func (b *MemoryBroker) Events() <-chan *Event {
+	return b.events
+}
+
+func (b *MemoryBroker) Close() error {
+	return nil
+}
+
+// newInstanceID возвращает ID экземпляра для поля Event.Origin.
+func newInstanceID() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	suffix := make([]byte, 4)
+	_, _ = rand.Read(suffix)
+	return fmt.Sprintf("%s-%d-%s", host, os.Getpid(), hex.EncodeToString(suffix))
+}
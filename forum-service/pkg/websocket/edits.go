@@ -0,0 +1,109 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+)
+
+// handleEdit правит сообщение автора и рассылает всем кадр MsgTypeEdit
+// с новым текстом, ключом служит ID сообщения. Правку, скрытую фильтром
+// контента, получает только автор — как и новое скрытое сообщение.
+func (c *Client) handleEdit(msg Message) {
+	if c.UserID == 0 || c.IsReadOnly() {
+		c.sendSystem("you cannot edit messages right now")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	updated, err := c.Pool.ChatService.EditMessage(ctx, msg.MessageID, c.UserID, msg.Content)
+	if err != nil {
+		c.rejectChange(err, msg.MessageID, "edit")
+		return
+	}
+
+	edit := Message{
+		ID:        updated.ID,
+		Type:      MsgTypeEdit,
+		Content:   updated.Content,
+		Sender:    updated.Username,
+		UserID:    updated.UserID,
+		Timestamp: parseTime(updated.CreatedAt).Unix(),
+		EditedAt:  parseTime(updated.EditedAt).Unix(),
+	}
+	switch {
+	case updated.Held:
+		c.sendSystem("your edit is awaiting moderator approval")
+		return
+	case updated.Hidden:
+		c.send(edit)
+		return
+	}
+	c.Pool.broadcastChange(edit)
+}
+
+// handleDelete удаляет сообщение (своё или любое для модератора) и рассылает
+// кадр-надгробие MsgTypeDelete без текста.
+func (c *Client) handleDelete(msg Message) {
+	if c.UserID == 0 {
+		c.sendSystem("you cannot delete messages")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	deleted, err := c.Pool.ChatService.DeleteMessage(ctx, msg.MessageID, c.UserID, domain.IsModerator(c.Role))
+	if err != nil {
+		c.rejectChange(err, msg.MessageID, "delete")
+		return
+	}
+
+	c.Pool.broadcastChange(Message{
+		ID:        deleted.ID,
+		Type:      MsgTypeDelete,
+		UserID:    deleted.UserID,
+		Sender:    deleted.Username,
+		Timestamp: parseTime(deleted.CreatedAt).Unix(),
+		Deleted:   true,
+	})
+}
+
+func (c *Client) rejectChange(err error, messageID int64, action string) {
+	var rejected *domain.ContentRejectedError
+	switch {
+	case errors.As(err, &rejected):
+		c.send(Message{
+			Type:      MsgTypeSystem,
+			Content:   "your edit was rejected by the spam filter",
+			Sender:    "system",
+			Timestamp: time.Now().Unix(),
+			Reason:    strings.Join(rejected.Reasons, ","),
+		})
+	case errors.Is(err, domain.ErrMessageNotFound):
+		c.sendSystem("message not found")
+	case errors.Is(err, domain.ErrNotMessageAuthor):
+		c.sendSystem("you can only " + action + " your own messages")
+	case errors.Is(err, domain.ErrEditWindowExpired):
+		c.sendSystem(err.Error())
+	default:
+		c.logger.Warn().
+			Err(err).
+			Int64("message_id", messageID).
+			Str("action", action).
+			Msg("Failed to change message")
+		c.sendSystem("failed to " + action + " message")
+	}
+}
+
+// broadcastChange рассылает правку или удаление через outbox. Изменение уже
+// сохранено, поэтому при переполненной очереди вызывающий ждёт, а не теряет
+// кадр: иначе клиенты до перезагрузки видели бы старый текст.
+func (pool *Pool) broadcastChange(msg Message) {
+	pool.enqueue(&Event{Kind: EventBroadcast, Message: &msg})
+}
@@ -0,0 +1,326 @@
+package websocket
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strconv"
+	"sync"
+	"sync/atomic"
+
+	"github.com/gorilla/websocket"
+)
+
+// ShardCount — на сколько наборов делятся клиенты пула. У каждого шарда свой
+// мьютекс и свой воркер рассылки, так что подключения и отключения не ждут
+// рассылку по всем клиентам, а рассылка идёт по шардам параллельно.
+const ShardCount = 32
+
+// SlowConsumerPolicy — что делать с клиентом, чья очередь отправки заполнена.
+// Индикаторы набора при полной очереди отбрасываются при любой политике.
+type SlowConsumerPolicy int
+
+const (
+	// PolicyDisconnect закрывает соединение с кодом 1008 (policy violation);
+	// клиент переподключается с ?since= и дочитывает пропущенное из базы.
+	PolicyDisconnect SlowConsumerPolicy = iota
+	// PolicyDropOldest выбрасывает самый старый кадр очереди.
+	PolicyDropOldest
+	// PolicyCoalesce заменяет ждущий в очереди кадр с тем же ключом (присутствие
+	// пользователя, итоги реакций, правка сообщения) новым; если заменить нечего,
+	// клиент отключается как при PolicyDisconnect.
+	PolicyCoalesce
+)
+
+var policyNames = map[SlowConsumerPolicy]string{
+	PolicyDisconnect: "disconnect",
+	PolicyDropOldest: "drop_oldest",
+	PolicyCoalesce:   "coalesce",
+}
+
+func (p SlowConsumerPolicy) String() string {
+	if name, ok := policyNames[p]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// ParseSlowConsumerPolicy разбирает имя политики: disconnect, drop_oldest или coalesce.
+func ParseSlowConsumerPolicy(name string) (SlowConsumerPolicy, error) {
+	for policy, policyName := range policyNames {
+		if policyName == name {
+			return policy, nil
+		}
+	}
+	return 0, fmt.Errorf("unknown slow consumer policy %q", name)
+}
+
+// frame — кадр, сериализованный один раз и общий для всех получателей.
+type frame struct {
+	msgType int
+	// id — ID сообщения чата, для SSE-поля id:.
+	id int64
+	// key — ключ слияния для PolicyCoalesce; пустой — кадр не сливается.
+	key string
+	// from — автор индикатора набора: его подключениям кадр не отправляется.
+	from int64
+	data []byte
+}
+
+func encodeFrame(msg Message) (*frame, error) {
+	data, err := json.Marshal(msg)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal frame: %w", err)
+	}
+
+	f := &frame{msgType: msg.Type, data: data}
+	switch msg.Type {
+	case MsgTypeChat:
+		f.id = msg.ID
+	case MsgTypePresence:
+		f.key = "presence:" + strconv.FormatInt(msg.UserID, 10)
+	case MsgTypeTyping:
+		f.key = "typing:" + strconv.FormatInt(msg.UserID, 10)
+		f.from = msg.UserID
+	case MsgTypeReaction:
+		if msg.PostID != 0 {
+			f.key = "reaction:post:" + strconv.FormatInt(msg.PostID, 10)
+		} else {
+			f.key = "reaction:message:" + strconv.FormatInt(msg.MessageID, 10)
+		}
+	case MsgTypeEdit:
+		f.key = "edit:" + strconv.FormatInt(msg.MessageID, 10)
+	}
+	return f, nil
+}
+
+// offerResult — чем закончилась попытка поставить кадр в очередь клиента.
+type offerResult int
+
+const (
+	offerQueued offerResult = iota
+	offerDropped
+	offerCoalesced
+	offerRejected
+)
+
+// sendQueue — ограниченная очередь кадров одного клиента. Писатель клиента
+// ждёт сигнала ready и забирает всё накопленное разом.
+type sendQueue struct {
+	mu     sync.Mutex
+	frames []*frame
+	limit  int
+	// ready сигналит писателю о новых кадрах, space — ждущим push о
+	// освободившемся месте; в обоих каналах не больше одного сигнала.
+	ready chan struct{}
+	space chan struct{}
+}
+
+func newSendQueue(limit int) *sendQueue {
+	return &sendQueue{
+		frames: make([]*frame, 0, limit),
+		limit:  limit,
+		ready:  make(chan struct{}, 1),
+		space:  make(chan struct{}, 1),
+	}
+}
+
+// push ставит кадр в очередь, только если в ней есть место.
+func (q *sendQueue) push(f *frame) bool {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.frames) >= q.limit {
+		return false
+	}
+	q.frames = append(q.frames, f)
+	signal(q.ready)
+	return true
+}
+
+// offer ставит кадр в очередь, при переполнении применяя policy.
+// offerRejected означает, что клиента нужно отключить.
+func (q *sendQueue) offer(f *frame, policy SlowConsumerPolicy) offerResult {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	if len(q.frames) < q.limit {
+		q.frames = append(q.frames, f)
+		signal(q.ready)
+		return offerQueued
+	}
+	if f.msgType == MsgTypeTyping {
+		return offerDropped
+	}
+
+	switch policy {
+	case PolicyDropOldest:
+		copy(q.frames, q.frames[1:])
+		q.frames[len(q.frames)-1] = f
+		return offerDropped
+	case PolicyCoalesce:
+		if f.key != "" {
+			for i, queued := range q.frames {
+				if queued.key == f.key {
+					q.frames[i] = f
+					return offerCoalesced
+				}
+			}
+		}
+		// Место под важный кадр можно освободить за счёт индикатора набора.
+		for i, queued := range q.frames {
+			if queued.msgType == MsgTypeTyping {
+				copy(q.frames[i:], q.frames[i+1:])
+				q.frames[len(q.frames)-1] = f
+				return offerCoalesced
+			}
+		}
+	}
+	return offerRejected
+}
+
+// drain переносит все кадры очереди в buf и возвращает его.
+func (q *sendQueue) drain(buf []*frame) []*frame {
+	q.mu.Lock()
+	defer q.mu.Unlock()
+
+	buf = append(buf, q.frames...)
+	clear(q.frames)
+	q.frames = q.frames[:0]
+	signal(q.space)
+	return buf
+}
+
+func signal(ch chan struct{}) {
+	select {
+	case ch <- struct{}{}:
+	default:
+	}
+}
+
+// clientShard — часть клиентов пула со своим воркером рассылки.
+type clientShard struct {
+	mu      sync.RWMutex
+	clients map[*Client]struct{}
+	in      chan *frame
+}
+
+func newClientShard() *clientShard {
+	return &clientShard{
+		clients: make(map[*Client]struct{}),
+		in:      make(chan *frame, MaxMessageQueue),
+	}
+}
+
+// clientSeq нумерует клиентов для распределения по шардам.
+var clientSeq atomic.Uint64
+
+func (pool *Pool) shardFor(c *Client) *clientShard {
+	return pool.shards[c.id%ShardCount]
+}
+
+// forEachClient вызывает fn для каждого клиента под RLock его шарда.
+// fn не должна блокироваться.
+func (pool *Pool) forEachClient(fn func(*Client)) {
+	for _, shard := range pool.shards {
+		shard.mu.RLock()
+		for client := range shard.clients {
+			fn(client)
+		}
+		shard.mu.RUnlock()
+	}
+}
+
+// runShard доставляет кадры рассылок клиентам шарда по порядку.
+func (pool *Pool) runShard(shard *clientShard) {
+	for {
+		select {
+		case <-pool.shutdown:
+			return
+		case f := <-shard.in:
+			shard.mu.RLock()
+			for client := range shard.clients {
+				if f.from != 0 && client.UserID == f.from {
+					continue
+				}
+				pool.deliver(client, f)
+			}
+			shard.mu.RUnlock()
+		}
+	}
+}
+
+// deliver ставит кадр рассылки в очередь клиента (или откладывает до конца
+// отправки истории) и применяет политику медленного потребителя.
+func (pool *Pool) deliver(c *Client, f *frame) {
+	if c.holdBack(f) {
+		return
+	}
+	pool.offer(c, f)
+}
+
+func (pool *Pool) offer(c *Client, f *frame) bool {
+	switch c.queue.offer(f, pool.SlowConsumerPolicy) {
+	case offerDropped:
+		pool.dropped.Add(1)
+	case offerCoalesced:
+		pool.coalesced.Add(1)
+	case offerRejected:
+		if pool.disconnect(c, websocket.ClosePolicyViolation, "slow consumer") {
+			pool.slowDisconnects.Add(1)
+			c.logger.Warn().
+				Str("policy", pool.SlowConsumerPolicy.String()).
+				Msg("Send queue full, disconnecting slow consumer")
+		}
+		return false
+	}
+	return true
+}
+
+// PoolStats — счётчики пула для мониторинга и нагрузочных тестов.
+type PoolStats struct {
+	Clients int `json:"clients"`
+	// BroadcastQueue — сколько кадров ждёт в Broadcast отправки в брокер.
+	BroadcastQueue  int   `json:"broadcast_queue"`
+	Messages        int64 `json:"messages"`
+	Dropped         int64 `json:"dropped"`
+	Coalesced       int64 `json:"coalesced"`
+	SlowDisconnects int64 `json:"slow_disconnects"`
+	// FloodRejected — сколько сообщений отклонили ограничения частоты.
+	FloodRejected int64 `json:"flood_rejected"`
+}
+
+func (pool *Pool) Stats() PoolStats {
+	return PoolStats{
+		Clients:         pool.ClientCount(),
+		BroadcastQueue:  len(pool.Broadcast),
+		Messages:        pool.messages.Load(),
+		Dropped:         pool.dropped.Load(),
+		Coalesced:       pool.coalesced.Load(),
+		SlowDisconnects: pool.slowDisconnects.Load(),
+		FloodRejected:   pool.floodRejected.Load(),
+	}
+}
+
+// Check — проверка готовности пула для /readyz.
+func (pool *Pool) Check(ctx context.Context) error {
+	switch {
+	case pool.draining.Load():
+		return errors.New("pool is shutting down")
+	case !pool.running.Load():
+		return errors.New("pool is not running")
+	}
+	return nil
+}
+
+// ClientCount возвращает число клиентов, подключённых к этому экземпляру.
+func (pool *Pool) ClientCount() int {
+	count := 0
+	for _, shard := range pool.shards {
+		shard.mu.RLock()
+		count += len(shard.clients)
+		shard.mu.RUnlock()
+	}
+	return count
+}
@@ -0,0 +1,142 @@
+package websocket
+
+import (
+	"bytes"
+	"context"
+	"net/http"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
+	"github.com/rs/zerolog"
+)
+
+// Бенчмарки рассылки пула без сети и базы: клиенты подключены как
+// SSE-клиенты и пишут в память. Подробный нагрузочный прогон с задержками
+// доставки — cmd/poolbench.
+//
+//	go test ./pkg/websocket -run '^$' -bench Fanout -benchmem
+
+func BenchmarkFanout(b *testing.B) {
+	for _, clients := range []int{100, 1000, 10000} {
+		b.Run("clients="+strconv.Itoa(clients), func(b *testing.B) {
+			benchmarkFanout(b, clients, 0, PolicyDisconnect)
+		})
+	}
+}
+
+// BenchmarkFanoutSlowConsumers — 1% клиентов пишут по миллисекунде на
+// сообщение; скорость доставки остальным зависит от политики.
+func BenchmarkFanoutSlowConsumers(b *testing.B) {
+	for _, policy := range []SlowConsumerPolicy{PolicyDisconnect, PolicyDropOldest, PolicyCoalesce} {
+		b.Run("policy="+policy.String(), func(b *testing.B) {
+			benchmarkFanout(b, 1000, 0.01, policy)
+		})
+	}
+}
+
+func benchmarkFanout(b *testing.B, clients int, slowShare float64, policy SlowConsumerPolicy) {
+	level := zerolog.GlobalLevel()
+	zerolog.SetGlobalLevel(zerolog.ErrorLevel)
+	b.Cleanup(func() { zerolog.SetGlobalLevel(level) })
+
+	pool := NewPool(historyless{}, nil, nil, nil, nil, "")
+	pool.SlowConsumerPolicy = policy
+	go pool.Start()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	slow := int(float64(clients) * slowShare)
+	var delivered atomic.Int64
+	for i := 0; i < clients; i++ {
+		w := &countingWriter{header: make(http.Header), delivered: &delivered}
+		if i < slow {
+			w.delay = time.Millisecond
+			w.delivered = nil
+		}
+		client := NewStreamClient(pool, "bench_"+strconv.Itoa(i), 0, "", true, 0)
+		pool.Register <- client
+		go client.Stream(ctx, w)
+	}
+	for pool.ClientCount() < clients {
+		time.Sleep(time.Millisecond)
+	}
+
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		pool.Broadcast <- Message{
+			ID:        int64(i + 1),
+			Type:      MsgTypeChat,
+			Content:   "benchmark message",
+			Sender:    "bench",
+			Timestamp: time.Now().Unix(),
+		}
+	}
+	expected := int64(clients-slow) * int64(b.N)
+	deadline := time.Now().Add(time.Minute)
+	for delivered.Load() < expected {
+		if time.Now().After(deadline) {
+			b.Fatalf("delivered %d of %d frames", delivered.Load(), expected)
+		}
+		time.Sleep(100 * time.Microsecond)
+	}
+	b.StopTimer()
+
+	b.ReportMetric(float64(expected)/b.Elapsed().Seconds(), "deliveries/s")
+	stats := pool.Stats()
+	b.ReportMetric(float64(stats.Dropped+stats.Coalesced), "shed")
+	b.ReportMetric(float64(stats.SlowDisconnects), "slow_disconnects")
+
+	shutdownCtx, cancelShutdown := context.WithTimeout(context.Background(), 2*WriteTimeout)
+	defer cancelShutdown()
+	if err := pool.Shutdown(shutdownCtx); err != nil {
+		b.Logf("pool shutdown: %v", err)
+	}
+}
+
+// historyless — ChatService без истории: клиенты подключаются сразу.
+type historyless struct {
+	service.ChatService
+}
+
+func (historyless) GetRecentMessages(context.Context, int, int64) ([]*domain.Message, error) {
+	return nil, nil
+}
+
+func (historyless) GetMessagesAfter(context.Context, int64, int, int64) ([]*domain.Message, error) {
+	return nil, nil
+}
+
+// countingWriter — http.ResponseWriter SSE-клиента, который считает кадры
+// чата; медленный (delay > 0) задерживает запись каждого, как забитый сокет.
+type countingWriter struct {
+	header    http.Header
+	delay     time.Duration
+	delivered *atomic.Int64
+}
+
+var chatEventPrefix = []byte("event: chat\n")
+
+func (w *countingWriter) Header() http.Header { return w.header }
+
+func (w *countingWriter) WriteHeader(int) {}
+
+func (w *countingWriter) Write(p []byte) (int, error) {
+	if !bytes.HasPrefix(p, chatEventPrefix) {
+		return len(p), nil
+	}
+	if w.delay > 0 {
+		time.Sleep(w.delay)
+	}
+	if w.delivered != nil {
+		w.delivered.Add(1)
+	}
+	return len(p), nil
+}
+
+func (w *countingWriter) Flush() {}
@@ -0,0 +1,129 @@
+package websocket
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestSendQueueOffer(t *testing.T) {
+	chat := func(id int64) *frame { return &frame{msgType: MsgTypeChat, id: id} }
+	keyed := func(key string) *frame { return &frame{msgType: MsgTypePresence, key: key} }
+	typing := &frame{msgType: MsgTypeTyping, key: "typing:1"}
+
+	first, second := chat(1), chat(2)
+	presence, newPresence := keyed("presence:7"), keyed("presence:7")
+	incoming := chat(3)
+
+	tests := []struct {
+		name   string
+		queued []*frame
+		offer  *frame
+		policy SlowConsumerPolicy
+		want   offerResult
+		// after — содержимое очереди после offer.
+		after []*frame
+	}{
+		{
+			name:   "space left",
+			queued: []*frame{first},
+			offer:  incoming,
+			policy: PolicyDisconnect,
+			want:   offerQueued,
+			after:  []*frame{first, incoming},
+		},
+		{
+			name:   "disconnect when full",
+			queued: []*frame{first, second},
+			offer:  incoming,
+			policy: PolicyDisconnect,
+			want:   offerRejected,
+			after:  []*frame{first, second},
+		},
+		{
+			name:   "typing dropped under disconnect",
+			queued: []*frame{first, second},
+			offer:  typing,
+			policy: PolicyDisconnect,
+			want:   offerDropped,
+			after:  []*frame{first, second},
+		},
+		{
+			name:   "drop oldest",
+			queued: []*frame{first, second},
+			offer:  incoming,
+			policy: PolicyDropOldest,
+			want:   offerDropped,
+			after:  []*frame{second, incoming},
+		},
+		{
+			name:   "typing dropped under drop oldest",
+			queued: []*frame{first, second},
+			offer:  typing,
+			policy: PolicyDropOldest,
+			want:   offerDropped,
+			after:  []*frame{first, second},
+		},
+		{
+			name:   "coalesce same key",
+			queued: []*frame{presence, first},
+			offer:  newPresence,
+			policy: PolicyCoalesce,
+			want:   offerCoalesced,
+			after:  []*frame{newPresence, first},
+		},
+		{
+			name:   "coalesce evicts typing",
+			queued: []*frame{typing, first},
+			offer:  incoming,
+			policy: PolicyCoalesce,
+			want:   offerCoalesced,
+			after:  []*frame{first, incoming},
+		},
+		{
+			name:   "typing dropped under coalesce",
+			queued: []*frame{first, second},
+			offer:  typing,
+			policy: PolicyCoalesce,
+			want:   offerDropped,
+			after:  []*frame{first, second},
+		},
+		{
+			name:   "coalesce without match disconnects",
+			queued: []*frame{first, second},
+			offer:  incoming,
+			policy: PolicyCoalesce,
+			want:   offerRejected,
+			after:  []*frame{first, second},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q := newSendQueue(2)
+			for _, f := range tt.queued {
+				if !q.push(f) {
+					t.Fatal("push failed on a queue with space")
+				}
+			}
+
+			if got := q.offer(tt.offer, tt.policy); got != tt.want {
+				t.Errorf("offer() = %d, want %d", got, tt.want)
+			}
+			if got := q.drain(nil); !slices.Equal(got, tt.after) {
+				t.Errorf("queue after offer = %v, want %v", got, tt.after)
+			}
+		})
+	}
+}
+
+func TestParseSlowConsumerPolicy(t *testing.T) {
+	for _, policy := range []SlowConsumerPolicy{PolicyDisconnect, PolicyDropOldest, PolicyCoalesce} {
+		got, err := ParseSlowConsumerPolicy(policy.String())
+		if err != nil || got != policy {
+			t.Errorf("ParseSlowConsumerPolicy(%q) = %v, %v", policy.String(), got, err)
+		}
+	}
+	if _, err := ParseSlowConsumerPolicy("block"); err == nil {
+		t.Error("ParseSlowConsumerPolicy(\"block\") returned no error")
+	}
+}
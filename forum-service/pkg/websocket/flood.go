@@ -0,0 +1,300 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+)
+
+// Причины отклонения сообщения; уходят клиенту в поле Reason системного кадра.
+const (
+	FloodRateLimited = "rate_limited"
+	FloodDuplicate   = "duplicate"
+	FloodSlowMode    = "slow_mode"
+)
+
+// floodWarnInterval — не чаще одного предупреждения клиенту за интервал,
+// чтобы отклонённый поток сообщений не превращался в поток предупреждений.
+const floodWarnInterval = time.Second
+
+// FloodControl — ограничения на сообщения чата от одного пользователя;
+// задаётся до Start.
+type FloodControl struct {
+	// MessageInterval — в среднем не чаще одного сообщения за интервал,
+	// при этом Burst сообщений подряд проходят без ожидания. Нулевой
+	// MessageInterval отключает ограничение.
+	MessageInterval time.Duration
+	Burst           int
+	// DuplicateWindow — сколько времени отклоняется повтор предыдущего
+	// сообщения; 0 отключает проверку.
+	DuplicateWindow time.Duration
+	// SlowMode — интервал медленного режима комнаты, пока модераторы не
+	// задали свой через SetSlowMode.
+	SlowMode time.Duration
+}
+
+// FloodError — сообщение отклонено ограничениями частоты. Explanation
+// показывается отправителю, RetryAfter — когда можно попробовать снова.
+type FloodError struct {
+	Reason      string
+	Explanation string
+	RetryAfter  time.Duration
+}
+
+func (e *FloodError) Error() string {
+	return "message rejected by flood control: " + e.Reason
+}
+
+// floodState — состояние ограничений одного пользователя (под Pool.floodMu).
+// Оно хранится в памяти экземпляра: при нескольких репликах пользователь,
+// подключённый к разным экземплярам, получает на каждом свой бюджет.
+// Медленный режим задаётся для всех экземпляров сразу (SetSlowMode), но
+// время последнего сообщения тоже учитывается на каждом отдельно.
+type floodState struct {
+	// tat — время, к которому восстановится весь Burst (GCRA, как в pkg/ratelimit).
+	tat time.Time
+	// lastContent — нормализованный текст последнего принятого сообщения,
+	// lastSent — время его отправки.
+	lastContent string
+	lastSent    time.Time
+}
+
+// CheckFlood решает, можно ли принять сообщение пользователя userID: все его
+// подключения (WebSocket, SSE и HTTP) делят один бюджет. Принятое сообщение
+// сразу учитывается в той же критической секции, поэтому одновременные
+// отправки не проходят сверх Burst и мимо проверки повторов. Если сообщение
+// затем отклонено (пустое, слишком длинное, отвергнуто фильтром контента),
+// вызывающий возвращает бюджет через refund. Гости писать не могут, поэтому
+// состояние ведётся только для пользователей.
+func (pool *Pool) CheckFlood(userID int64, role, content string) (refund func(), flood *FloodError) {
+	return pool.checkFlood(userID, role, content, time.Now())
+}
+
+func (pool *Pool) checkFlood(userID int64, role, content string, now time.Time) (func(), *FloodError) {
+	if userID == 0 {
+		return func() {}, nil
+	}
+	limits := pool.FloodControl
+	slowMode := pool.SlowMode()
+	normalized := normalizeContent(content)
+
+	pool.floodMu.Lock()
+	defer pool.floodMu.Unlock()
+	state, ok := pool.flood[userID]
+	if !ok {
+		state = &floodState{}
+		pool.flood[userID] = state
+	}
+
+	// Модераторов медленный режим не касается: им нужно отвечать в чате,
+	// который они сами замедлили.
+	if slowMode > 0 && !domain.IsModerator(role) {
+		if wait := state.lastSent.Add(slowMode).Sub(now); wait > 0 {
+			return nil, &FloodError{
+				Reason:      FloodSlowMode,
+				Explanation: fmt.Sprintf("slow mode is on: one message every %s, try again in %s", slowMode, roundUp(wait)),
+				RetryAfter:  wait,
+			}
+		}
+	}
+
+	if limits.MessageInterval > 0 {
+		if wait := nextTAT(state.tat, now, limits.MessageInterval).Sub(now) - burstAllowance(limits); wait > 0 {
+			return nil, &FloodError{
+				Reason:      FloodRateLimited,
+				Explanation: fmt.Sprintf("you are sending messages too fast, try again in %s", roundUp(wait)),
+				RetryAfter:  wait,
+			}
+		}
+	}
+
+	if limits.DuplicateWindow > 0 && normalized == state.lastContent {
+		if wait := state.lastSent.Add(limits.DuplicateWindow).Sub(now); wait > 0 {
+			return nil, &FloodError{
+				Reason:      FloodDuplicate,
+				Explanation: "you have just sent the same message",
+				RetryAfter:  wait,
+			}
+		}
+	}
+
+	prev := *state
+	if limits.MessageInterval > 0 {
+		state.tat = nextTAT(state.tat, now, limits.MessageInterval)
+	}
+	state.lastContent = normalized
+	state.lastSent = now
+
+	var once sync.Once
+	return func() {
+		once.Do(func() { pool.refundFlood(userID, prev, now, normalized) })
+	}, nil
+}
+
+// refundFlood возвращает бюджет сообщения, учтённого в момент sent и затем
+// отклонённого. prev — состояние до него: текст и время последнего сообщения
+// восстанавливаются, только если после этого сообщения других не было.
+func (pool *Pool) refundFlood(userID int64, prev floodState, sent time.Time, content string) {
+	pool.floodMu.Lock()
+	defer pool.floodMu.Unlock()
+
+	state, ok := pool.flood[userID]
+	if !ok {
+		return
+	}
+	if interval := pool.FloodControl.MessageInterval; interval > 0 {
+		state.tat = state.tat.Add(-interval)
+	}
+	if state.lastSent.Equal(sent) && state.lastContent == content {
+		state.lastContent = prev.lastContent
+		state.lastSent = prev.lastSent
+	}
+}
+
+// evictFlood забывает пользователей, на чьи следующие сообщения их прошлые
+// уже не влияют.
+func (pool *Pool) evictFlood(now time.Time) {
+	limits := pool.FloodControl
+	idle := max(limits.DuplicateWindow, pool.SlowMode())
+
+	pool.floodMu.Lock()
+	defer pool.floodMu.Unlock()
+	for userID, state := range pool.flood {
+		if !state.tat.After(now) && now.Sub(state.lastSent) >= idle {
+			delete(pool.flood, userID)
+		}
+	}
+}
+
+// nextTAT — значение tat после ещё одного сообщения в момент now.
+func nextTAT(tat, now time.Time, interval time.Duration) time.Time {
+	if tat.Before(now) {
+		tat = now
+	}
+	return tat.Add(interval)
+}
+
+// burstAllowance — насколько tat может опережать текущее время.
+func burstAllowance(limits FloodControl) time.Duration {
+	return time.Duration(max(limits.Burst, 1)) * limits.MessageInterval
+}
+
+// rejectFlood учитывает отклонённое сообщение и объясняет отправителю
+// причину системным кадром.
+func (c *Client) rejectFlood(flood *FloodError, now time.Time) {
+	c.Pool.floodRejected.Add(1)
+	c.logger.Debug().
+		Str("reason", flood.Reason).
+		Msg("Message rejected by flood control")
+
+	c.stateMu.Lock()
+	warn := now.Sub(c.lastFloodWarning) >= floodWarnInterval
+	if warn {
+		c.lastFloodWarning = now
+	}
+	c.stateMu.Unlock()
+
+	if warn {
+		c.send(Message{
+			Type:      MsgTypeSystem,
+			Content:   flood.Explanation,
+			Sender:    "system",
+			Timestamp: now.Unix(),
+			Reason:    flood.Reason,
+		})
+	}
+}
+
+// FloodRejected учитывает сообщение, отклонённое CheckFlood вне WebSocket
+// (например, отправленное через HTTP).
+func (pool *Pool) FloodRejected() {
+	pool.floodRejected.Add(1)
+}
+
+// SlowMode возвращает текущий интервал медленного режима; 0 — выключен.
+func (pool *Pool) SlowMode() time.Duration {
+	return time.Duration(pool.slowMode.Load())
+}
+
+// SlowModeStore сохраняет интервал медленного режима, чтобы его видели
+// экземпляры, запущенные позже, и он переживал перезапуск.
+type SlowModeStore interface {
+	LoadSlowMode(ctx context.Context) (interval time.Duration, ok bool, err error)
+	SaveSlowMode(ctx context.Context, interval time.Duration) error
+}
+
+// SetSlowMode включает медленный режим комнаты на всех экземплярах: обычные
+// пользователи могут отправлять не больше одного сообщения за interval.
+// 0 выключает режим. Интервал сохраняется в SlowModeStore.
+func (pool *Pool) SetSlowMode(ctx context.Context, interval time.Duration) error {
+	if pool.SlowModeStore != nil {
+		if err := pool.SlowModeStore.SaveSlowMode(ctx, interval); err != nil {
+			return err
+		}
+	}
+	pool.publish(&Event{Kind: EventSlowMode, SlowMode: interval})
+	return nil
+}
+
+// loadSlowMode возвращает сохранённый интервал медленного режима, а если
+// его нет или хранилище недоступно — FloodControl.SlowMode.
+func (pool *Pool) loadSlowMode() time.Duration {
+	if pool.SlowModeStore == nil {
+		return pool.FloodControl.SlowMode
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	interval, ok, err := pool.SlowModeStore.LoadSlowMode(ctx)
+	if err != nil {
+		pool.logger.Error().Err(err).Msg("Failed to load slow mode, using configured value")
+		return pool.FloodControl.SlowMode
+	}
+	if !ok {
+		return pool.FloodControl.SlowMode
+	}
+	return interval
+}
+
+// applySlowMode меняет интервал на этом экземпляре и сообщает об этом
+// всем его клиентам.
+func (pool *Pool) applySlowMode(interval time.Duration) {
+	if time.Duration(pool.slowMode.Swap(int64(interval))) == interval {
+		return
+	}
+	pool.logger.Info().
+		Dur("interval", interval).
+		Msg("Slow mode changed")
+	pool.broadcastMessage(slowModeFrame(interval))
+}
+
+// slowModeFrame — системный кадр о состоянии медленного режима.
+func slowModeFrame(interval time.Duration) Message {
+	content := "slow mode is off"
+	if interval > 0 {
+		content = fmt.Sprintf("slow mode is on: one message every %s", interval)
+	}
+	return Message{
+		Type:      MsgTypeSystem,
+		Content:   content,
+		Sender:    "system",
+		Timestamp: time.Now().Unix(),
+		Reason:    FloodSlowMode,
+	}
+}
+
+// normalizeContent приводит текст к виду, в котором повторы с другим
+// регистром или пробелами считаются одинаковыми.
+func normalizeContent(content string) string {
+	return strings.ToLower(strings.Join(strings.Fields(content), " "))
+}
+
+// roundUp округляет d вверх до целых секунд для сообщений пользователю.
+func roundUp(d time.Duration) time.Duration {
+	return time.Duration(math.Ceil(d.Seconds())) * time.Second
+}
@@ -0,0 +1,140 @@
+package websocket
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+)
+
+func newFloodPool(limits FloodControl) *Pool {
+	pool := NewPool(historyless{}, nil, nil, nil, nil, "")
+	pool.FloodControl = limits
+	pool.slowMode.Store(int64(limits.SlowMode))
+	return pool
+}
+
+func TestCheckFloodBurst(t *testing.T) {
+	pool := newFloodPool(FloodControl{MessageInterval: time.Second, Burst: 3})
+	now := time.Unix(1_700_000_000, 0)
+
+	for i := 0; i < 3; i++ {
+		if _, flood := pool.checkFlood(1, domain.RoleUser, fmt.Sprintf("message %d", i), now); flood != nil {
+			t.Fatalf("message %d within burst rejected: %v", i+1, flood)
+		}
+	}
+
+	_, flood := pool.checkFlood(1, domain.RoleUser, "one more", now)
+	if flood == nil || flood.Reason != FloodRateLimited {
+		t.Fatalf("message over burst = %v, want %s", flood, FloodRateLimited)
+	}
+	if flood.RetryAfter != time.Second {
+		t.Errorf("RetryAfter = %s, want 1s", flood.RetryAfter)
+	}
+
+	if _, flood := pool.checkFlood(1, domain.RoleUser, "one more", now.Add(time.Second)); flood != nil {
+		t.Errorf("message after interval rejected: %v", flood)
+	}
+	if _, flood := pool.checkFlood(2, domain.RoleUser, "other user", now); flood != nil {
+		t.Errorf("other user's message rejected: %v", flood)
+	}
+}
+
+func TestCheckFloodRefund(t *testing.T) {
+	pool := newFloodPool(FloodControl{MessageInterval: time.Second, Burst: 1, DuplicateWindow: 30 * time.Second})
+	now := time.Unix(1_700_000_000, 0)
+
+	refund, flood := pool.checkFlood(1, domain.RoleUser, "message", now)
+	if flood != nil {
+		t.Fatalf("first message rejected: %v", flood)
+	}
+	// Проверка сразу резервирует бюджет: второе сообщение не проходит,
+	// даже если первое ещё не сохранено.
+	if _, flood := pool.checkFlood(1, domain.RoleUser, "next", now); flood == nil {
+		t.Fatal("concurrent message over burst accepted")
+	}
+
+	// Отклонённое позже сообщение бюджет не тратит и повтором не считается.
+	refund()
+	refund()
+	if _, flood := pool.checkFlood(1, domain.RoleUser, "message", now); flood != nil {
+		t.Errorf("message after refund rejected: %v", flood)
+	}
+	if _, flood := pool.checkFlood(1, domain.RoleUser, "next", now); flood == nil {
+		t.Error("double refund returned budget twice")
+	}
+}
+
+func TestCheckFloodDuplicate(t *testing.T) {
+	pool := newFloodPool(FloodControl{DuplicateWindow: 30 * time.Second})
+	now := time.Unix(1_700_000_000, 0)
+	pool.checkFlood(1, domain.RoleUser, "Hello  world", now)
+
+	tests := []struct {
+		name    string
+		content string
+		at      time.Duration
+		want    bool
+	}{
+		{"same text", "Hello  world", time.Second, true},
+		{"differs in case and spaces", " hello WORLD ", time.Second, true},
+		{"different text", "hello there", time.Second, false},
+		{"same text after window", "Hello  world", 30 * time.Second, false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, flood := pool.checkFlood(1, domain.RoleUser, tt.content, now.Add(tt.at))
+			if got := flood != nil; got != tt.want {
+				t.Fatalf("rejected = %v, want %v (%v)", got, tt.want, flood)
+			}
+			if flood != nil && flood.Reason != FloodDuplicate {
+				t.Errorf("reason = %s, want %s", flood.Reason, FloodDuplicate)
+			}
+		})
+	}
+}
+
+func TestCheckFloodSlowMode(t *testing.T) {
+	pool := newFloodPool(FloodControl{SlowMode: 10 * time.Second})
+	now := time.Unix(1_700_000_000, 0)
+	pool.checkFlood(1, domain.RoleUser, "first", now)
+	pool.checkFlood(2, domain.RoleModerator, "first", now)
+
+	_, flood := pool.checkFlood(1, domain.RoleUser, "second", now.Add(4*time.Second))
+	if flood == nil || flood.Reason != FloodSlowMode {
+		t.Fatalf("message in slow mode = %v, want %s", flood, FloodSlowMode)
+	}
+	if flood.RetryAfter != 6*time.Second {
+		t.Errorf("RetryAfter = %s, want 6s", flood.RetryAfter)
+	}
+
+	if _, flood := pool.checkFlood(2, domain.RoleModerator, "second", now.Add(4*time.Second)); flood != nil {
+		t.Errorf("moderator rejected by slow mode: %v", flood)
+	}
+	if _, flood := pool.checkFlood(1, domain.RoleUser, "second", now.Add(10*time.Second)); flood != nil {
+		t.Errorf("message after slow mode interval rejected: %v", flood)
+	}
+
+	pool.slowMode.Store(0)
+	if _, flood := pool.checkFlood(1, domain.RoleUser, "second", now.Add(time.Second)); flood != nil {
+		t.Errorf("message rejected with slow mode off: %v", flood)
+	}
+}
+
+func TestEvictFlood(t *testing.T) {
+	pool := newFloodPool(FloodControl{MessageInterval: time.Second, Burst: 2, DuplicateWindow: 30 * time.Second})
+	now := time.Unix(1_700_000_000, 0)
+	pool.checkFlood(1, domain.RoleUser, "message", now)
+
+	pool.evictFlood(now.Add(10 * time.Second))
+	if _, ok := pool.flood[1]; !ok {
+		t.Fatal("state evicted inside duplicate window")
+	}
+
+	pool.evictFlood(now.Add(30 * time.Second))
+	if _, ok := pool.flood[1]; ok {
+		t.Error("idle state not evicted")
+	}
+}
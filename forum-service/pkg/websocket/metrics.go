@@ -0,0 +1,81 @@
+package websocket
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+var (
+	clientsDesc = prometheus.NewDesc(
+		"chat_clients",
+		"WebSocket and SSE clients connected to this instance.",
+		nil, nil,
+	)
+	broadcastQueueDesc = prometheus.NewDesc(
+		"chat_broadcast_queue_depth",
+		"Frames waiting in Pool.Broadcast to be published to the broker.",
+		nil, nil,
+	)
+	messagesDesc = prometheus.NewDesc(
+		"chat_messages_total",
+		"Chat messages published by this instance; rate() gives messages per second.",
+		nil, nil,
+	)
+	droppedDesc = prometheus.NewDesc(
+		"chat_frames_dropped_total",
+		"Frames dropped from full client send queues.",
+		nil, nil,
+	)
+	coalescedDesc = prometheus.NewDesc(
+		"chat_frames_coalesced_total",
+		"Queued frames replaced by newer ones under the coalesce policy.",
+		nil, nil,
+	)
+	slowDisconnectsDesc = prometheus.NewDesc(
+		"chat_slow_consumer_disconnects_total",
+		"Clients disconnected because their send queue was full.",
+		nil, nil,
+	)
+	floodRejectedDesc = prometheus.NewDesc(
+		"chat_messages_rejected_total",
+		"Chat messages rejected by flood control, duplicate suppression or slow mode.",
+		nil, nil,
+	)
+	slowModeDesc = prometheus.NewDesc(
+		"chat_slow_mode_seconds",
+		"Current slow mode interval of the chat room; 0 when off.",
+		nil, nil,
+	)
+)
+
+// poolCollector снимает PoolStats при каждом опросе /metrics.
+type poolCollector struct {
+	pool *Pool
+}
+
+// Collector возвращает коллектор Prometheus с метриками пула.
+func (pool *Pool) Collector() prometheus.Collector {
+	return &poolCollector{pool: pool}
+}
+
+func (c *poolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- clientsDesc
+	ch <- broadcastQueueDesc
+	ch <- messagesDesc
+	ch <- droppedDesc
+	ch <- coalescedDesc
+	ch <- slowDisconnectsDesc
+	ch <- floodRejectedDesc
+	ch <- slowModeDesc
+}
+
+func (c *poolCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.pool.Stats()
+	ch <- prometheus.MustNewConstMetric(clientsDesc, prometheus.GaugeValue, float64(stats.Clients))
+	ch <- prometheus.MustNewConstMetric(broadcastQueueDesc, prometheus.GaugeValue, float64(stats.BroadcastQueue))
+	ch <- prometheus.MustNewConstMetric(messagesDesc, prometheus.CounterValue, float64(stats.Messages))
+	ch <- prometheus.MustNewConstMetric(droppedDesc, prometheus.CounterValue, float64(stats.Dropped))
+	ch <- prometheus.MustNewConstMetric(coalescedDesc, prometheus.CounterValue, float64(stats.Coalesced))
+	ch <- prometheus.MustNewConstMetric(slowDisconnectsDesc, prometheus.CounterValue, float64(stats.SlowDisconnects))
+	ch <- prometheus.MustNewConstMetric(floodRejectedDesc, prometheus.CounterValue, float64(stats.FloodRejected))
+	ch <- prometheus.MustNewConstMetric(slowModeDesc, prometheus.GaugeValue, c.pool.SlowMode().Seconds())
+}
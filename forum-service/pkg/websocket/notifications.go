@@ -0,0 +1,85 @@
+package websocket
+
+import (
+	"context"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
+)
+
+func notificationMessage(n *notifications.Notification) Message {
+	return Message{
+		Type:         MsgTypeNotification,
+		Content:      n.Text,
+		Sender:       n.Actor,
+		UserID:       n.ActorID,
+		Timestamp:    parseTime(n.CreatedAt).Unix(),
+		Notification: n,
+	}
+}
+
+// deliverNotification отправляет уведомление подключениям адресата на этом
+// экземпляре и отмечает его доставленным. Если адресат нигде не в сети,
+// уведомление ждёт следующего подключения.
+func (pool *Pool) deliverNotification(n *notifications.Notification) {
+	if pool.sendToUser(n.UserID, notificationMessage(n)) == 0 {
+		pool.logger.Debug().
+			Int64("notification_id", n.ID).
+			Int64("user_id", n.UserID).
+			Msg("No local connections for notification")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Notifications.MarkDelivered(ctx, []int64{n.ID}); err != nil {
+		pool.logger.Warn().Err(err).Int64("notification_id", n.ID).Msg("Failed to mark notification delivered")
+	}
+}
+
+// sendPendingNotifications отдаёт только что подключившемуся клиенту уведомления,
+// накопленные, пока пользователь был не в сети.
+func (pool *Pool) sendPendingNotifications(c *Client) {
+	if c.UserID == 0 || pool.Notifications == nil {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	pending, err := pool.Notifications.Pending(ctx, c.UserID)
+	if err != nil || len(pending) == 0 {
+		return
+	}
+
+	delivered := make([]int64, 0, len(pending))
+	for _, n := range pending {
+		if !c.sendWait(notificationMessage(n)) {
+			pool.logger.Warn().Int64("user_id", c.UserID).Msg("Client send timeout while delivering notifications")
+			break
+		}
+		delivered = append(delivered, n.ID)
+	}
+
+	if err := pool.Notifications.MarkDelivered(ctx, delivered); err != nil {
+		pool.logger.Warn().Err(err).Int64("user_id", c.UserID).Msg("Failed to mark notifications delivered")
+	}
+}
+
+// sendToUser ставит кадр в очереди всех подключений пользователя на этом
+// экземпляре и возвращает, скольким подключениям кадр ушёл.
+func (pool *Pool) sendToUser(userID int64, msg Message) int {
+	f, err := encodeFrame(msg)
+	if err != nil {
+		pool.logger.Error().Err(err).Int64("user_id", userID).Msg("Failed to encode frame")
+		return 0
+	}
+
+	sent := 0
+	pool.forEachClient(func(client *Client) {
+		if client.UserID == userID && pool.offer(client, f) {
+			sent++
+		}
+	})
+	return sent
+}
@@ -0,0 +1,141 @@
+package websocket
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/lib/pq"
+	"github.com/rs/zerolog"
+	"github.com/rs/zerolog/log"
+)
+
+const (
+	// PoolChannel — канал LISTEN/NOTIFY, через который экземпляры обмениваются событиями.
+	PoolChannel = "forum_pool_events"
+	// NotifyPayloadLimit — предел полезной нагрузки NOTIFY (в Postgres 8000 байт
+	// с запасом). Вместо более крупного сообщения чата по каналу уходит его
+	// ID, и получатели перечитывают сообщение из базы.
+	NotifyPayloadLimit = 7900
+)
+
+// PostgresBroker разносит события пула между экземплярами через
+// LISTEN/NOTIFY. Публикация идёт через общий пул соединений, подписка —
+// через выделенное соединение pq.Listener с автоматическим переподключением.
+// После переподключения в Events приходит EventResync: уведомления за время
+// разрыва потеряны, и пул досылает пропущенные сообщения из базы.
+type PostgresBroker struct {
+	db        *sql.DB
+	listener  *pq.Listener
+	events    chan *Event
+	done      chan struct{}
+	closeOnce sync.Once
+	logger    zerolog.Logger
+}
+
+// NewPostgresBroker подписывается на PoolChannel. connStr — строка
+// подключения к той же базе, что и db.
+func NewPostgresBroker(db *sql.DB, connStr string) (*PostgresBroker, error) {
+	b := &PostgresBroker{
+		db:     db,
+		events: make(chan *Event, MaxMessageQueue),
+		done:   make(chan struct{}),
+		logger: log.With().Str("component", "postgres_broker").Logger(),
+	}
+
+	b.listener = pq.NewListener(connStr, time.Second, time.Minute, func(event pq.ListenerEventType, err error) {
+		switch event {
+		case pq.ListenerEventDisconnected:
+			b.logger.Warn().Err(err).Msg("Listener disconnected")
+		case pq.ListenerEventReconnected:
+			b.logger.Info().Msg("Listener reconnected")
+		case pq.ListenerEventConnectionAttemptFailed:
+			b.logger.Warn().Err(err).Msg("Listener connection attempt failed")
+		}
+	})
+	if err := b.listener.Listen(PoolChannel); err != nil {
+		b.listener.Close()
+		return nil, fmt.Errorf("failed to listen on %s: %w", PoolChannel, err)
+	}
+
+	go b.run()
+
+	b.logger.Info().Str("channel", PoolChannel).Msg("Postgres broker started")
+	return b, nil
+}
+
+func (b *PostgresBroker) Publish(ctx context.Context, event *Event) error {
+	data, err := json.Marshal(event)
+	if err != nil {
+		return fmt.Errorf("failed to marshal event: %w", err)
+	}
+
+	if len(data) > NotifyPayloadLimit {
+		ref := event.withMessageRef()
+		if ref == nil {
+			return fmt.Errorf("%s event is %d bytes, over the NOTIFY limit of %d", event.Kind, len(data), NotifyPayloadLimit)
+		}
+		if data, err = json.Marshal(ref); err != nil {
+			return fmt.Errorf("failed to marshal event: %w", err)
+		}
+	}
+
+	if _, err := b.db.ExecContext(ctx, `SELECT pg_notify($1, $2)`, PoolChannel, string(data)); err != nil {
+		return fmt.Errorf("failed to notify: %w", err)
+	}
+	return nil
+}
+
+func (b *PostgresBroker) Events() <-chan *Event {
+	return b.events
+}
+
+func (b *PostgresBroker) Close() error {
+	b.closeOnce.Do(func() { close(b.done) })
+	return b.listener.Close()
+}
+
+// run читает уведомления слушателя, пока брокер не закрыт.
+func (b *PostgresBroker) run() {
+	// Ping проверяет соединение слушателя, даже когда событий нет.
+	ping := time.NewTicker(90 * time.Second)
+	defer ping.Stop()
+
+	for {
+		select {
+		case <-b.done:
+			return
+
+		case n, ok := <-b.listener.Notify:
+			if !ok {
+				return
+			}
+			// nil приходит после переподключения: уведомления за время
+			// разрыва потеряны, пул досылает сообщения сам.
+			var event *Event
+			if n == nil {
+				b.logger.Warn().Msg("Listener reconnected, requesting resync of missed messages")
+				event = &Event{Kind: EventResync}
+			} else {
+				event = &Event{}
+				if err := json.Unmarshal([]byte(n.Extra), event); err != nil {
+					b.logger.Warn().Err(err).Msg("Failed to decode pool event")
+					continue
+				}
+			}
+			select {
+			case b.events <- event:
+			case <-b.done:
+				return
+			}
+
+		case <-ping.C:
+			if err := b.listener.Ping(); err != nil {
+				b.logger.Warn().Err(err).Msg("Listener ping failed")
+			}
+		}
+	}
+}
@@ -6,25 +6,42 @@ import (
 	"errors"
 	"fmt"
 	"net/http"
+	"slices"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/avatar"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/notifications"
 	"github.com/Frozz164/forum-app_v2/forum-service/internal/service"
 	"github.com/gorilla/websocket"
 	"github.com/rs/zerolog"
 	"github.com/rs/zerolog/log"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 )
 
 const (
-	MsgTypeChat     = 1
-	MsgTypeSystem   = 2
-	PingInterval    = 25 * time.Second
-	WriteTimeout    = 10 * time.Second
-	ReadTimeout     = PingInterval * 2
-	MaxMsgSize      = 1024
-	BufferSize      = 256
-	MaxMessageQueue = 100
-	ReconnectDelay  = 3 * time.Second
+	MsgTypeChat         = 1
+	MsgTypeSystem       = 2
+	MsgTypeReport       = 3
+	MsgTypePresence     = 4
+	MsgTypeTyping       = 5
+	MsgTypeEdit         = 6
+	MsgTypeDelete       = 7
+	MsgTypeReaction     = 8
+	MsgTypeNotification = 9
+	MsgTypeGap          = 10
+	PingInterval        = 25 * time.Second
+	WriteTimeout        = 10 * time.Second
+	ReadTimeout         = PingInterval * 2
+	MaxMsgSize          = 1024
+	BufferSize          = 256
+	MaxResumeMessages   = 500
+	MaxMessageQueue     = 100
+	ReconnectDelay      = 3 * time.Second
 )
 
 var (
@@ -39,48 +56,162 @@ var (
 )
 
 type Message struct {
+	ID        int64  `json:"id,omitempty"`
 	Type      int    `json:"type"`
 	Content   string `json:"content"`
 	Sender    string `json:"sender"`
 	Timestamp int64  `json:"timestamp"`
 	UserID    int64  `json:"user_id,omitempty"`
+	AvatarURL string `json:"avatar_url,omitempty"`
+	EditedAt  int64  `json:"edited_at,omitempty"`
+	Deleted   bool   `json:"deleted,omitempty"`
+	// Event — joined или left для кадров присутствия (MsgTypePresence).
+	Event string `json:"event,omitempty"`
+	// MessageID — сообщение, на которое ссылается кадр жалобы, правки или удаления;
+	// Reason — причина жалобы (MsgTypeReport) или отклонения сообщения
+	// в системном кадре (FloodRateLimited и другие).
+	MessageID int64  `json:"message_id,omitempty"`
+	Reason    string `json:"reason,omitempty"`
+	// Поля кадра реакции (MsgTypeReaction): цель — MessageID или PostID,
+	// Added — поставлена реакция или снята, Reactions — новые итоги по цели.
+	PostID    int64                  `json:"post_id,omitempty"`
+	Emoji     string                 `json:"emoji,omitempty"`
+	Added     bool                   `json:"added,omitempty"`
+	Reactions []domain.ReactionCount `json:"reactions,omitempty"`
+	// ReplyTo — ID сообщения, на которое отвечает кадр MsgTypeChat; Quote — его превью.
+	ReplyTo int64                `json:"reply_to,omitempty"`
+	Quote   *domain.MessageQuote `json:"quote,omitempty"`
+	// Notification — новое уведомление адресата в кадре MsgTypeNotification.
+	Notification *notifications.Notification `json:"notification,omitempty"`
 }
 
 type Client struct {
-	Conn      *websocket.Conn
-	Pool      *Pool
-	Username  string
-	UserID    int64
-	ReadOnly  bool
-	Send      chan Message
+	Conn     *websocket.Conn
+	Pool     *Pool
+	Username string
+	UserID   int64
+	Role     string
+	ReadOnly bool // читать через IsReadOnly: флаг меняется при мьюте
+	// id определяет шард клиента, queue — его ограниченная очередь отправки.
+	id        uint64
+	queue     *sendQueue
 	mu        sync.Mutex
+	stateMu   sync.RWMutex
 	done      chan struct{}
 	closeOnce sync.Once
-	logger    zerolog.Logger
+	closeCode int
+	closeText string
+	// finished закрывает писатель клиента (Write или Stream), отправив кадр
+	// закрытия; его ждёт Shutdown.
+	finished chan struct{}
+	// lastTyping — время последнего разосланного индикатора набора (под stateMu).
+	lastTyping time.Time
+	// resumeAfter — ID последнего полученного сообщения; при подключении вместо
+	// недавней истории досылаются только более новые сообщения.
+	resumeAfter int64
+	// replaying — история ещё отправляется; рассылки копятся в pending
+	// и уходят после неё (оба поля под stateMu).
+	replaying bool
+	pending   []*frame
+	// lastFloodWarning — когда клиенту последний раз объяснили, почему его
+	// сообщение отклонено (под stateMu).
+	lastFloodWarning time.Time
+	// mutes — действующие мьюты клиента: ID санкции → срок окончания,
+	// нулевой для бессрочного (под stateMu).
+	mutes  map[int64]time.Time
+	logger zerolog.Logger
 }
 
 type Pool struct {
-	Register    chan *Client
-	Unregister  chan *Client
-	Broadcast   chan Message
-	Clients     map[*Client]bool
-	clientMutex sync.RWMutex
-	ChatService service.ChatService
-	shutdown    chan struct{}
-	wg          sync.WaitGroup
-	logger      zerolog.Logger
+	Register      chan *Client
+	Unregister    chan *Client
+	Broadcast     chan Message
+	shards        [ShardCount]*clientShard
+	ChatService   service.ChatService
+	ReportService service.ReportService
+	// ReactionService — реакции на сообщения; nil отключает кадры MsgTypeReaction.
+	ReactionService service.ReactionService
+	// Notifications — доставка уведомлений; nil отключает кадры MsgTypeNotification.
+	Notifications *notifications.Service
+	AvatarBaseURL string
+	// Broker разносит рассылки, присутствие, санкции и уведомления между
+	// экземплярами сервиса; клиентам уходит только то, что пришло из него.
+	Broker Broker
+	// outbox — события для брокера; publishLoop отправляет их по одному,
+	// сохраняя порядок.
+	outbox chan *Event
+	// SlowConsumerPolicy — что делать с клиентом, чья очередь заполнена;
+	// задаётся до Start.
+	SlowConsumerPolicy SlowConsumerPolicy
+	// FloodControl — ограничения частоты сообщений пользователей; задаётся до Start.
+	FloodControl FloodControl
+	// SlowModeStore хранит медленный режим между перезапусками; задаётся
+	// до Start. Без него режим живёт только в памяти экземпляров.
+	SlowModeStore SlowModeStore
+	// flood — состояние ограничений частоты по UserID.
+	flood   map[int64]*floodState
+	floodMu sync.Mutex
+	// slowMode — текущий интервал медленного режима в наносекундах.
+	slowMode        atomic.Int64
+	floodRejected   atomic.Int64
+	dropped         atomic.Int64
+	coalesced       atomic.Int64
+	slowDisconnects atomic.Int64
+	// messages — сколько сообщений чата опубликовал этот экземпляр.
+	messages        atomic.Int64
+	instanceID      string
+	presence        map[int64]*presenceEntry
+	presenceMu      sync.Mutex
+	localPresence   map[int64]localPresence
+	localPresenceMu sync.Mutex
+	// running — цикл Start работает; draining — пул останавливается:
+	// новые клиенты сразу отключаются.
+	running  atomic.Bool
+	draining atomic.Bool
+	// unacked — сколько опубликованных этим экземпляром событий ещё не
+	// вернулось из брокера.
+	unacked atomic.Int64
+	// lastMessageID — самое новое сообщение чата, пришедшее из брокера;
+	// resynced — сообщения, уже досланные после разрыва подписки брокера.
+	// Оба поля меняет только consumeEvents.
+	lastMessageID int64
+	resynced      map[int64]struct{}
+	shutdown      chan struct{}
+	// loops — цикл Start и его воркеры, wg — фоновые задачи пула
+	// (история, санкции, уведомления).
+	loops  sync.WaitGroup
+	wg     sync.WaitGroup
+	logger zerolog.Logger
 }
 
-func NewPool(chatService service.ChatService) *Pool {
-	return &Pool{
-		Register:    make(chan *Client, 10),
-		Unregister:  make(chan *Client, 10),
-		Broadcast:   make(chan Message, MaxMessageQueue),
-		Clients:     make(map[*Client]bool),
-		ChatService: chatService,
-		shutdown:    make(chan struct{}),
-		logger:      log.With().Str("component", "websocket_pool").Logger(),
+// NewPool создаёт пул клиентов. Без broker пул работает в одном экземпляре
+// через MemoryBroker.
+func NewPool(chatService service.ChatService, reportService service.ReportService, reactionService service.ReactionService, notificationService *notifications.Service, broker Broker, avatarBaseURL string) *Pool {
+	if broker == nil {
+		broker = NewMemoryBroker()
 	}
+	pool := &Pool{
+		Register:        make(chan *Client, 10),
+		Unregister:      make(chan *Client, 10),
+		Broadcast:       make(chan Message, MaxMessageQueue),
+		ChatService:     chatService,
+		ReportService:   reportService,
+		ReactionService: reactionService,
+		Notifications:   notificationService,
+		AvatarBaseURL:   avatarBaseURL,
+		Broker:          broker,
+		outbox:          make(chan *Event, MaxMessageQueue),
+		instanceID:      newInstanceID(),
+		presence:        make(map[int64]*presenceEntry),
+		localPresence:   make(map[int64]localPresence),
+		flood:           make(map[int64]*floodState),
+		shutdown:        make(chan struct{}),
+		logger:          log.With().Str("component", "websocket_pool").Logger(),
+	}
+	for i := range pool.shards {
+		pool.shards[i] = newClientShard()
+	}
+	return pool
 }
 
 func (pool *Pool) Start() {
@@ -92,11 +223,36 @@ func (pool *Pool) Start() {
 		}
 	}()
 
-	pool.logger.Info().Msg("Starting WebSocket pool")
+	pool.logger.Info().
+		Str("instance_id", pool.instanceID).
+		Str("slow_consumer_policy", pool.SlowConsumerPolicy.String()).
+		Msg("Starting WebSocket pool")
+	pool.slowMode.Store(int64(pool.loadSlowMode()))
+
+	pool.loops.Add(1 + len(pool.shards) + 2)
+	defer pool.loops.Done()
+	pool.running.Store(true)
+	defer pool.running.Store(false)
+	for _, shard := range pool.shards {
+		go func() {
+			defer pool.loops.Done()
+			pool.runShard(shard)
+		}()
+	}
+	go func() {
+		defer pool.loops.Done()
+		pool.publishLoop()
+	}()
+	go func() {
+		defer pool.loops.Done()
+		pool.consumeEvents()
+	}()
 
 	ticker := time.NewTicker(PingInterval)
 	defer ticker.Stop()
 
+	// Регистрация и отключение только меняют наборы клиентов и ставят
+	// события в outbox, поэтому обрабатываются прямо в этом цикле.
 	for {
 		select {
 		case <-pool.shutdown:
@@ -104,34 +260,201 @@ func (pool *Pool) Start() {
 			return
 
 		case client := <-pool.Register:
-			pool.wg.Add(1)
-			go func(c *Client) {
-				defer pool.wg.Done()
-				pool.handleNewClient(c)
-			}(client)
+			pool.handleNewClient(client)
 
 		case client := <-pool.Unregister:
-			pool.wg.Add(1)
-			go func(c *Client) {
-				defer pool.wg.Done()
-				pool.handleDisconnect(c)
-			}(client)
+			pool.handleDisconnect(client)
+
+		case <-ticker.C:
+			pool.refreshPresence()
+			pool.evictFlood(time.Now())
+		}
+	}
+}
+
+// publishLoop по одному отправляет в брокер кадры из Broadcast, события из
+// outbox и новые уведомления, сохраняя порядок каждого источника.
+func (pool *Pool) publishLoop() {
+	var deliveries <-chan *notifications.Notification
+	if pool.Notifications != nil {
+		deliveries = pool.Notifications.Deliveries()
+	}
+
+	for {
+		select {
+		case <-pool.shutdown:
+			return
+		case msg := <-pool.Broadcast:
+			pool.publish(&Event{Kind: EventBroadcast, Message: &msg})
+		case event := <-pool.outbox:
+			pool.publish(event)
+		case n := <-deliveries:
+			pool.publish(&Event{Kind: EventNotification, Notification: n})
+		}
+	}
+}
+
+// enqueue ставит событие в outbox для publishLoop.
+func (pool *Pool) enqueue(event *Event) {
+	select {
+	case pool.outbox <- event:
+	case <-pool.shutdown:
+	}
+}
+
+// publish отправляет событие в брокер от имени этого экземпляра.
+func (pool *Pool) publish(event *Event) {
+	event.Origin = pool.instanceID
+	pool.unacked.Add(1)
+	if event.Kind == EventBroadcast && event.Message.Type == MsgTypeChat {
+		pool.messages.Add(1)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := pool.Broker.Publish(ctx, event); err != nil {
+		pool.unacked.Add(-1)
+		pool.logger.Error().
+			Err(err).
+			Str("kind", event.Kind).
+			Msg("Failed to publish pool event")
+	}
+}
+
+// consumeEvents применяет события брокера к клиентам этого экземпляра
+// в порядке поступления.
+func (pool *Pool) consumeEvents() {
+	events := pool.Broker.Events()
+	for {
+		select {
+		case <-pool.shutdown:
+			return
+		case event, ok := <-events:
+			if !ok {
+				pool.logger.Warn().Msg("Broker event stream closed")
+				return
+			}
+			if event.Origin == pool.instanceID {
+				pool.unacked.Add(-1)
+			}
+			pool.handleEvent(event)
+		}
+	}
+}
+
+func (pool *Pool) handleEvent(event *Event) {
+	defer func() {
+		if r := recover(); r != nil {
+			pool.logger.Error().
+				Interface("recover", r).
+				Str("kind", event.Kind).
+				Msg("Recovered in handleEvent")
+		}
+	}()
 
-		case message := <-pool.Broadcast:
+	switch event.Kind {
+	case EventBroadcast, EventTyping:
+		// Кадр набора не уходит подключениям автора: см. frame.from.
+		if event.Message == nil && event.MessageRef != 0 {
+			event.Message = pool.loadMessage(event.MessageRef)
+		}
+		if event.Message == nil {
+			break
+		}
+		if event.Kind == EventBroadcast && event.Message.Type == MsgTypeChat && !pool.trackMessage(event.Message.ID) {
+			break
+		}
+		if len(event.Trace) > 0 {
+			_, span := startBroadcastSpan(event)
+			defer span.End()
+		}
+		pool.broadcastMessage(*event.Message)
+	case EventPresence:
+		pool.applyPresence(event)
+	case EventSlowMode:
+		pool.applySlowMode(event.SlowMode)
+	case EventResync:
+		pool.resync()
+	case EventSanction:
+		if event.Sanction != nil {
 			pool.wg.Add(1)
-			go func(msg Message) {
+			go func(sanction domain.Sanction) {
 				defer pool.wg.Done()
-				pool.broadcastMessage(msg)
-			}(message)
-
-		case <-ticker.C:
+				pool.applySanction(sanction)
+			}(*event.Sanction)
+		}
+	case EventNotification:
+		if event.Notification != nil && pool.Notifications != nil {
 			pool.wg.Add(1)
-			go func() {
+			go func(n *notifications.Notification) {
 				defer pool.wg.Done()
-				pool.sendPingToAll()
-			}()
+				pool.deliverNotification(n)
+			}(event.Notification)
 		}
+	default:
+		pool.logger.Warn().Str("kind", event.Kind).Msg("Unknown pool event")
+	}
+}
+
+// loadMessage перечитывает из базы сообщение, которое не поместилось в шину.
+func (pool *Pool) loadMessage(id int64) *Message {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	msg, err := pool.ChatService.GetMessage(ctx, id)
+	if err != nil {
+		pool.logger.Error().Err(err).Int64("message_id", id).Msg("Failed to load referenced message")
+		return nil
+	}
+	frame := pool.chatFrame(msg)
+	return &frame
+}
+
+// trackMessage запоминает самое новое сообщение из брокера и возвращает
+// false для сообщения, которое уже дослал resync.
+func (pool *Pool) trackMessage(id int64) bool {
+	if _, ok := pool.resynced[id]; ok {
+		delete(pool.resynced, id)
+		return false
+	}
+	pool.lastMessageID = max(pool.lastMessageID, id)
+	return true
+}
+
+// resync досылает клиентам сообщения, опубликованные, пока подписка брокера
+// была разорвана (не больше MaxResumeMessages), и перечитывает медленный
+// режим. Правки, реакции и санкции за это время не досылаются, присутствие
+// восстанавливает очередной refreshPresence.
+func (pool *Pool) resync() {
+	// События, опубликованные за время разрыва, уже не вернутся из брокера.
+	pool.unacked.Store(0)
+	pool.applySlowMode(pool.loadSlowMode())
+	if pool.lastMessageID == 0 {
+		pool.logger.Info().Msg("No messages received yet, nothing to resync")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	// Рассылка идёт всем, поэтому скрытые фильтром сообщения не нужны.
+	messages, err := pool.ChatService.GetMessagesAfter(ctx, pool.lastMessageID, MaxResumeMessages, 0)
+	if err != nil {
+		pool.logger.Error().Err(err).Int64("after_id", pool.lastMessageID).Msg("Failed to resync missed messages")
+		return
+	}
+
+	pool.resynced = make(map[int64]struct{}, len(messages))
+	for _, msg := range messages {
+		pool.resynced[msg.ID] = struct{}{}
+		pool.lastMessageID = max(pool.lastMessageID, msg.ID)
+		pool.broadcastMessage(pool.chatFrame(msg))
 	}
+
+	pool.logger.Info().
+		Int("message_count", len(messages)).
+		Int64("last_message_id", pool.lastMessageID).
+		Msg("Resynced messages missed by the broker")
 }
 
 func (pool *Pool) handleNewClient(client *Client) {
@@ -145,9 +468,24 @@ func (pool *Pool) handleNewClient(client *Client) {
 		}
 	}()
 
-	pool.clientMutex.Lock()
-	pool.Clients[client] = true
-	pool.clientMutex.Unlock()
+	if pool.draining.Load() {
+		client.mu.Lock()
+		client.closeCode = websocket.CloseGoingAway
+		client.closeText = reconnectHint
+		client.mu.Unlock()
+		client.closeOnce.Do(func() { close(client.done) })
+		return
+	}
+
+	client.stateMu.Lock()
+	client.replaying = true
+	client.stateMu.Unlock()
+
+	shard := pool.shardFor(client)
+	shard.mu.Lock()
+	shard.clients[client] = struct{}{}
+	shard.mu.Unlock()
+	pool.presenceConnected(client)
 
 	pool.logger.Info().
 		Str("username", client.Username).
@@ -155,42 +493,146 @@ func (pool *Pool) handleNewClient(client *Client) {
 		Msg("New client connected")
 
 	// Отправка истории сообщений
-	go func(c *Client) {
-		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-		defer cancel()
+	pool.wg.Add(1)
+	go func() {
+		defer pool.wg.Done()
+		pool.sendHistory(client)
+	}()
+}
 
-		messages, err := pool.ChatService.GetRecentMessages(ctx, 50)
-		if err != nil {
-			pool.logger.Error().
-				Err(err).
+// sendHistory отправляет клиенту историю чата в хронологическом порядке,
+// а затем накопленные уведомления. Клиенту с resumeAfter досылаются только
+// сообщения новее этого ID; если их больше MaxResumeMessages, приходят
+// последние из них, а перед ними — кадр MsgTypeGap. Скрытые фильтром
+// сообщения самого клиента в истории есть: для него они опубликованы.
+func (pool *Pool) sendHistory(c *Client) {
+	lastID := c.resumeAfter
+	defer func() { c.finishReplay(lastID) }()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	var messages []*domain.Message
+	var err error
+	if c.resumeAfter > 0 {
+		messages, err = pool.ChatService.GetMessagesAfter(ctx, c.resumeAfter, MaxResumeMessages+1, c.UserID)
+	} else {
+		messages, err = pool.ChatService.GetRecentMessages(ctx, 50, c.UserID)
+		// GetRecentMessages отдаёт новые первыми.
+		slices.Reverse(messages)
+	}
+	if err != nil {
+		pool.logger.Error().
+			Err(err).
+			Str("username", c.Username).
+			Int64("user_id", c.UserID).
+			Msg("Failed to get message history")
+		return
+	}
+
+	history := make([]Message, 0, len(messages)+1)
+	if c.resumeAfter > 0 && len(messages) > MaxResumeMessages {
+		messages = messages[len(messages)-MaxResumeMessages:]
+		history = append(history, Message{
+			Type:      MsgTypeGap,
+			Content:   "too many messages missed, older history was skipped",
+			Sender:    "system",
+			Timestamp: time.Now().Unix(),
+			MessageID: c.resumeAfter,
+		})
+		pool.logger.Info().
+			Str("username", c.Username).
+			Int64("user_id", c.UserID).
+			Int64("since", c.resumeAfter).
+			Msg("Resume gap, missed messages exceed cap")
+	}
+	for _, msg := range messages {
+		history = append(history, pool.chatFrame(msg))
+	}
+
+	for _, msg := range history {
+		if !c.sendWait(msg) {
+			pool.logger.Warn().
 				Str("username", c.Username).
 				Int64("user_id", c.UserID).
-				Msg("Failed to get message history")
+				Msg("Client send timeout")
 			return
 		}
+		if msg.Type == MsgTypeChat {
+			lastID = msg.ID
+		}
+	}
 
-		for _, msg := range messages {
-			wsMsg := Message{
-				Type:      MsgTypeChat,
-				Content:   msg.Content,
-				Sender:    msg.Username,
-				Timestamp: parseTime(msg.CreatedAt).Unix(),
-				UserID:    msg.UserID,
-			}
+	if interval := pool.SlowMode(); interval > 0 {
+		c.sendWait(slowModeFrame(interval))
+	}
+	pool.sendPendingNotifications(c)
+}
 
-			select {
-			case c.Send <- wsMsg:
-			case <-c.done:
-				return
-			case <-time.After(100 * time.Millisecond):
-				pool.logger.Warn().
-					Str("username", c.Username).
-					Int64("user_id", c.UserID).
-					Msg("Client send timeout")
-				return
-			}
+// holdBack откладывает рассылку, пока клиенту отправляется история,
+// чтобы живые сообщения не обогнали её. Возвращает true, если кадр отложен.
+func (c *Client) holdBack(f *frame) bool {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	if !c.replaying {
+		return false
+	}
+	c.pending = append(c.pending, f)
+	return true
+}
+
+// finishReplay ставит в очередь отложенные рассылки, пропуская сообщения
+// чата, уже попавшие в историю (ID не больше lastID).
+func (c *Client) finishReplay(lastID int64) {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	for _, f := range c.pending {
+		if f.msgType == MsgTypeChat && f.id <= lastID {
+			continue
 		}
-	}(client)
+		if !c.Pool.offer(c, f) {
+			break
+		}
+	}
+	c.pending = nil
+	c.replaying = false
+}
+
+// chatFrame собирает кадр MsgTypeChat из сохранённого сообщения.
+func (pool *Pool) chatFrame(msg *domain.Message) Message {
+	frame := Message{
+		ID:        msg.ID,
+		Type:      MsgTypeChat,
+		Content:   msg.Content,
+		Sender:    msg.Username,
+		Timestamp: parseTime(msg.CreatedAt).Unix(),
+		UserID:    msg.UserID,
+		AvatarURL: avatar.URL(pool.AvatarBaseURL, msg.UserID),
+		Deleted:   msg.Deleted,
+		Reactions: msg.Reactions,
+		ReplyTo:   msg.ReplyTo,
+		Quote:     msg.Quote,
+	}
+	if msg.EditedAt != "" {
+		frame.EditedAt = parseTime(msg.EditedAt).Unix()
+	}
+	return frame
+}
+
+// PublishMessage рассылает всем клиентам только что сохранённое сообщение чата.
+// Контекст трассы из ctx уходит вместе с событием, и span рассылки ссылается
+// на него. Возвращает false, если очередь рассылки переполнена.
+func (pool *Pool) PublishMessage(ctx context.Context, msg *domain.Message) bool {
+	frame := pool.chatFrame(msg)
+	event := &Event{Kind: EventBroadcast, Message: &frame, Trace: traceCarrier(ctx)}
+	select {
+	case pool.outbox <- event:
+		return true
+	case <-time.After(100 * time.Millisecond):
+		return false
+	}
 }
 
 func parseTime(timeStr string) time.Time {
@@ -213,29 +655,15 @@ func (pool *Pool) handleDisconnect(client *Client) {
 	}()
 
 	client.closeOnce.Do(func() {
+		// Кадр закрытия отправляет и соединение закрывает писатель клиента
+		// (Write или Stream), увидев закрытый done.
 		close(client.done)
-		client.mu.Lock()
-		defer client.mu.Unlock()
-
-		if client.Conn != nil {
-			err := client.Conn.WriteControl(
-				websocket.CloseMessage,
-				websocket.FormatCloseMessage(websocket.CloseNormalClosure, ""),
-				time.Now().Add(WriteTimeout),
-			)
-			if err != nil {
-				pool.logger.Warn().
-					Err(err).
-					Str("username", client.Username).
-					Int64("user_id", client.UserID).
-					Msg("Failed to send close message")
-			}
-			client.Conn.Close()
-		}
 
-		pool.clientMutex.Lock()
-		delete(pool.Clients, client)
-		pool.clientMutex.Unlock()
+		shard := pool.shardFor(client)
+		shard.mu.Lock()
+		delete(shard.clients, client)
+		shard.mu.Unlock()
+		pool.presenceDisconnected(client)
 
 		pool.logger.Info().
 			Str("username", client.Username).
@@ -244,68 +672,51 @@ func (pool *Pool) handleDisconnect(client *Client) {
 	})
 }
 
+// broadcastMessage сериализует кадр один раз и передаёт его воркерам шардов.
+// Если воркеры не успевают, вызывающий ждёт: так перегрузка доходит до брокера,
+// а не теряется молча.
 func (pool *Pool) broadcastMessage(msg Message) {
-	defer func() {
-		if r := recover(); r != nil {
-			pool.logger.Error().
-				Interface("recover", r).
-				Msg("Recovered in broadcastMessage")
-		}
-	}()
-
-	pool.clientMutex.RLock()
-	defer pool.clientMutex.RUnlock()
+	f, err := encodeFrame(msg)
+	if err != nil {
+		pool.logger.Error().Err(err).Int("type", msg.Type).Msg("Failed to encode broadcast")
+		return
+	}
 
-	for client := range pool.Clients {
+	for _, shard := range pool.shards {
 		select {
-		case client.Send <- msg:
-		case <-client.done:
-		default:
-			go func(c *Client) {
-				select {
-				case pool.Unregister <- c:
-				case <-time.After(100 * time.Millisecond):
-				}
-			}(client)
+		case shard.in <- f:
+		case <-pool.shutdown:
+			return
 		}
 	}
 }
 
-func (pool *Pool) sendPingToAll() {
-	defer func() {
-		if r := recover(); r != nil {
-			pool.logger.Error().
-				Interface("recover", r).
-				Msg("Recovered in sendPingToAll")
-		}
-	}()
-
-	pool.clientMutex.RLock()
-	defer pool.clientMutex.RUnlock()
-
-	for client := range pool.Clients {
-		go func(c *Client) {
-			c.mu.Lock()
-			defer c.mu.Unlock()
-
-			if err := c.Conn.WriteControl(
-				websocket.PingMessage,
-				nil,
-				time.Now().Add(WriteTimeout),
-			); err != nil {
-				pool.logger.Warn().
-					Err(err).
-					Str("username", c.Username).
-					Int64("user_id", c.UserID).
-					Msg("Failed to send ping")
+// disconnect закрывает соединение клиента с указанным кодом, не блокируя
+// вызывающего (тот может держать мьютекс шарда). Возвращает false, если
+// клиент уже отключается.
+func (pool *Pool) disconnect(c *Client, code int, text string) bool {
+	c.mu.Lock()
+	if c.closeCode != 0 {
+		c.mu.Unlock()
+		return false
+	}
+	c.closeCode = code
+	c.closeText = text
+	c.mu.Unlock()
 
-				select {
-				case pool.Unregister <- c:
-				case <-time.After(100 * time.Millisecond):
-				}
+	select {
+	case pool.Unregister <- c:
+	case <-c.done:
+	default:
+		go func() {
+			select {
+			case pool.Unregister <- c:
+			case <-c.done:
+			case <-pool.shutdown:
 			}
-		}(client)
+		}()
 	}
+	return true
 }
 
 func (c *Client) Read(chatService service.ChatService) {
@@ -315,7 +726,7 @@ func (c *Client) Read(chatService service.ChatService) {
 				Interface("recover", r).
 				Msg("Recovered in client read")
 		}
-		c.Pool.Unregister <- c
+		c.Pool.unregister(c)
 	}()
 
 	c.Conn.SetReadLimit(MaxMsgSize)
@@ -348,74 +759,266 @@ func (c *Client) Read(chatService service.ChatService) {
 			continue
 		}
 
-		if c.ReadOnly || len(msg.Content) == 0 || len(msg.Content) > 500 {
+		switch msg.Type {
+		case MsgTypeReport:
+			c.handleReport(msg)
+			continue
+		case MsgTypeTyping:
+			c.handleTyping()
+			continue
+		case MsgTypeEdit:
+			c.handleEdit(msg)
+			continue
+		case MsgTypeDelete:
+			c.handleDelete(msg)
+			continue
+		case MsgTypeReaction:
+			c.handleReaction(msg)
+			continue
+		}
+
+		readOnly := c.IsReadOnly()
+		if readOnly || len(msg.Content) == 0 || len(msg.Content) > 500 {
+			if readOnly && len(msg.Content) > 0 {
+				c.sendSystem("you cannot send messages right now")
+			}
 			c.logger.Debug().
-				Bool("read_only", c.ReadOnly).
+				Bool("read_only", readOnly).
 				Int("content_length", len(msg.Content)).
 				Msg("Message ignored due to restrictions")
 			continue
 		}
 
-		msg.Timestamp = time.Now().Unix()
-		msg.Sender = c.Username
-		msg.UserID = c.UserID
+		refund, flood := c.Pool.CheckFlood(c.UserID, c.Role, msg.Content)
+		if flood != nil {
+			c.rejectFlood(flood, time.Now())
+			continue
+		}
 
+		c.receiveMessage(chatService, msg, refund)
+	}
+}
+
+// receiveMessage сохраняет сообщение чата и ставит его в рассылку. Каждое
+// сообщение начинает свою трассу (span chat.receive): сохранение идёт в ней,
+// а span рассылки ссылается на неё. Если сообщение не сохранено, refund
+// возвращает бюджет, учтённый CheckFlood.
+func (c *Client) receiveMessage(chatService service.ChatService, msg Message, refund func()) {
+	ctx, span := tracer.Start(context.Background(), "chat.receive",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithAttributes(attribute.Int64("chat.user_id", c.UserID)),
+	)
+	defer span.End()
+	logger := c.logger.With().Ctx(ctx).Logger()
+
+	domainMsg := &domain.Message{
+		Content:  msg.Content,
+		Username: c.Username,
+		UserID:   c.UserID,
+		ReplyTo:  msg.ReplyTo,
+	}
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+	if err := chatService.ProcessMessage(ctx, domainMsg); err != nil {
+		refund()
+		logger.Warn().
+			Err(err).
+			Msg("Failed to process message")
+		var rejected *domain.ContentRejectedError
+		if errors.As(err, &rejected) {
+			c.send(Message{
+				Type:      MsgTypeSystem,
+				Content:   "your message was rejected by the spam filter",
+				Sender:    "system",
+				Timestamp: time.Now().Unix(),
+				Reason:    strings.Join(rejected.Reasons, ","),
+			})
+			return
+		}
+		c.sendSystem(err.Error())
+		return
+	}
+	span.SetAttributes(attribute.Int64("chat.message_id", domainMsg.ID))
+
+	// Задержанное фильтром сообщение ждёт модератора, теневое видит только
+	// автор: ему оно приходит как обычное.
+	switch {
+	case domainMsg.Held:
+		c.sendSystem("your message is awaiting moderator approval")
+		return
+	case domainMsg.Hidden:
+		c.send(c.Pool.chatFrame(domainMsg))
+		return
+	}
+
+	if !c.Pool.PublishMessage(ctx, domainMsg) {
+		logger.Warn().Msg("Broadcast queue full")
+		return
+	}
+	logger.Debug().
+		Str("content", domainMsg.Content).
+		Msg("Message broadcasted")
+}
+
+// handleReport принимает жалобу на сообщение чата и отвечает отправителю
+// системным кадром; остальным клиентам жалоба не рассылается.
+func (c *Client) handleReport(msg Message) {
+	if c.UserID == 0 || c.Pool.ReportService == nil {
+		c.sendSystem("reports are not available")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	_, err := c.Pool.ReportService.CreateReport(ctx, &domain.Report{
+		TargetType: domain.ReportTargetMessage,
+		TargetID:   msg.MessageID,
+		ReporterID: c.UserID,
+		Reason:     msg.Reason,
+		Details:    msg.Content,
+	})
+	switch {
+	case errors.Is(err, domain.ErrAlreadyReported):
+		c.sendSystem("you have already reported this message")
+	case errors.Is(err, domain.ErrTargetNotFound):
+		c.sendSystem("message not found")
+	case err != nil:
+		c.logger.Warn().
+			Err(err).
+			Int64("message_id", msg.MessageID).
+			Msg("Failed to create report")
+		c.sendSystem("failed to submit report")
+	default:
+		c.logger.Info().
+			Int64("message_id", msg.MessageID).
+			Str("reason", msg.Reason).
+			Msg("Message reported")
+		c.sendSystem("report received")
+	}
+}
+
+// sendSystem отправляет системный кадр только этому клиенту. При полной
+// очереди действует политика медленного потребителя пула.
+func (c *Client) sendSystem(content string) {
+	c.send(Message{
+		Type:      MsgTypeSystem,
+		Content:   content,
+		Sender:    "system",
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// send ставит кадр в очередь только этого клиента.
+func (c *Client) send(msg Message) bool {
+	f, err := encodeFrame(msg)
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Failed to encode frame")
+		return false
+	}
+	return c.Pool.offer(c, f)
+}
+
+// sendWait ставит кадр в очередь клиента, дожидаясь места до WriteTimeout,
+// вместо политики медленного потребителя: так отправляются история и
+// накопленные уведомления, которые длиннее очереди.
+func (c *Client) sendWait(msg Message) bool {
+	f, err := encodeFrame(msg)
+	if err != nil {
+		c.logger.Error().Err(err).Msg("Failed to encode frame")
+		return false
+	}
+
+	timer := time.NewTimer(WriteTimeout)
+	defer timer.Stop()
+	for !c.queue.push(f) {
 		select {
-		case c.Pool.Broadcast <- msg:
-			c.logger.Debug().
-				Str("content", msg.Content).
-				Msg("Message broadcasted")
-		case <-time.After(100 * time.Millisecond):
-			c.logger.Warn().Msg("Broadcast queue full")
+		case <-c.queue.space:
+		case <-c.done:
+			return false
+		case <-timer.C:
+			return false
 		}
 	}
+	return true
 }
 
 func (c *Client) Write() {
 	ticker := time.NewTicker(PingInterval)
 	defer func() {
 		ticker.Stop()
-		c.Pool.Unregister <- c
+		c.closeConn()
+		close(c.finished)
+		c.Pool.unregister(c)
 	}()
 
-	for {
-		select {
-		case msg, ok := <-c.Send:
-			c.mu.Lock()
-			if !ok {
-				c.Conn.WriteMessage(websocket.CloseMessage, nil)
-				c.mu.Unlock()
-				return
+	// writeFrames пишет кадры, каждый — с WriteTimeout, но не позже deadline,
+	// если он задан.
+	writeFrames := func(frames []*frame, deadline time.Time) bool {
+		for _, f := range frames {
+			frameDeadline := time.Now().Add(WriteTimeout)
+			if !deadline.IsZero() && deadline.Before(frameDeadline) {
+				frameDeadline = deadline
 			}
-
-			c.Conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
-			if err := c.Conn.WriteJSON(msg); err != nil {
+			c.Conn.SetWriteDeadline(frameDeadline)
+			if err := c.Conn.WriteMessage(websocket.TextMessage, f.data); err != nil {
 				c.logger.Warn().
 					Err(err).
 					Msg("Failed to write message to WebSocket")
-				c.mu.Unlock()
+				return false
+			}
+		}
+		return true
+	}
+
+	var batch []*frame
+	for {
+		select {
+		case <-c.queue.ready:
+			batch = c.queue.drain(batch[:0])
+			if !writeFrames(batch, time.Time{}) {
 				return
 			}
-			c.mu.Unlock()
 
 		case <-ticker.C:
-			c.mu.Lock()
 			c.Conn.SetWriteDeadline(time.Now().Add(WriteTimeout))
 			if err := c.Conn.WriteMessage(websocket.PingMessage, nil); err != nil {
 				c.logger.Warn().
 					Err(err).
 					Msg("Failed to send ping")
-				c.mu.Unlock()
 				return
 			}
-			c.mu.Unlock()
 
 		case <-c.done:
+			writeFrames(c.flushOnClose(), time.Now().Add(WriteTimeout))
 			return
 		}
 	}
 }
 
+// closeConn отправляет кадр закрытия с кодом, заданным Kick, политикой
+// медленного потребителя или Shutdown (по умолчанию 1000), и закрывает соединение.
+func (c *Client) closeConn() {
+	c.mu.Lock()
+	code, text := websocket.CloseNormalClosure, ""
+	if c.closeCode != 0 {
+		code, text = c.closeCode, c.closeText
+	}
+	c.mu.Unlock()
+
+	err := c.Conn.WriteControl(
+		websocket.CloseMessage,
+		websocket.FormatCloseMessage(code, text),
+		time.Now().Add(WriteTimeout),
+	)
+	if err != nil && !errors.Is(err, websocket.ErrCloseSent) {
+		c.logger.Debug().
+			Err(err).
+			Msg("Failed to send close message")
+	}
+	c.Conn.Close()
+}
+
 func Upgrade(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 	conn, err := upgrader.Upgrade(w, r, nil)
 	if err != nil {
@@ -433,15 +1036,18 @@ func Upgrade(w http.ResponseWriter, r *http.Request) (*websocket.Conn, error) {
 	return conn, nil
 }
 
-func NewClient(conn *websocket.Conn, pool *Pool, username string, userID int64, readOnly bool) *Client {
+func NewClient(conn *websocket.Conn, pool *Pool, username string, userID int64, role string, readOnly bool) *Client {
 	return &Client{
 		Conn:     conn,
 		Pool:     pool,
 		Username: username,
 		UserID:   userID,
+		Role:     role,
 		ReadOnly: readOnly,
-		Send:     make(chan Message, BufferSize),
+		id:       clientSeq.Add(1),
+		queue:    newSendQueue(BufferSize),
 		done:     make(chan struct{}),
+		finished: make(chan struct{}),
 		logger: log.With().
 			Str("component", "websocket_client").
 			Str("username", username).
@@ -449,3 +1055,9 @@ func NewClient(conn *websocket.Conn, pool *Pool, username string, userID int64,
 			Logger(),
 	}
 }
+
+// ResumeAfter задаёт ID последнего сообщения, полученного клиентом до
+// переподключения. Вызывается до регистрации клиента в пуле.
+func (c *Client) ResumeAfter(messageID int64) {
+	c.resumeAfter = messageID
+}
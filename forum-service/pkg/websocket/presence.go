@@ -0,0 +1,267 @@
+package websocket
+
+import (
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/auth-service/pkg/avatar"
+)
+
+const (
+	// PresenceGrace — сколько ждать после закрытия последнего подключения
+	// пользователя, прежде чем объявить, что он вышел. Быстрое переподключение
+	// (перезагрузка страницы, смена сети) не порождает пары left/joined.
+	PresenceGrace = 5 * time.Second
+	// TypingInterval — не чаще одного индикатора набора от клиента за интервал.
+	TypingInterval = 2 * time.Second
+	// PresenceStale — через сколько без отчётов подключения экземпляра
+	// перестают учитываться; экземпляры отчитываются каждые PingInterval.
+	PresenceStale = 3 * PingInterval
+	// PresenceBatch — сколько счётчиков помещается в одно событие refreshPresence,
+	// чтобы оно не превышало NotifyPayloadLimit.
+	PresenceBatch = 50
+)
+
+const (
+	PresenceJoined = "joined"
+	PresenceLeft   = "left"
+)
+
+// OnlineUser — пользователь, у которого есть хотя бы одно живое подключение.
+type OnlineUser struct {
+	UserID    int64  `json:"user_id"`
+	Username  string `json:"username"`
+	AvatarURL string `json:"avatar_url"`
+}
+
+// presenceEntry — присутствие пользователя на всех экземплярах: число
+// подключений по ID экземпляра и время последнего отчёта каждого из них.
+type presenceEntry struct {
+	username   string
+	conns      map[string]int
+	seen       map[string]time.Time
+	leaveTimer *time.Timer
+}
+
+// localPresence — подключения пользователя на этом экземпляре.
+type localPresence struct {
+	username string
+	conns    int
+}
+
+func (e *presenceEntry) total() int {
+	total := 0
+	for _, n := range e.conns {
+		total += n
+	}
+	return total
+}
+
+// presenceConnected учитывает новое локальное подключение и сообщает
+// остальным экземплярам число подключений пользователя на этом.
+// Гости (UserID == 0) в присутствии не участвуют.
+func (pool *Pool) presenceConnected(client *Client) {
+	if client.UserID == 0 {
+		return
+	}
+	pool.changeLocalPresence(client.UserID, client.Username, 1)
+}
+
+// presenceDisconnected снимает локальное подключение с учёта.
+func (pool *Pool) presenceDisconnected(client *Client) {
+	if client.UserID == 0 {
+		return
+	}
+	pool.changeLocalPresence(client.UserID, client.Username, -1)
+}
+
+// changeLocalPresence меняет счётчик локальных подключений и ставит его
+// в outbox под localPresenceMu, чтобы счётчики одного пользователя
+// не обгоняли друг друга в брокере.
+func (pool *Pool) changeLocalPresence(userID int64, username string, delta int) {
+	pool.localPresenceMu.Lock()
+	defer pool.localPresenceMu.Unlock()
+
+	n := pool.localPresence[userID].conns + delta
+	if n > 0 {
+		pool.localPresence[userID] = localPresence{username: username, conns: n}
+	} else {
+		n = 0
+		delete(pool.localPresence, userID)
+	}
+
+	pool.enqueue(&Event{
+		Kind:     EventPresence,
+		Presence: []PresenceCount{{UserID: userID, Username: username, Connections: n}},
+	})
+}
+
+// refreshPresence повторно публикует локальные счётчики пачками по
+// PresenceBatch и забывает экземпляры, которые не отчитывались дольше PresenceStale
+// (например, упавшие).
+func (pool *Pool) refreshPresence() {
+	pool.localPresenceMu.Lock()
+	if len(pool.localPresence) > 0 {
+		counts := make([]PresenceCount, 0, len(pool.localPresence))
+		for userID, local := range pool.localPresence {
+			counts = append(counts, PresenceCount{UserID: userID, Username: local.username, Connections: local.conns})
+		}
+		for batch := range slices.Chunk(counts, PresenceBatch) {
+			pool.enqueue(&Event{Kind: EventPresence, Presence: batch})
+		}
+	}
+	pool.localPresenceMu.Unlock()
+
+	now := time.Now()
+	pool.presenceMu.Lock()
+	defer pool.presenceMu.Unlock()
+	for userID, entry := range pool.presence {
+		for origin, seen := range entry.seen {
+			if now.Sub(seen) > PresenceStale {
+				delete(entry.conns, origin)
+				delete(entry.seen, origin)
+			}
+		}
+		if entry.total() == 0 && entry.leaveTimer == nil {
+			pool.scheduleLeave(userID, entry)
+		}
+	}
+}
+
+// applyPresence обновляет присутствие по событию брокера.
+func (pool *Pool) applyPresence(event *Event) {
+	for _, count := range event.Presence {
+		pool.applyPresenceCount(event.Origin, count)
+	}
+}
+
+// applyPresenceCount учитывает счётчик одного пользователя. Вход объявляется
+// своим клиентам, когда у пользователя появляется первое подключение на любом
+// экземпляре; выход — спустя PresenceGrace после закрытия последнего, так что
+// быстрое переподключение (перезагрузка страницы, смена сети) не порождает
+// пары left/joined. Все экземпляры видят одни и те же события и приходят
+// к одним и тем же объявлениям.
+func (pool *Pool) applyPresenceCount(origin string, count PresenceCount) {
+	pool.presenceMu.Lock()
+
+	entry, ok := pool.presence[count.UserID]
+	if !ok {
+		if count.Connections == 0 {
+			pool.presenceMu.Unlock()
+			return
+		}
+		entry = &presenceEntry{
+			username: count.Username,
+			conns:    make(map[string]int),
+			seen:     make(map[string]time.Time),
+		}
+		pool.presence[count.UserID] = entry
+	}
+	if count.Username != "" {
+		entry.username = count.Username
+	}
+	if count.Connections > 0 {
+		entry.conns[origin] = count.Connections
+		entry.seen[origin] = time.Now()
+	} else {
+		delete(entry.conns, origin)
+		delete(entry.seen, origin)
+	}
+
+	if entry.total() == 0 {
+		if entry.leaveTimer == nil {
+			pool.scheduleLeave(count.UserID, entry)
+		}
+		pool.presenceMu.Unlock()
+		return
+	}
+
+	reconnected := entry.leaveTimer != nil && entry.leaveTimer.Stop()
+	entry.leaveTimer = nil
+	pool.presenceMu.Unlock()
+
+	if reconnected {
+		pool.logger.Debug().Int64("user_id", count.UserID).Msg("Quick reconnect, presence unchanged")
+	}
+	if !ok {
+		pool.announcePresence(count.UserID, entry.username, PresenceJoined)
+	}
+}
+
+// scheduleLeave объявляет выход пользователя через PresenceGrace, если за это
+// время у него не появится подключений. Вызывается под presenceMu.
+func (pool *Pool) scheduleLeave(userID int64, entry *presenceEntry) {
+	var timer *time.Timer
+	timer = time.AfterFunc(PresenceGrace, func() {
+		pool.presenceMu.Lock()
+		current, ok := pool.presence[userID]
+		if !ok || current.leaveTimer != timer || current.total() > 0 {
+			pool.presenceMu.Unlock()
+			return
+		}
+		delete(pool.presence, userID)
+		pool.presenceMu.Unlock()
+
+		pool.announcePresence(userID, current.username, PresenceLeft)
+	})
+	entry.leaveTimer = timer
+}
+
+// announcePresence рассылает кадр присутствия только клиентам этого
+// экземпляра: остальные экземпляры объявляют то же событие сами.
+func (pool *Pool) announcePresence(userID int64, username, event string) {
+	pool.broadcastMessage(Message{
+		Type:      MsgTypePresence,
+		Event:     event,
+		Sender:    username,
+		UserID:    userID,
+		AvatarURL: avatar.URL(pool.AvatarBaseURL, userID),
+		Timestamp: time.Now().Unix(),
+	})
+}
+
+// OnlineUsers возвращает авторизованных пользователей с живыми подключениями
+// на любом экземпляре, отсортированных по имени.
+func (pool *Pool) OnlineUsers() []OnlineUser {
+	pool.presenceMu.Lock()
+	users := make([]OnlineUser, 0, len(pool.presence))
+	for userID, entry := range pool.presence {
+		users = append(users, OnlineUser{
+			UserID:    userID,
+			Username:  entry.username,
+			AvatarURL: avatar.URL(pool.AvatarBaseURL, userID),
+		})
+	}
+	pool.presenceMu.Unlock()
+
+	sort.Slice(users, func(i, j int) bool { return users[i].Username < users[j].Username })
+	return users
+}
+
+// handleTyping рассылает остальным клиентам эфемерный индикатор набора.
+// Кадры сверх одного за TypingInterval молча отбрасываются; в базу ничего не пишется.
+func (c *Client) handleTyping() {
+	if c.UserID == 0 || c.IsReadOnly() {
+		return
+	}
+
+	now := time.Now()
+	c.stateMu.Lock()
+	if now.Sub(c.lastTyping) < TypingInterval {
+		c.stateMu.Unlock()
+		return
+	}
+	c.lastTyping = now
+	c.stateMu.Unlock()
+
+	c.Pool.enqueue(&Event{
+		Kind: EventTyping,
+		Message: &Message{
+			Type:      MsgTypeTyping,
+			Sender:    c.Username,
+			UserID:    c.UserID,
+			Timestamp: now.Unix(),
+		},
+	})
+}
@@ -0,0 +1,60 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+)
+
+// handleReaction переключает реакцию клиента на сообщение чата (MessageID, Emoji).
+func (c *Client) handleReaction(msg Message) {
+	if c.UserID == 0 || c.IsReadOnly() || c.Pool.ReactionService == nil {
+		c.sendSystem("you cannot react right now")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	update, err := c.Pool.ReactionService.ToggleReaction(ctx, domain.ReactionTargetMessage, msg.MessageID, c.UserID, msg.Emoji)
+	switch {
+	case errors.Is(err, domain.ErrInvalidReaction):
+		c.sendSystem(err.Error())
+		return
+	case errors.Is(err, domain.ErrMessageNotFound):
+		c.sendSystem("message not found")
+		return
+	case err != nil:
+		c.logger.Warn().
+			Err(err).
+			Int64("message_id", msg.MessageID).
+			Msg("Failed to toggle reaction")
+		c.sendSystem("failed to react to message")
+		return
+	}
+
+	c.Pool.BroadcastReaction(update)
+}
+
+// BroadcastReaction рассылает всем клиентам новые итоги реакций. Для сообщений
+// чата цель передаётся в MessageID, для постов — в PostID. Как и правки,
+// итоги не отбрасываются при переполненной очереди.
+func (pool *Pool) BroadcastReaction(update *domain.ReactionUpdate) {
+	msg := Message{
+		Type:      MsgTypeReaction,
+		UserID:    update.UserID,
+		Emoji:     update.Emoji,
+		Added:     update.Added,
+		Reactions: update.Reactions,
+		Timestamp: time.Now().Unix(),
+	}
+	if update.TargetType == domain.ReactionTargetPost {
+		msg.PostID = update.TargetID
+	} else {
+		msg.MessageID = update.TargetID
+	}
+
+	pool.enqueue(&Event{Kind: EventBroadcast, Message: &msg})
+}
@@ -0,0 +1,150 @@
+package websocket
+
+import (
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+	"github.com/gorilla/websocket"
+)
+
+func (c *Client) IsReadOnly() bool {
+	c.stateMu.RLock()
+	defer c.stateMu.RUnlock()
+	return c.ReadOnly
+}
+
+func (c *Client) SetReadOnly(readOnly bool) {
+	c.stateMu.Lock()
+	c.ReadOnly = readOnly
+	c.stateMu.Unlock()
+}
+
+// Mute переводит клиента в режим только чтения на время мьюта. Мьюты
+// учитываются по ID санкции: право писать возвращается, только когда истёк
+// или снят последний из них.
+func (c *Client) Mute(sanction domain.Sanction) {
+	until := sanction.Expiry()
+	c.stateMu.Lock()
+	if c.mutes == nil {
+		c.mutes = make(map[int64]time.Time)
+	}
+	c.mutes[sanction.ID] = until
+	c.ReadOnly = true
+	c.stateMu.Unlock()
+
+	if until.IsZero() {
+		c.sendSystem("you have been muted: " + sanction.Reason)
+		return
+	}
+
+	c.sendSystem("you have been muted until " + until.Format(time.RFC3339) + ": " + sanction.Reason)
+	time.AfterFunc(time.Until(until), func() {
+		select {
+		case <-c.done:
+		default:
+			if c.endMute(sanction.ID, until) {
+				c.sendSystem("your mute has expired")
+			}
+		}
+	})
+}
+
+// Unmute снимает отозванный мьют. Клиент снова может писать, только если
+// других действующих мьютов у него нет.
+func (c *Client) Unmute(sanctionID int64) {
+	c.stateMu.RLock()
+	until, ok := c.mutes[sanctionID]
+	c.stateMu.RUnlock()
+
+	if ok && c.endMute(sanctionID, until) {
+		c.sendSystem("your mute has been lifted")
+	}
+}
+
+// endMute убирает мьют, если его срок с тех пор не менялся (повторная выдача
+// той же санкции заводит новый таймер), и сообщает, вернулось ли право писать.
+func (c *Client) endMute(sanctionID int64, until time.Time) bool {
+	c.stateMu.Lock()
+	defer c.stateMu.Unlock()
+
+	current, ok := c.mutes[sanctionID]
+	if !ok || !current.Equal(until) {
+		return false
+	}
+	delete(c.mutes, sanctionID)
+
+	now := time.Now()
+	for id, expiresAt := range c.mutes {
+		if !expiresAt.IsZero() && !expiresAt.After(now) {
+			delete(c.mutes, id)
+		}
+	}
+	if len(c.mutes) > 0 || !c.ReadOnly {
+		return false
+	}
+	c.ReadOnly = false
+	return true
+}
+
+// Kick закрывает соединение с кодом 1008 (policy violation) и причиной.
+func (c *Client) Kick(reason string) {
+	c.mu.Lock()
+	c.closeCode = websocket.ClosePolicyViolation
+	c.closeText = reason
+	c.mu.Unlock()
+
+	select {
+	case c.Pool.Unregister <- c:
+	case <-c.done:
+	case <-time.After(100 * time.Millisecond):
+		c.logger.Warn().Msg("Unregister queue full, kick delayed")
+		go c.Pool.unregister(c)
+	}
+}
+
+// ApplySanction применяет выданную или отозванную санкцию ко всем живым
+// подключениям пользователя на всех экземплярах: бан разрывает соединения,
+// мьют снимает право писать.
+func (pool *Pool) ApplySanction(sanction domain.Sanction) {
+	pool.publish(&Event{Kind: EventSanction, Sanction: &sanction})
+}
+
+// applySanction применяет санкцию к подключениям пользователя на этом экземпляре.
+func (pool *Pool) applySanction(sanction domain.Sanction) {
+	logger := pool.logger.With().
+		Int64("sanction_id", sanction.ID).
+		Int64("user_id", sanction.UserID).
+		Str("type", sanction.Type).
+		Logger()
+
+	clients := pool.clientsByUser(sanction.UserID)
+	active := sanction.IsActive(time.Now())
+
+	for _, client := range clients {
+		switch {
+		case sanction.Type == domain.SanctionBan && active:
+			client.Kick("banned: " + sanction.Reason)
+		case sanction.Type == domain.SanctionMute && active:
+			client.Mute(sanction)
+		case sanction.Type == domain.SanctionMute && !active:
+			client.Unmute(sanction.ID)
+		case sanction.Type == domain.SanctionPostSuspension && active:
+			client.sendSystem("posting has been suspended for your account: " + sanction.Reason)
+		}
+	}
+
+	logger.Info().
+		Bool("active", active).
+		Int("affected_clients", len(clients)).
+		Msg("Sanction applied to live connections")
+}
+
+func (pool *Pool) clientsByUser(userID int64) []*Client {
+	var clients []*Client
+	pool.forEachClient(func(client *Client) {
+		if client.UserID == userID {
+			clients = append(clients, client)
+		}
+	})
+	return clients
+}
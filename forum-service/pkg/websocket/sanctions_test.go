@@ -0,0 +1,46 @@
+package websocket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/Frozz164/forum-app_v2/forum-service/internal/domain"
+)
+
+func TestMuteOverlapping(t *testing.T) {
+	pool := NewPool(historyless{}, nil, nil, nil, nil, "")
+	client := NewClient(nil, pool, "user", 1, domain.RoleUser, false)
+
+	short := domain.Sanction{ID: 1, Type: domain.SanctionMute, ExpiresAt: time.Now().Add(50 * time.Millisecond).Format(time.RFC3339Nano)}
+	permanent := domain.Sanction{ID: 2, Type: domain.SanctionMute}
+	client.Mute(short)
+	client.Mute(permanent)
+
+	time.Sleep(100 * time.Millisecond)
+	if !client.IsReadOnly() {
+		t.Fatal("expired short mute lifted a permanent one")
+	}
+
+	client.Unmute(3)
+	if !client.IsReadOnly() {
+		t.Fatal("revoking an unknown mute lifted an active one")
+	}
+
+	client.Unmute(permanent.ID)
+	if client.IsReadOnly() {
+		t.Error("client still read-only after its last mute was revoked")
+	}
+}
+
+func TestMuteRevokeKeepsOtherMute(t *testing.T) {
+	pool := NewPool(historyless{}, nil, nil, nil, nil, "")
+	client := NewClient(nil, pool, "user", 1, domain.RoleUser, false)
+
+	client.Mute(domain.Sanction{ID: 1, Type: domain.SanctionMute})
+	client.Mute(domain.Sanction{ID: 2, Type: domain.SanctionMute, ExpiresAt: time.Now().Add(time.Hour).Format(time.RFC3339)})
+
+	client.Unmute(1)
+	if !client.IsReadOnly() {
+		t.Error("revoking one mute lifted another active mute")
+	}
+}
@@ -0,0 +1,142 @@
+package websocket
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"github.com/gorilla/websocket"
+)
+
+// FlushTimeout — сколько при остановке ждать, пока рассылки, уже принятые
+// пулом, дойдут до очередей клиентов.
+const FlushTimeout = 2 * time.Second
+
+// reconnectHint — причина закрытия при остановке сервера: клиенту можно сразу
+// переподключаться (с ?since= или Last-Event-ID) к другому экземпляру.
+var reconnectHint = fmt.Sprintf("server restarting, reconnect in %s", ReconnectDelay)
+
+// Shutdown останавливает пул. Новые клиенты больше не принимаются; рассылки,
+// уже попавшие в пул, доходят до очередей клиентов; каждый клиент дочитывает
+// свою очередь и получает кадр закрытия 1001 (going away) с подсказкой
+// переподключиться. Затем останавливаются воркеры и фоновые задачи пула.
+// Брокер Shutdown не закрывает — это делает тот, кто его создал.
+func (pool *Pool) Shutdown(ctx context.Context) error {
+	if !pool.draining.CompareAndSwap(false, true) {
+		return errors.New("pool is already shutting down")
+	}
+
+	clientCount := pool.ClientCount()
+	pool.logger.Info().
+		Int("clients", clientCount).
+		Msg("Draining WebSocket pool")
+
+	pool.waitIdle(ctx)
+
+	clients := make([]*Client, 0, clientCount)
+	pool.forEachClient(func(c *Client) {
+		clients = append(clients, c)
+	})
+	for _, c := range clients {
+		pool.disconnect(c, websocket.CloseGoingAway, reconnectHint)
+	}
+
+	var err error
+wait:
+	for _, c := range clients {
+		select {
+		case <-c.finished:
+		case <-ctx.Done():
+			err = fmt.Errorf("wait for clients to close: %w", ctx.Err())
+			break wait
+		}
+	}
+
+	// Отключения разослали присутствие; пусть и оно уйдёт в брокер.
+	pool.waitIdle(ctx)
+	close(pool.shutdown)
+
+	if err == nil {
+		err = waitGroup(ctx, &pool.loops, "pool workers")
+	}
+	if err == nil {
+		err = waitGroup(ctx, &pool.wg, "pool background tasks")
+	}
+	if err != nil {
+		pool.logger.Warn().Err(err).Msg("WebSocket pool stopped before draining")
+		return err
+	}
+
+	pool.logger.Info().
+		Int("clients", len(clients)).
+		Msg("WebSocket pool stopped")
+	return nil
+}
+
+// waitIdle ждёт, пока опубликованные этим экземпляром события вернутся из
+// брокера и воркеры шардов разберут свои очереди, но не дольше FlushTimeout.
+func (pool *Pool) waitIdle(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, FlushTimeout)
+	defer cancel()
+
+	ticker := time.NewTicker(10 * time.Millisecond)
+	defer ticker.Stop()
+	for !pool.idle() {
+		select {
+		case <-ctx.Done():
+			pool.logger.Warn().
+				Int64("unacked", pool.unacked.Load()).
+				Msg("Pending broadcasts were not flushed in time")
+			return
+		case <-ticker.C:
+		}
+	}
+}
+
+func (pool *Pool) idle() bool {
+	if len(pool.Broadcast) > 0 || len(pool.outbox) > 0 || pool.unacked.Load() > 0 {
+		return false
+	}
+	for _, shard := range pool.shards {
+		if len(shard.in) > 0 {
+			return false
+		}
+	}
+	return true
+}
+
+// unregister сообщает пулу об отключении клиента; после остановки пула
+// сообщать уже некому.
+func (pool *Pool) unregister(c *Client) {
+	select {
+	case pool.Unregister <- c:
+	case <-pool.shutdown:
+	}
+}
+
+// flushOnClose возвращает кадры, оставшиеся в очереди закрываемого клиента,
+// если сервер останавливается: их стоит дописать перед кадром закрытия.
+// При кике и отключении медленного потребителя очередь отбрасывается.
+func (c *Client) flushOnClose() []*frame {
+	if !c.Pool.draining.Load() {
+		return nil
+	}
+	return c.queue.drain(nil)
+}
+
+func waitGroup(ctx context.Context, wg *sync.WaitGroup, what string) error {
+	done := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("wait for %s: %w", what, ctx.Err())
+	}
+}
@@ -0,0 +1,133 @@
+package websocket
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"time"
+
+	"github.com/rs/zerolog/log"
+)
+
+// eventNames — имена SSE-событий для типов кадров пула.
+var eventNames = map[int]string{
+	MsgTypeChat:         "chat",
+	MsgTypeSystem:       "system",
+	MsgTypeReport:       "report",
+	MsgTypePresence:     "presence",
+	MsgTypeTyping:       "typing",
+	MsgTypeEdit:         "edit",
+	MsgTypeDelete:       "delete",
+	MsgTypeReaction:     "reaction",
+	MsgTypeNotification: "notification",
+	MsgTypeGap:          "gap",
+}
+
+// NewStreamClient создаёт клиента пула без WebSocket-соединения: кадры ему
+// отдаёт Stream в формате Server-Sent Events. lastEventID — ID последнего
+// полученного сообщения чата (0 — прислать недавнюю историю).
+func NewStreamClient(pool *Pool, username string, userID int64, role string, readOnly bool, lastEventID int64) *Client {
+	client := NewClient(nil, pool, username, userID, role, readOnly)
+	client.ResumeAfter(lastEventID)
+	client.logger = log.With().
+		Str("component", "stream_client").
+		Str("username", username).
+		Int64("user_id", userID).
+		Logger()
+	return client
+}
+
+// Stream пишет кадры клиента в w как text/event-stream, пока клиент не будет
+// отключён пулом (кик, бан, остановка сервера) или не завершится ctx запроса. Сообщения чата
+// несут id: с их ID, чтобы браузер вернул его в Last-Event-ID при переподключении.
+func (c *Client) Stream(ctx context.Context, w http.ResponseWriter) {
+	flusher, ok := w.(http.Flusher)
+	if !ok {
+		c.logger.Error().Msg("Response writer does not support flushing")
+		close(c.finished)
+		c.Pool.unregister(c)
+		return
+	}
+
+	ticker := time.NewTicker(PingInterval)
+	defer func() {
+		ticker.Stop()
+		close(c.finished)
+		c.Pool.unregister(c)
+	}()
+
+	// retry подсказывает EventSource, через сколько переподключаться.
+	fmt.Fprintf(w, "retry: %d\n\n", ReconnectDelay.Milliseconds())
+	flusher.Flush()
+
+	var batch []*frame
+	for {
+		select {
+		case <-c.queue.ready:
+			batch = c.queue.drain(batch[:0])
+			for _, f := range batch {
+				if err := writeEvent(w, f); err != nil {
+					c.logger.Warn().
+						Err(err).
+						Msg("Failed to write event to stream")
+					return
+				}
+			}
+			flusher.Flush()
+
+		case <-ticker.C:
+			if _, err := fmt.Fprint(w, ": ping\n\n"); err != nil {
+				c.logger.Warn().
+					Err(err).
+					Msg("Failed to send heartbeat")
+				return
+			}
+			flusher.Flush()
+
+		case <-ctx.Done():
+			return
+
+		case <-c.done:
+			// Остаток очереди дописывается не дольше WriteTimeout.
+			deadline := time.Now().Add(WriteTimeout)
+			for _, f := range c.flushOnClose() {
+				if time.Now().After(deadline) {
+					break
+				}
+				if err := writeEvent(w, f); err != nil {
+					return
+				}
+			}
+			c.mu.Lock()
+			reason := c.closeText
+			c.mu.Unlock()
+			if reason != "" {
+				f, err := encodeFrame(Message{
+					Type:      MsgTypeSystem,
+					Content:   reason,
+					Sender:    "system",
+					Timestamp: time.Now().Unix(),
+				})
+				if err == nil && writeEvent(w, f) == nil {
+					flusher.Flush()
+				}
+			}
+			return
+		}
+	}
+}
+
+// writeEvent пишет один кадр как SSE-событие.
+func writeEvent(w http.ResponseWriter, f *frame) error {
+	if f.id > 0 {
+		if _, err := fmt.Fprintf(w, "id: %d\n", f.id); err != nil {
+			return err
+		}
+	}
+	name, ok := eventNames[f.msgType]
+	if !ok {
+		name = "message"
+	}
+	_, err := fmt.Fprintf(w, "event: %s\ndata: %s\n\n", name, f.data)
+	return err
+}
@@ -0,0 +1,37 @@
+package websocket
+
+import (
+	"context"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var tracer = otel.Tracer("github.com/Frozz164/forum-app_v2/forum-service/pkg/websocket")
+
+// traceCarrier сохраняет контекст трассы ctx для Event.Trace.
+func traceCarrier(ctx context.Context) map[string]string {
+	if !trace.SpanContextFromContext(ctx).IsValid() {
+		return nil
+	}
+	carrier := propagation.MapCarrier{}
+	otel.GetTextMapPropagator().Inject(ctx, carrier)
+	return carrier
+}
+
+// startBroadcastSpan открывает span рассылки события. Рассылка — отдельная
+// трасса, связанная ссылкой со span'ом приёма сообщения: одна рассылка
+// обслуживает тысячи клиентов и не должна растягивать трассу запроса.
+func startBroadcastSpan(event *Event) (context.Context, trace.Span) {
+	origin := otel.GetTextMapPropagator().Extract(context.Background(), propagation.MapCarrier(event.Trace))
+	return tracer.Start(context.Background(), "chat.broadcast",
+		trace.WithSpanKind(trace.SpanKindConsumer),
+		trace.WithLinks(trace.LinkFromContext(origin)),
+		trace.WithAttributes(
+			attribute.Int64("chat.message_id", event.Message.ID),
+			attribute.String("chat.origin", event.Origin),
+		),
+	)
+}
@@ -103,7 +103,7 @@ document.addEventListener('DOMContentLoaded', () => {
                 localStorage.setItem('username', data.username);
                 setTimeout(() => window.location.href = '/', 1500);
             } else {
-                showMessage(data.error || "Registration failed", false);
+                showMessage(data.detail || "Registration failed", false);
             }
         } catch (error) {
             showMessage("Network error. Please try again.", false);
@@ -139,7 +139,7 @@ document.addEventListener('DOMContentLoaded', () => {
                 localStorage.setItem('username', data.username);
                 setTimeout(() => window.location.href = '/', 1500);
             } else {
-                showMessage(data.error || "Login failed", false);
+                showMessage(data.detail || "Login failed", false);
             }
         } catch (error) {
             showMessage("Network error. Please try again.", false);